@@ -1,47 +1,107 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
-	"saas-go-app/internal/db"
+	"saas-go-app/internal/logging"
 	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-// GetAccounts retrieves all accounts
-// @Summary      List all accounts
-// @Description  Get a list of all accounts
+// AccountHandler serves the account endpoints
+type AccountHandler struct {
+	accounts repository.AccountRepository
+}
+
+// NewAccountHandler creates an account handler backed by the given repository
+func NewAccountHandler(accounts repository.AccountRepository) *AccountHandler {
+	return &AccountHandler{accounts: accounts}
+}
+
+// GetAccounts retrieves a page of accounts
+// @Summary      List accounts
+// @Description  Get a page of accounts, newest first by default. Use limit and cursor for keyset pagination, or page and per_page for offset pagination. Page links are returned in the Link header.
 // @Tags         accounts
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   models.Account
-// @Failure      500  {object}  map[string]string
+// @Param        status          query     string  false  "Only accounts with this status"
+// @Param        customer_id     query     int     false  "Only accounts belonging to this customer"
+// @Param        created_after   query     string  false  "Only accounts created at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param        created_before  query     string  false  "Only accounts created before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param        sort            query     string  false  "Sort field, prefix with - for descending (created_at, updated_at, name, status, customer_id, id)"  default(-created_at)
+// @Param        limit           query     int     false  "Page size for cursor pagination (default 50, max 200)"
+// @Param        cursor          query     string  false  "Cursor from next_cursor of the previous page"
+// @Param        page            query     int     false  "Page number for offset pagination"
+// @Param        per_page        query     int     false  "Page size for offset pagination (default 50, max 200)"
+// @Success      200             {object}  AccountListResponse
+// @Failure      400             {object}  map[string]string
+// @Failure      403             {object}  map[string]string
+// @Failure      500             {object}  map[string]string
 // @Router       /accounts [get]
 // @Security     BearerAuth
-func GetAccounts(c *gin.Context) {
-	rows, err := db.PrimaryDB.Query(
-		"SELECT id, customer_id, name, status, created_at, updated_at FROM accounts ORDER BY created_at DESC",
-	)
+// @Security     ApiKeyAuth
+func (h *AccountHandler) GetAccounts(c *gin.Context) {
+	filter, err := parseAccountFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+
+	pageReq, err := parsePageRequest(c, repository.ParseAccountSort)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch accounts"})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
 		return
 	}
-	defer rows.Close()
 
-	var accounts []models.Account
-	for rows.Next() {
-		var account models.Account
-		if err := rows.Scan(&account.ID, &account.CustomerID, &account.Name, &account.Status, &account.CreatedAt, &account.UpdatedAt); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan account"})
-			return
+	page, err := h.accounts.List(c.Request.Context(), filter, pageReq)
+	if isBadListRequest(err) {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+	if err != nil {
+		internalError(c, "Failed to fetch accounts", err)
+		return
+	}
+
+	setLinkHeader(c, pageReq, page.Total, page.NextCursor)
+	pageNumber, perPage := pageNumbers(pageReq)
+	c.JSON(http.StatusOK, AccountListResponse{
+		Data:       page.Items,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+		Page:       pageNumber,
+		PerPage:    perPage,
+	})
+}
+
+// parseAccountFilter reads the account list filters from the query string
+func parseAccountFilter(c *gin.Context) (repository.AccountFilter, error) {
+	var filter repository.AccountFilter
+	var err error
+
+	filter.Status = c.Query("status")
+	if len(filter.Status) > 50 {
+		return filter, errors.New("status must be at most 50 characters")
+	}
+
+	if customerID := c.Query("customer_id"); customerID != "" {
+		filter.CustomerID, err = strconv.Atoi(customerID)
+		if err != nil || filter.CustomerID <= 0 {
+			return filter, errors.New("customer_id must be a positive integer")
 		}
-		accounts = append(accounts, account)
 	}
 
-	c.JSON(http.StatusOK, accounts)
+	if filter.CreatedAfter, err = parseTimeQuery(c, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseTimeQuery(c, "created_before"); err != nil {
+		return filter, err
+	}
+	return filter, nil
 }
 
 // GetAccount retrieves a single account by ID
@@ -53,28 +113,25 @@ func GetAccounts(c *gin.Context) {
 // @Param        id   path      int  true  "Account ID"
 // @Success      200  {object}  models.Account
 // @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
 // @Router       /accounts/{id} [get]
 // @Security     BearerAuth
-func GetAccount(c *gin.Context) {
+// @Security     ApiKeyAuth
+func (h *AccountHandler) GetAccount(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Invalid account ID"))
 		return
 	}
 
-	var account models.Account
-	err = db.PrimaryDB.QueryRow(
-		"SELECT id, customer_id, name, status, created_at, updated_at FROM accounts WHERE id = $1",
-		id,
-	).Scan(&account.ID, &account.CustomerID, &account.Name, &account.Status, &account.CreatedAt, &account.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+	account, err := h.accounts.Get(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Account not found"))
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account"})
+		internalError(c, "Failed to fetch account", err)
 		return
 	}
 
@@ -90,23 +147,24 @@ func GetAccount(c *gin.Context) {
 // @Param        account  body      models.CreateAccountRequest  true  "Account data"
 // @Success      201      {object}  models.Account
 // @Failure      400      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
 // @Router       /accounts [post]
 // @Security     BearerAuth
-func CreateAccount(c *gin.Context) {
+// @Security     ApiKeyAuth
+func (h *AccountHandler) CreateAccount(c *gin.Context) {
 	var req models.CreateAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
 		return
 	}
 
-	var account models.Account
-	err := db.PrimaryDB.QueryRow(
-		"INSERT INTO accounts (customer_id, name, status) VALUES ($1, $2, $3) RETURNING id, customer_id, name, status, created_at, updated_at",
-		req.CustomerID, req.Name, req.Status,
-	).Scan(&account.ID, &account.CustomerID, &account.Name, &account.Status, &account.CreatedAt, &account.UpdatedAt)
-
+	account, err := h.accounts.Create(c.Request.Context(), req)
+	if errors.Is(err, repository.ErrInvalidReference) {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Customer does not exist"))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
+		internalError(c, "Failed to create account", err)
 		return
 	}
 
@@ -123,34 +181,31 @@ func CreateAccount(c *gin.Context) {
 // @Param        account  body      models.UpdateAccountRequest true  "Updated account data"
 // @Success      200      {object}  models.Account
 // @Failure      400      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
 // @Failure      404      {object}  map[string]string
 // @Router       /accounts/{id} [put]
 // @Security     BearerAuth
-func UpdateAccount(c *gin.Context) {
+// @Security     ApiKeyAuth
+func (h *AccountHandler) UpdateAccount(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Invalid account ID"))
 		return
 	}
 
 	var req models.UpdateAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
 		return
 	}
 
-	var account models.Account
-	err = db.PrimaryDB.QueryRow(
-		"UPDATE accounts SET name = $1, status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3 RETURNING id, customer_id, name, status, created_at, updated_at",
-		req.Name, req.Status, id,
-	).Scan(&account.ID, &account.CustomerID, &account.Name, &account.Status, &account.CreatedAt, &account.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+	account, err := h.accounts.Update(c.Request.Context(), id, req)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Account not found"))
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+		internalError(c, "Failed to update account", err)
 		return
 	}
 
@@ -166,28 +221,27 @@ func UpdateAccount(c *gin.Context) {
 // @Param        id   path      int  true  "Account ID"
 // @Success      200  {object}  map[string]string
 // @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
 // @Router       /accounts/{id} [delete]
 // @Security     BearerAuth
-func DeleteAccount(c *gin.Context) {
+// @Security     ApiKeyAuth
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Invalid account ID"))
 		return
 	}
 
-	result, err := db.PrimaryDB.Exec("DELETE FROM accounts WHERE id = $1", id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+	err = h.accounts.Delete(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Account not found"))
 		return
 	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+	if err != nil {
+		internalError(c, "Failed to delete account", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
 }
-
@@ -0,0 +1,388 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"saas-go-app/internal/jobs"
+	"saas-go-app/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler serves the system administration endpoints
+type AdminHandler struct {
+	scheduler *jobs.Scheduler
+	inspector jobs.Inspector
+}
+
+// NewAdminHandler creates an admin handler reporting on the given scheduler
+// and job queues. inspector may be nil when no job queue is configured.
+func NewAdminHandler(scheduler *jobs.Scheduler, inspector jobs.Inspector) *AdminHandler {
+	return &AdminHandler{scheduler: scheduler, inspector: inspector}
+}
+
+// SchedulesResponse lists the periodic jobs registered on this instance
+type SchedulesResponse struct {
+	// Running is false when this instance is not running the scheduler
+	Running bool                `json:"running"`
+	Jobs    []jobs.ScheduledJob `json:"jobs"`
+}
+
+// GetSchedules lists the periodic jobs and their last and next runs
+// @Summary      List scheduled jobs
+// @Description  List the periodic jobs registered on the instance serving the request, with their cron spec, next run and the last run this instance handled. Requires a system administrator.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  SchedulesResponse
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /admin/schedules [get]
+// @Security     BearerAuth
+func (h *AdminHandler) GetSchedules(c *gin.Context) {
+	c.JSON(http.StatusOK, SchedulesResponse{
+		Running: h.scheduler.Running(),
+		Jobs:    h.scheduler.Jobs(),
+	})
+}
+
+// DefaultTaskPageSize is used when per_page is not given for task lists
+const DefaultTaskPageSize = 30
+
+// TaskListResponse is one page of a queue's tasks in one state
+type TaskListResponse struct {
+	Data    []jobs.TaskDetails `json:"data"`
+	Page    int                `json:"page"`
+	PerPage int                `json:"per_page"`
+}
+
+// BulkTaskResponse reports how many tasks a bulk operation affected
+type BulkTaskResponse struct {
+	Count int `json:"count"`
+}
+
+// requireInspector answers 503 when no job queue is configured
+func (h *AdminHandler) requireInspector(c *gin.Context) bool {
+	if h.inspector == nil {
+		c.JSON(http.StatusServiceUnavailable, logging.ErrorBody(c, "Background jobs are not configured"))
+		return false
+	}
+	return true
+}
+
+// respondJobError maps inspector errors onto HTTP responses
+func respondJobError(c *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, jobs.ErrQueueNotFound):
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Queue not found"))
+	case errors.Is(err, jobs.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Task not found"))
+	case errors.Is(err, jobs.ErrTaskStateConflict):
+		c.JSON(http.StatusConflict, logging.ErrorBody(c, "Task is not in a state that allows this"))
+	default:
+		internalError(c, "Failed to "+action, err)
+	}
+}
+
+// GetQueues lists the job queues with their task counts
+// @Summary      List job queues
+// @Description  List the job queues with task counts per state, today's processed and failed counts, and whether they are paused. Requires a system administrator.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   jobs.QueueStats
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /admin/queues [get]
+// @Security     BearerAuth
+func (h *AdminHandler) GetQueues(c *gin.Context) {
+	if !h.requireInspector(c) {
+		return
+	}
+
+	queues, err := h.inspector.Queues(c.Request.Context())
+	if err != nil {
+		respondJobError(c, err, "list queues")
+		return
+	}
+
+	c.JSON(http.StatusOK, queues)
+}
+
+// GetQueueTasks lists a queue's tasks in one state
+// @Summary      List tasks
+// @Description  List one page of a queue's tasks in the given state, with payloads, retry counts and last errors. Requires a system administrator.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        queue     path      string  true   "Queue name"
+// @Param        state     query     string  false  "Task state (default archived)"  Enums(pending, active, scheduled, retry, archived, completed)
+// @Param        page      query     int     false  "Page number (from 1)"
+// @Param        per_page  query     int     false  "Tasks per page (default 30, max 200)"
+// @Success      200       {object}  TaskListResponse
+// @Failure      400       {object}  map[string]string
+// @Failure      401       {object}  map[string]string
+// @Failure      403       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /admin/queues/{queue}/tasks [get]
+// @Security     BearerAuth
+func (h *AdminHandler) GetQueueTasks(c *gin.Context) {
+	if !h.requireInspector(c) {
+		return
+	}
+
+	state := c.DefaultQuery("state", jobs.TaskStateArchived)
+	if !slices.Contains(jobs.TaskStates, state) {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "state must be one of "+strings.Join(jobs.TaskStates, ", ")))
+		return
+	}
+
+	page, err := positiveQuery(c, "page", 1, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+	perPage, err := positiveQuery(c, "per_page", DefaultTaskPageSize, MaxPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+
+	tasks, err := h.inspector.ListTasks(c.Request.Context(), c.Param("queue"), state, page, perPage)
+	if err != nil {
+		respondJobError(c, err, "list tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, TaskListResponse{Data: tasks, Page: page, PerPage: perPage})
+}
+
+// GetQueueTask returns one task
+// @Summary      Get task
+// @Description  Get a task's payload, state, retry count and last error. Requires a system administrator.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        queue    path      string  true  "Queue name"
+// @Param        task_id  path      string  true  "Task ID"
+// @Success      200      {object}  jobs.TaskDetails
+// @Failure      401      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /admin/queues/{queue}/tasks/{task_id} [get]
+// @Security     BearerAuth
+func (h *AdminHandler) GetQueueTask(c *gin.Context) {
+	if !h.requireInspector(c) {
+		return
+	}
+
+	task, err := h.inspector.GetTask(c.Request.Context(), c.Param("queue"), c.Param("task_id"))
+	if err != nil {
+		respondJobError(c, err, "get task")
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// RunQueueTask retries an archived, retry or scheduled task now
+// @Summary      Run task
+// @Description  Move an archived, retry or scheduled task to pending so a worker runs it now. Requires a system administrator.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        queue    path      string  true  "Queue name"
+// @Param        task_id  path      string  true  "Task ID"
+// @Success      200      {object}  map[string]string
+// @Failure      401      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      409      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /admin/queues/{queue}/tasks/{task_id}/run [post]
+// @Security     BearerAuth
+func (h *AdminHandler) RunQueueTask(c *gin.Context) {
+	if !h.requireInspector(c) {
+		return
+	}
+
+	if err := h.inspector.RunTask(c.Request.Context(), c.Param("queue"), c.Param("task_id")); err != nil {
+		respondJobError(c, err, "run task")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task queued to run"})
+}
+
+// DeleteQueueTask deletes a task that is not running
+// @Summary      Delete task
+// @Description  Delete a task in any state but active. Requires a system administrator.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        queue    path      string  true  "Queue name"
+// @Param        task_id  path      string  true  "Task ID"
+// @Success      200      {object}  map[string]string
+// @Failure      401      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      409      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /admin/queues/{queue}/tasks/{task_id} [delete]
+// @Security     BearerAuth
+func (h *AdminHandler) DeleteQueueTask(c *gin.Context) {
+	if !h.requireInspector(c) {
+		return
+	}
+
+	if err := h.inspector.DeleteTask(c.Request.Context(), c.Param("queue"), c.Param("task_id")); err != nil {
+		respondJobError(c, err, "delete task")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
+}
+
+// RunArchivedTasks retries every archived task in a queue
+// @Summary      Run archived tasks
+// @Description  Move every archived (dead-lettered) task in the queue to pending. Requires a system administrator.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        queue  path      string  true  "Queue name"
+// @Success      200    {object}  BulkTaskResponse
+// @Failure      401    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Failure      503    {object}  map[string]string
+// @Router       /admin/queues/{queue}/archived/run [post]
+// @Security     BearerAuth
+func (h *AdminHandler) RunArchivedTasks(c *gin.Context) {
+	if !h.requireInspector(c) {
+		return
+	}
+
+	count, err := h.inspector.RunArchivedTasks(c.Request.Context(), c.Param("queue"))
+	if err != nil {
+		respondJobError(c, err, "run archived tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, BulkTaskResponse{Count: count})
+}
+
+// DeleteArchivedTasks deletes every archived task in a queue
+// @Summary      Delete archived tasks
+// @Description  Delete every archived (dead-lettered) task in the queue. Requires a system administrator.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        queue  path      string  true  "Queue name"
+// @Success      200    {object}  BulkTaskResponse
+// @Failure      401    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Failure      503    {object}  map[string]string
+// @Router       /admin/queues/{queue}/archived [delete]
+// @Security     BearerAuth
+func (h *AdminHandler) DeleteArchivedTasks(c *gin.Context) {
+	if !h.requireInspector(c) {
+		return
+	}
+
+	count, err := h.inspector.DeleteArchivedTasks(c.Request.Context(), c.Param("queue"))
+	if err != nil {
+		respondJobError(c, err, "delete archived tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, BulkTaskResponse{Count: count})
+}
+
+// PauseQueue stops workers taking tasks from a queue
+// @Summary      Pause queue
+// @Description  Stop workers taking new tasks from the queue. Running tasks finish; tasks can still be enqueued. Requires a system administrator.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        queue  path      string  true  "Queue name"
+// @Success      200    {object}  map[string]string
+// @Failure      401    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Failure      503    {object}  map[string]string
+// @Router       /admin/queues/{queue}/pause [post]
+// @Security     BearerAuth
+func (h *AdminHandler) PauseQueue(c *gin.Context) {
+	if !h.requireInspector(c) {
+		return
+	}
+
+	if err := h.inspector.PauseQueue(c.Request.Context(), c.Param("queue")); err != nil {
+		respondJobError(c, err, "pause queue")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Queue paused"})
+}
+
+// ResumeQueue lets workers take tasks from a paused queue again
+// @Summary      Resume queue
+// @Description  Let workers take tasks from a paused queue again. Requires a system administrator.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        queue  path      string  true  "Queue name"
+// @Success      200    {object}  map[string]string
+// @Failure      401    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Failure      503    {object}  map[string]string
+// @Router       /admin/queues/{queue}/resume [post]
+// @Security     BearerAuth
+func (h *AdminHandler) ResumeQueue(c *gin.Context) {
+	if !h.requireInspector(c) {
+		return
+	}
+
+	if err := h.inspector.ResumeQueue(c.Request.Context(), c.Param("queue")); err != nil {
+		respondJobError(c, err, "resume queue")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Queue resumed"})
+}
+
+// positiveQuery reads an optional positive integer, capped at limit when limit > 0
+func positiveQuery(c *gin.Context, name string, fallback, limit int) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || (limit > 0 && n > limit) {
+		if limit > 0 {
+			return 0, fmt.Errorf("%s must be between 1 and %d", name, limit)
+		}
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return n, nil
+}
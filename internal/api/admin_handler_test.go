@@ -0,0 +1,312 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/jobs"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetSchedulesRequiresAdmin(t *testing.T) {
+	router, store := newAuthRouter(t)
+
+	scheduler := jobs.NewScheduler(nil)
+	for _, job := range jobs.DefaultPeriodicJobs() {
+		if err := scheduler.Register(job); err != nil {
+			t.Fatalf("Failed to register job: %v", err)
+		}
+	}
+	handler := NewAdminHandler(scheduler, nil)
+	admin := router.Group("/api/admin")
+	admin.Use(auth.AuthMiddleware(), auth.RequireAdmin())
+	admin.GET("/schedules", handler.GetSchedules)
+
+	// Owning an organization does not make a user an administrator
+	session := loginTestUser(t, router, "operator")
+	if w := doJSON(router, http.MethodGet, "/api/admin/schedules", session.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, got %d", w.Code)
+	}
+
+	user, err := store.Users().GetByUsername(context.Background(), "operator")
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if err := store.Users().SetAdmin(context.Background(), user.ID, true); err != nil {
+		t.Fatalf("Failed to make user admin: %v", err)
+	}
+
+	// The flag is picked up by the next login or refresh
+	w := doJSON(router, http.MethodPost, "/api/auth/refresh", "", gin.H{"refresh_token": session.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to refresh: %d %s", w.Code, w.Body.String())
+	}
+	session = decodeLoginResponse(t, w.Body.Bytes())
+
+	w = doJSON(router, http.MethodGet, "/api/admin/schedules", session.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response SchedulesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Running || len(response.Jobs) != 1 || response.Jobs[0].TaskType != jobs.TypeAggregateData {
+		t.Errorf("Unexpected schedules: %+v", response)
+	}
+}
+
+// fakeInspector holds tasks in memory, keyed by queue then ID
+type fakeInspector struct {
+	tasks  map[string]map[string]jobs.TaskDetails
+	paused map[string]bool
+}
+
+func newFakeInspector() *fakeInspector {
+	return &fakeInspector{
+		tasks: map[string]map[string]jobs.TaskDetails{
+			"default": {
+				"failed-1": {ID: "failed-1", Queue: "default", Type: jobs.TypeAggregateData, State: jobs.TaskStateArchived, LastError: "boom", Payload: json.RawMessage(`{"date":"2024-01-01T00:00:00Z"}`)},
+				"failed-2": {ID: "failed-2", Queue: "default", Type: jobs.TypeAggregateData, State: jobs.TaskStateArchived, Payload: json.RawMessage("null")},
+				"running":  {ID: "running", Queue: "default", Type: jobs.TypeAggregateData, State: jobs.TaskStateActive, Payload: json.RawMessage("null")},
+			},
+			"low": {},
+		},
+		paused: make(map[string]bool),
+	}
+}
+
+func (f *fakeInspector) queue(queue string) (map[string]jobs.TaskDetails, error) {
+	tasks, ok := f.tasks[queue]
+	if !ok {
+		return nil, jobs.ErrQueueNotFound
+	}
+	return tasks, nil
+}
+
+func (f *fakeInspector) task(queue, id string) (jobs.TaskDetails, error) {
+	tasks, err := f.queue(queue)
+	if err != nil {
+		return jobs.TaskDetails{}, err
+	}
+	task, ok := tasks[id]
+	if !ok {
+		return jobs.TaskDetails{}, jobs.ErrTaskNotFound
+	}
+	return task, nil
+}
+
+func (f *fakeInspector) Queues(ctx context.Context) ([]jobs.QueueStats, error) {
+	var stats []jobs.QueueStats
+	for queue, tasks := range f.tasks {
+		stats = append(stats, jobs.QueueStats{Queue: queue, Size: len(tasks), Paused: f.paused[queue]})
+	}
+	return stats, nil
+}
+
+func (f *fakeInspector) ListTasks(ctx context.Context, queue, state string, page, perPage int) ([]jobs.TaskDetails, error) {
+	tasks, err := f.queue(queue)
+	if err != nil {
+		return nil, err
+	}
+	list := []jobs.TaskDetails{}
+	for _, task := range tasks {
+		if task.State == state {
+			list = append(list, task)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeInspector) GetTask(ctx context.Context, queue, id string) (jobs.TaskDetails, error) {
+	return f.task(queue, id)
+}
+
+func (f *fakeInspector) RunTask(ctx context.Context, queue, id string) error {
+	task, err := f.task(queue, id)
+	if err != nil {
+		return err
+	}
+	if task.State == jobs.TaskStateActive || task.State == jobs.TaskStatePending {
+		return jobs.ErrTaskStateConflict
+	}
+	task.State = jobs.TaskStatePending
+	f.tasks[queue][id] = task
+	return nil
+}
+
+func (f *fakeInspector) DeleteTask(ctx context.Context, queue, id string) error {
+	task, err := f.task(queue, id)
+	if err != nil {
+		return err
+	}
+	if task.State == jobs.TaskStateActive {
+		return jobs.ErrTaskStateConflict
+	}
+	delete(f.tasks[queue], id)
+	return nil
+}
+
+func (f *fakeInspector) RunArchivedTasks(ctx context.Context, queue string) (int, error) {
+	tasks, err := f.queue(queue)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for id, task := range tasks {
+		if task.State == jobs.TaskStateArchived {
+			task.State = jobs.TaskStatePending
+			tasks[id] = task
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeInspector) DeleteArchivedTasks(ctx context.Context, queue string) (int, error) {
+	tasks, err := f.queue(queue)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for id, task := range tasks {
+		if task.State == jobs.TaskStateArchived {
+			delete(tasks, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeInspector) PauseQueue(ctx context.Context, queue string) error {
+	if _, err := f.queue(queue); err != nil {
+		return err
+	}
+	f.paused[queue] = true
+	return nil
+}
+
+func (f *fakeInspector) ResumeQueue(ctx context.Context, queue string) error {
+	if _, err := f.queue(queue); err != nil {
+		return err
+	}
+	f.paused[queue] = false
+	return nil
+}
+
+// newAdminRouter serves the queue admin routes and returns an admin token
+func newAdminRouter(t *testing.T, inspector jobs.Inspector) (*gin.Engine, string) {
+	t.Helper()
+	router, store := newCustomerRouter(t)
+
+	handler := NewAdminHandler(jobs.NewScheduler(nil), inspector)
+	admin := router.Group("/api/admin")
+	admin.Use(auth.AuthMiddleware(), auth.RequireAdmin())
+	admin.GET("/queues", handler.GetQueues)
+	admin.GET("/queues/:queue/tasks", handler.GetQueueTasks)
+	admin.GET("/queues/:queue/tasks/:task_id", handler.GetQueueTask)
+	admin.POST("/queues/:queue/tasks/:task_id/run", handler.RunQueueTask)
+	admin.DELETE("/queues/:queue/tasks/:task_id", handler.DeleteQueueTask)
+	admin.POST("/queues/:queue/archived/run", handler.RunArchivedTasks)
+	admin.DELETE("/queues/:queue/archived", handler.DeleteArchivedTasks)
+	admin.POST("/queues/:queue/pause", handler.PauseQueue)
+	admin.POST("/queues/:queue/resume", handler.ResumeQueue)
+
+	ctx := context.Background()
+	user, org, err := store.Users().Register(ctx, "root", "unused", "ops")
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	token, err := auth.GenerateToken(auth.Identity{UserID: user.ID, Username: user.Username, OrgID: org.ID, Role: auth.RoleOwner, Admin: true})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	return router, token
+}
+
+func TestQueueAdmin(t *testing.T) {
+	inspector := newFakeInspector()
+	router, token := newAdminRouter(t, inspector)
+
+	w := doJSON(router, http.MethodGet, "/api/admin/queues/default/tasks", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list TaskListResponse
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Data) != 2 || list.Page != 1 || list.PerPage != DefaultTaskPageSize {
+		t.Errorf("Expected the 2 archived tasks by default, got %+v", list)
+	}
+
+	w = doJSON(router, http.MethodGet, "/api/admin/queues/default/tasks/failed-1", token, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"last_error":"boom"`) || !strings.Contains(w.Body.String(), `"payload":{"date":"2024-01-01T00:00:00Z"}`) {
+		t.Errorf("Expected the task with its payload and last error, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/admin/queues/default/tasks?state=dead", http.StatusBadRequest},
+		{http.MethodGet, "/api/admin/queues/default/tasks?per_page=0", http.StatusBadRequest},
+		{http.MethodGet, "/api/admin/queues/nope/tasks", http.StatusNotFound},
+		{http.MethodGet, "/api/admin/queues/default/tasks/missing", http.StatusNotFound},
+		{http.MethodPost, "/api/admin/queues/default/tasks/failed-1/run", http.StatusOK},
+		{http.MethodPost, "/api/admin/queues/default/tasks/failed-1/run", http.StatusConflict},
+		{http.MethodDelete, "/api/admin/queues/default/tasks/running", http.StatusConflict},
+		{http.MethodPost, "/api/admin/queues/default/pause", http.StatusOK},
+		{http.MethodPost, "/api/admin/queues/nope/pause", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := doJSON(router, tt.method, tt.path, token, nil); w.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d: %s", tt.method, tt.path, tt.want, w.Code, w.Body.String())
+		}
+	}
+	if !inspector.paused["default"] {
+		t.Error("Expected the default queue to be paused")
+	}
+
+	w = doJSON(router, http.MethodDelete, "/api/admin/queues/default/archived", token, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"count":1`) {
+		t.Errorf("Expected one archived task deleted, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestQueueAdminWithoutJobQueue(t *testing.T) {
+	router, token := newAdminRouter(t, nil)
+
+	if w := doJSON(router, http.MethodGet, "/api/admin/queues", token, nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+}
+
+func TestRespondJobError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{jobs.ErrQueueNotFound, http.StatusNotFound},
+		{fmt.Errorf("pause: %w", jobs.ErrQueueNotFound), http.StatusNotFound},
+		{jobs.ErrTaskNotFound, http.StatusNotFound},
+		{jobs.ErrTaskStateConflict, http.StatusConflict},
+		{errors.New("connection reset"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		respondJobError(c, tt.err, "get task")
+		if w.Code != tt.want {
+			t.Errorf("respondJobError(%v) = %d, want %d", tt.err, w.Code, tt.want)
+		}
+	}
+}
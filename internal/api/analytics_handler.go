@@ -1,20 +1,26 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
-	"saas-go-app/internal/db"
+	"saas-go-app/internal/logging"
+	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AnalyticsResponse represents analytics data
-type AnalyticsResponse struct {
-	TotalCustomers   int     `json:"total_customers"`
-	TotalAccounts    int     `json:"total_accounts"`
-	ActiveAccounts   int     `json:"active_accounts"`
-	InactiveAccounts int     `json:"inactive_accounts"`
-	AvgAccountsPerCustomer float64 `json:"avg_accounts_per_customer"`
+// AnalyticsHandler serves the analytics endpoints
+type AnalyticsHandler struct {
+	analytics repository.AnalyticsRepository
+}
+
+// NewAnalyticsHandler creates an analytics handler backed by the given repository
+func NewAnalyticsHandler(analytics repository.AnalyticsRepository) *AnalyticsHandler {
+	return &AnalyticsHandler{analytics: analytics}
 }
 
 // GetAnalytics retrieves analytics data from the follower pool
@@ -23,101 +29,146 @@ type AnalyticsResponse struct {
 // @Tags         analytics
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  AnalyticsResponse
+// @Success      200  {object}  models.AnalyticsOverview
+// @Failure      403  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /analytics [get]
 // @Security     BearerAuth
-func GetAnalytics(c *gin.Context) {
-	// Use analytics DB (follower pool) for read-only analytics queries
-	analyticsDB := db.AnalyticsDB
-	if analyticsDB == nil {
-		analyticsDB = db.PrimaryDB
-	}
-
-	var totalCustomers int
-	err := analyticsDB.QueryRow("SELECT COUNT(*) FROM customers").Scan(&totalCustomers)
+// @Security     ApiKeyAuth
+func (h *AnalyticsHandler) GetAnalytics(c *gin.Context) {
+	overview, err := h.analytics.Overview(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch customer count"})
+		internalError(c, "Failed to fetch analytics", err)
 		return
 	}
 
-	var totalAccounts int
-	err = analyticsDB.QueryRow("SELECT COUNT(*) FROM accounts").Scan(&totalAccounts)
+	c.JSON(http.StatusOK, overview)
+}
+
+// GetCustomerAnalytics retrieves analytics for a specific customer
+// @Summary      Get customer analytics
+// @Description  Get analytics for a specific customer including account counts
+// @Tags         analytics
+// @Accept       json
+// @Produce      json
+// @Param        customer_id  path      int  true  "Customer ID"
+// @Success      200          {object}  models.CustomerAnalytics
+// @Failure      400          {object}  map[string]string
+// @Failure      403          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Failure      500          {object}  map[string]string
+// @Router       /analytics/customers/{customer_id} [get]
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+func (h *AnalyticsHandler) GetCustomerAnalytics(c *gin.Context) {
+	customerID, err := strconv.Atoi(c.Param("customer_id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch account count"})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Invalid customer ID"))
 		return
 	}
 
-	var activeAccounts int
-	err = analyticsDB.QueryRow("SELECT COUNT(*) FROM accounts WHERE status = 'active'").Scan(&activeAccounts)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch active account count"})
+	summary, err := h.analytics.CustomerSummary(c.Request.Context(), customerID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Customer not found"))
 		return
 	}
-
-	var inactiveAccounts int
-	err = analyticsDB.QueryRow("SELECT COUNT(*) FROM accounts WHERE status = 'inactive'").Scan(&inactiveAccounts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inactive account count"})
+		internalError(c, "Failed to fetch customer analytics", err)
 		return
 	}
 
-	var avgAccountsPerCustomer float64
-	if totalCustomers > 0 {
-		err = analyticsDB.QueryRow(
-			"SELECT COALESCE(AVG(account_count), 0) FROM (SELECT customer_id, COUNT(*) as account_count FROM accounts GROUP BY customer_id) AS subquery",
-		).Scan(&avgAccountsPerCustomer)
-		if err != nil {
-			avgAccountsPerCustomer = 0
-		}
-	}
+	c.JSON(http.StatusOK, summary)
+}
 
-	response := AnalyticsResponse{
-		TotalCustomers:        totalCustomers,
-		TotalAccounts:         totalAccounts,
-		ActiveAccounts:        activeAccounts,
-		InactiveAccounts:      inactiveAccounts,
-		AvgAccountsPerCustomer: avgAccountsPerCustomer,
-	}
+// Timeseries range limits, in days
+const (
+	defaultTimeseriesDays = 30
+	maxTimeseriesDays     = 366
+)
 
-	c.JSON(http.StatusOK, response)
+// timeseriesMetrics are the metrics the aggregation job stores
+var timeseriesMetrics = map[string]bool{
+	models.MetricCustomers:    true,
+	models.MetricNewCustomers: true,
+	models.MetricAccounts:     true,
+	models.MetricNewAccounts:  true,
 }
 
-// GetCustomerAnalytics retrieves analytics for a specific customer
-// @Summary      Get customer analytics
-// @Description  Get analytics for a specific customer including account counts
+// GetTimeseries returns the daily aggregates stored by the aggregate:data job
+// @Summary      Get metric timeseries
+// @Description  Get a metric's daily values from the stored aggregates. Accounts are broken down by status in addition to the "all" series. Days not yet aggregated are missing from the series.
 // @Tags         analytics
 // @Accept       json
 // @Produce      json
-// @Param        customer_id  path      string  true  "Customer ID"
-// @Success      200          {object}  map[string]interface{}
-// @Failure      500          {object}  map[string]string
-// @Router       /analytics/customers/{customer_id} [get]
+// @Param        metric  query     string  true   "Metric"  Enums(customers, new_customers, accounts, new_accounts)
+// @Param        from    query     string  false  "First day (YYYY-MM-DD), defaults to 30 days before to"
+// @Param        to      query     string  false  "Last day (YYYY-MM-DD), defaults to today"
+// @Success      200     {object}  models.Timeseries
+// @Failure      400     {object}  map[string]string
+// @Failure      403     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /analytics/timeseries [get]
 // @Security     BearerAuth
-func GetCustomerAnalytics(c *gin.Context) {
-	customerID := c.Param("customer_id")
+// @Security     ApiKeyAuth
+func (h *AnalyticsHandler) GetTimeseries(c *gin.Context) {
+	metric := c.Query("metric")
+	if !timeseriesMetrics[metric] {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "metric must be one of customers, new_customers, accounts, new_accounts"))
+		return
+	}
+
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
 
-	analyticsDB := db.AnalyticsDB
-	if analyticsDB == nil {
-		analyticsDB = db.PrimaryDB
+	if to == nil {
+		now := time.Now().UTC()
+		to = &now
+	}
+	if from == nil {
+		start := to.AddDate(0, 0, -(defaultTimeseriesDays - 1))
+		from = &start
+	}
+	first, _ := time.Parse(time.DateOnly, from.UTC().Format(time.DateOnly))
+	last, _ := time.Parse(time.DateOnly, to.UTC().Format(time.DateOnly))
+	if first.After(last) {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "from must not be after to"))
+		return
+	}
+	if days := int(last.Sub(first).Hours()/24) + 1; days > maxTimeseriesDays {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Range must not exceed 366 days"))
+		return
 	}
 
-	var accountCount int
-	var activeCount int
-	err := analyticsDB.QueryRow(
-		"SELECT COUNT(*), COUNT(CASE WHEN status = 'active' THEN 1 END) FROM accounts WHERE customer_id = $1",
-		customerID,
-	).Scan(&accountCount, &activeCount)
+	points, err := h.analytics.Timeseries(c.Request.Context(), metric, first, last)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch customer analytics"})
+		internalError(c, "Failed to fetch timeseries", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"customer_id":   customerID,
-		"total_accounts": accountCount,
-		"active_accounts": activeCount,
-		"inactive_accounts": accountCount - activeCount,
+	series := []models.TimeseriesSeries{}
+	for _, point := range points {
+		if len(series) == 0 || series[len(series)-1].Dimension != point.Dimension {
+			series = append(series, models.TimeseriesSeries{Dimension: point.Dimension, Points: []models.TimeseriesPoint{}})
+		}
+		current := &series[len(series)-1]
+		current.Points = append(current.Points, models.TimeseriesPoint{
+			Date:  point.Date.UTC().Format(time.DateOnly),
+			Value: point.Value,
+		})
+	}
+
+	c.JSON(http.StatusOK, models.Timeseries{
+		Metric: metric,
+		From:   first.Format(time.DateOnly),
+		To:     last.Format(time.DateOnly),
+		Series: series,
 	})
 }
-
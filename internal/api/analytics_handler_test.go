@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/jobs"
+	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newAnalyticsRouter(t *testing.T) (*gin.Engine, *repository.MemoryStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	if err := auth.InitJWT(auth.JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+
+	store := repository.NewMemoryStore()
+	customerHandler := NewCustomerHandler(store.Customers())
+	accountHandler := NewAccountHandler(store.Accounts())
+	analyticsHandler := NewAnalyticsHandler(store.Analytics())
+
+	router := gin.New()
+	apiRoutes := router.Group("/api")
+	apiRoutes.Use(auth.AuthMiddleware())
+	apiRoutes.POST("/customers", customerHandler.CreateCustomer)
+	apiRoutes.POST("/accounts", accountHandler.CreateAccount)
+	apiRoutes.PUT("/accounts/:id", accountHandler.UpdateAccount)
+	apiRoutes.GET("/analytics/customers/:customer_id", auth.RequirePermission(auth.PermAnalyticsRead), analyticsHandler.GetCustomerAnalytics)
+	apiRoutes.GET("/analytics/timeseries", auth.RequirePermission(auth.PermAnalyticsRead), analyticsHandler.GetTimeseries)
+
+	return router, store
+}
+
+// aggregateToday runs the aggregation job for today against store
+func aggregateToday(t *testing.T, store *repository.MemoryStore) {
+	t.Helper()
+	task, err := jobs.NewAggregationTask(context.Background(), time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if err := jobs.NewAggregationHandler(store.DailyMetrics()).ProcessTask(context.Background(), task); err != nil {
+		t.Fatalf("Aggregation failed: %v", err)
+	}
+}
+
+func getTimeseries(t *testing.T, router *gin.Engine, token, query string) models.Timeseries {
+	t.Helper()
+	w := doJSON(router, http.MethodGet, "/api/analytics/timeseries?"+query, token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var series models.Timeseries
+	if err := json.Unmarshal(w.Body.Bytes(), &series); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return series
+}
+
+// seriesValues maps each dimension to its value on the only day in ts
+func seriesValues(t *testing.T, ts models.Timeseries) map[string]int64 {
+	t.Helper()
+	values := make(map[string]int64)
+	for _, series := range ts.Series {
+		if len(series.Points) != 1 {
+			t.Fatalf("Expected one point in %q, got %d", series.Dimension, len(series.Points))
+		}
+		values[series.Dimension] = series.Points[0].Value
+	}
+	return values
+}
+
+func TestCustomerAnalytics(t *testing.T) {
+	router, store := newAnalyticsRouter(t)
+	token := registerTestUser(t, store, "analyst")
+	other := registerTestUser(t, store, "other")
+
+	w := doJSON(router, http.MethodPost, "/api/customers", token, map[string]string{"name": "Acme", "email": "ops@acme.test"})
+	var customer models.Customer
+	json.Unmarshal(w.Body.Bytes(), &customer)
+	for i, status := range []string{"active", "inactive"} {
+		w := doJSON(router, http.MethodPost, "/api/accounts", token, map[string]interface{}{
+			"customer_id": customer.ID, "name": "Account " + strconv.Itoa(i), "status": status,
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create account: %d %s", w.Code, w.Body.String())
+		}
+	}
+	path := "/api/analytics/customers/" + strconv.Itoa(customer.ID)
+
+	w = doJSON(router, http.MethodGet, path, token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var summary models.CustomerAnalytics
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if summary.TotalAccounts != 2 || summary.ActiveAccounts != 1 || summary.InactiveAccounts != 1 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+
+	// Another organization's customer is as missing as one that never existed
+	if w := doJSON(router, http.MethodGet, path, other, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for another organization's customer, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodGet, "/api/analytics/customers/999", token, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown customer, got %d", w.Code)
+	}
+}
+
+func TestTimeseriesFromAggregation(t *testing.T) {
+	router, store := newAnalyticsRouter(t)
+	token := registerTestUser(t, store, "analyst")
+	other := registerTestUser(t, store, "other")
+
+	w := doJSON(router, http.MethodPost, "/api/customers", token, map[string]string{"name": "Acme", "email": "ops@acme.test"})
+	var customer models.Customer
+	json.Unmarshal(w.Body.Bytes(), &customer)
+	for i, status := range []string{"active", "active", "suspended"} {
+		w := doJSON(router, http.MethodPost, "/api/accounts", token, map[string]interface{}{
+			"customer_id": customer.ID, "name": "Account " + strconv.Itoa(i), "status": status,
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create account: %d %s", w.Code, w.Body.String())
+		}
+	}
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	query := "metric=accounts&from=" + today + "&to=" + today
+
+	if ts := getTimeseries(t, router, token, query); len(ts.Series) != 0 {
+		t.Errorf("Expected no series before aggregation, got %+v", ts.Series)
+	}
+
+	// Reruns overwrite rather than add up
+	aggregateToday(t, store)
+	aggregateToday(t, store)
+
+	ts := getTimeseries(t, router, token, query)
+	if ts.From != today || ts.To != today || ts.Metric != "accounts" {
+		t.Errorf("Unexpected range: %+v", ts)
+	}
+	values := seriesValues(t, ts)
+	if values["all"] != 3 || values["active"] != 2 || values["suspended"] != 1 {
+		t.Errorf("Unexpected account values: %v", values)
+	}
+
+	customers := seriesValues(t, getTimeseries(t, router, token, "metric=new_customers&from="+today+"&to="+today))
+	if customers["all"] != 1 {
+		t.Errorf("Expected 1 new customer, got %v", customers)
+	}
+
+	// A status with no accounts left drops to zero on the next run
+	w = doJSON(router, http.MethodPut, "/api/accounts/3", token, map[string]string{"name": "Account 2", "status": "active"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to update account: %d %s", w.Code, w.Body.String())
+	}
+	aggregateToday(t, store)
+
+	values = seriesValues(t, getTimeseries(t, router, token, query))
+	if values["active"] != 3 || values["suspended"] != 0 {
+		t.Errorf("Unexpected account values after rerun: %v", values)
+	}
+
+	// Other organizations get their own, empty, aggregates
+	values = seriesValues(t, getTimeseries(t, router, other, query))
+	if len(values) != 1 || values["all"] != 0 {
+		t.Errorf("Expected only a zero total for another organization, got %v", values)
+	}
+}
+
+func TestTimeseriesDefaultRange(t *testing.T) {
+	router, store := newAnalyticsRouter(t)
+	token := registerTestUser(t, store, "analyst")
+
+	ts := getTimeseries(t, router, token, "metric=customers")
+	to := time.Now().UTC()
+	if ts.To != to.Format(time.DateOnly) || ts.From != to.AddDate(0, 0, -29).Format(time.DateOnly) {
+		t.Errorf("Expected the last 30 days, got %s to %s", ts.From, ts.To)
+	}
+}
+
+func TestTimeseriesValidation(t *testing.T) {
+	router, store := newAnalyticsRouter(t)
+	token := registerTestUser(t, store, "analyst")
+
+	for _, query := range []string{
+		"",
+		"metric=revenue",
+		"metric=customers&from=yesterday",
+		"metric=customers&from=2024-02-01&to=2024-01-01",
+		"metric=customers&from=2023-01-01&to=2024-01-02",
+	} {
+		w := doJSON(router, http.MethodGet, "/api/analytics/timeseries?"+query, token, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, w.Code)
+		}
+	}
+
+	w := doJSON(router, http.MethodGet, "/api/analytics/timeseries?metric=customers&from=2024-01-01&to=2024-12-31", token, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected a 366-day range to be accepted, got %d", w.Code)
+	}
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/logging"
+	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyCreateAttempts bounds retries when a new key's prefix collides
+const apiKeyCreateAttempts = 3
+
+// APIKeyHandler serves the API key management endpoints
+type APIKeyHandler struct {
+	keys repository.APIKeyRepository
+}
+
+// NewAPIKeyHandler creates an API key handler backed by the given repository
+func NewAPIKeyHandler(keys repository.APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys}
+}
+
+// CreateAPIKeyResponse is a newly minted API key. Key is only ever returned here.
+type CreateAPIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// GetAPIKeys lists the organization's API keys
+// @Summary      List API keys
+// @Description  Get the active organization's API keys, including revoked ones. Secrets are never returned.
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   models.APIKey
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /api-keys [get]
+// @Security     BearerAuth
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	keys, err := h.keys.List(c.Request.Context())
+	if err != nil {
+		internalError(c, "Failed to fetch API keys", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// GetAPIKey gets a single API key by ID
+// @Summary      Get API key by ID
+// @Description  Get an API key of the active organization. The secret is never returned.
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "API key ID"
+// @Success      200  {object}  models.APIKey
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api-keys/{id} [get]
+// @Security     BearerAuth
+func (h *APIKeyHandler) GetAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Invalid API key ID"))
+		return
+	}
+
+	key, err := h.keys.Get(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "API key not found"))
+		return
+	}
+	if err != nil {
+		internalError(c, "Failed to fetch API key", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// CreateAPIKey mints a new API key
+// @Summary      Create API key
+// @Description  Mint an API key for the active organization. Scopes are permissions such as "customers:read" and cannot exceed the caller's own role. The key is shown only in this response; use it as "Authorization: ApiKey <key>".
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Param        api_key  body      models.CreateAPIKeyRequest  true  "API key data"
+// @Success      201      {object}  CreateAPIKeyResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Router       /api-keys [post]
+// @Security     BearerAuth
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+
+	identity := auth.CurrentIdentity(c)
+	scopes, err := validateScopes(identity, req.Scopes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "expires_at must be in the future"))
+		return
+	}
+
+	for attempt := 0; attempt < apiKeyCreateAttempts; attempt++ {
+		secret, prefix, hash, err := auth.NewAPIKey()
+		if err != nil {
+			internalError(c, "Failed to generate API key", err)
+			return
+		}
+
+		key, err := h.keys.Create(c.Request.Context(), models.APIKey{
+			Name:      req.Name,
+			Prefix:    prefix,
+			KeyHash:   hash,
+			Scopes:    scopes,
+			CreatedBy: &identity.UserID,
+			ExpiresAt: req.ExpiresAt,
+		})
+		if errors.Is(err, repository.ErrConflict) {
+			continue
+		}
+		if err != nil {
+			internalError(c, "Failed to create API key", err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, CreateAPIKeyResponse{APIKey: key, Key: secret})
+		return
+	}
+
+	internalError(c, "Failed to create API key", errors.New("no unused key prefix found"))
+}
+
+// validateScopes checks that every scope is a known permission the caller
+// holds, and returns them without duplicates
+func validateScopes(identity auth.Identity, requested []string) ([]string, error) {
+	seen := make(map[string]bool)
+	scopes := []string{}
+	for _, scope := range requested {
+		if !auth.ValidPermission(scope) {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+		if !identity.HasPermission(auth.Permission(scope)) {
+			return nil, fmt.Errorf("cannot grant scope %q that your role does not have", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// RevokeAPIKey revokes an API key
+// @Summary      Revoke API key
+// @Description  Revoke an API key of the active organization; it stops working immediately
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "API key ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /api-keys/{id} [delete]
+// @Security     BearerAuth
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Invalid API key ID"))
+		return
+	}
+
+	err = h.keys.Revoke(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "API key not found"))
+		return
+	}
+	if err != nil {
+		internalError(c, "Failed to revoke API key", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newAPIKeyRouter(t *testing.T) (*gin.Engine, *repository.MemoryStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	if err := auth.InitJWT(auth.JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+
+	store := repository.NewMemoryStore()
+	auth.SetAPIKeyStore(store.APIKeys())
+	t.Cleanup(func() { auth.SetAPIKeyStore(nil) })
+
+	keys := NewAPIKeyHandler(store.APIKeys())
+	customers := NewCustomerHandler(store.Customers())
+
+	router := gin.New()
+	apiRoutes := router.Group("/api")
+	apiRoutes.Use(auth.AuthMiddleware())
+
+	apiKeys := apiRoutes.Group("/api-keys")
+	apiKeys.Use(auth.RequireUser(), auth.RequirePermission(auth.PermAPIKeysManage))
+	apiKeys.GET("", keys.GetAPIKeys)
+	apiKeys.GET("/:id", keys.GetAPIKey)
+	apiKeys.POST("", keys.CreateAPIKey)
+	apiKeys.DELETE("/:id", keys.RevokeAPIKey)
+
+	apiRoutes.GET("/customers", auth.RequirePermission(auth.PermCustomersRead), customers.GetCustomers)
+	apiRoutes.POST("/customers", auth.RequirePermission(auth.PermCustomersWrite), customers.CreateCustomer)
+
+	return router, store
+}
+
+// mintAPIKey creates a key through the API and returns the response
+func mintAPIKey(t *testing.T, router *gin.Engine, token string, scopes ...string) CreateAPIKeyResponse {
+	t.Helper()
+
+	w := doJSON(router, "POST", "/api/api-keys", token, models.CreateAPIKeyRequest{Name: "integration", Scopes: scopes})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create API key: %d %s", w.Code, w.Body.String())
+	}
+	var created CreateAPIKeyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return created
+}
+
+// doWithAPIKey is doJSON authenticating with an API key instead of a token
+func doWithAPIKey(router *gin.Engine, method, path, key string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "ApiKey "+key)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	router, store := newAPIKeyRouter(t)
+	token := registerTestUser(t, store, "owner")
+
+	created := mintAPIKey(t, router, token, "customers:read", "customers:read")
+	if created.Key == "" || created.Prefix == "" {
+		t.Fatalf("Expected key and prefix in response, got %+v", created)
+	}
+	if len(created.Scopes) != 1 {
+		t.Errorf("Expected duplicate scopes to be collapsed, got %v", created.Scopes)
+	}
+
+	// The secret is never returned again, and is not stored in clear
+	w := doJSON(router, "GET", "/api/api-keys", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var listed []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(listed) != 1 || listed[0]["key"] != nil || listed[0]["key_hash"] != nil {
+		t.Errorf("Expected one key without secret material, got %v", listed)
+	}
+	if created.KeyHash != "" {
+		t.Error("Expected key hash to be omitted from the create response")
+	}
+}
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	router, store := newAPIKeyRouter(t)
+	ownerToken := registerTestUser(t, store, "owner")
+	memberToken := registerTestUserWithRole(t, store, "member", auth.RoleMember)
+
+	tests := []struct {
+		name  string
+		token string
+		body  interface{}
+		want  int
+	}{
+		{"no scopes", ownerToken, models.CreateAPIKeyRequest{Name: "k", Scopes: []string{}}, http.StatusBadRequest},
+		{"unknown scope", ownerToken, models.CreateAPIKeyRequest{Name: "k", Scopes: []string{"customers:nuke"}}, http.StatusBadRequest},
+		{"expired", ownerToken, gin.H{"name": "k", "scopes": []string{"customers:read"}, "expires_at": time.Now().Add(-time.Hour)}, http.StatusBadRequest},
+		{"member cannot manage keys", memberToken, models.CreateAPIKeyRequest{Name: "k", Scopes: []string{"customers:read"}}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := doJSON(router, "POST", "/api/api-keys", tt.token, tt.body); w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	router, store := newAPIKeyRouter(t)
+	token := registerTestUser(t, store, "owner")
+	created := mintAPIKey(t, router, token, "customers:read")
+
+	if w := doWithAPIKey(router, "GET", "/api/customers", created.Key, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected scoped read to return %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	body := models.CreateCustomerRequest{Name: "Acme", Email: "acme@example.com"}
+	if w := doWithAPIKey(router, "POST", "/api/customers", created.Key, body); w.Code != http.StatusForbidden {
+		t.Errorf("Expected write outside scopes to return %d, got %d", http.StatusForbidden, w.Code)
+	}
+
+	// Keys cannot manage keys, even their own organization's
+	if w := doWithAPIKey(router, "GET", "/api/api-keys", created.Key, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected API key management with a key to return %d, got %d", http.StatusForbidden, w.Code)
+	}
+
+	// Use is recorded
+	w := doJSON(router, "GET", "/api/api-keys/"+strconv.Itoa(created.ID), token, nil)
+	var key models.APIKey
+	if err := json.Unmarshal(w.Body.Bytes(), &key); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if key.LastUsedAt == nil {
+		t.Error("Expected last_used_at to be set after use")
+	}
+}
+
+func TestAPIKeysAreScopedToOrganization(t *testing.T) {
+	router, store := newAPIKeyRouter(t)
+	aliceToken := registerTestUser(t, store, "alice")
+	bobToken := registerTestUser(t, store, "bob")
+
+	created := mintAPIKey(t, router, aliceToken, "customers:read", "customers:write")
+	body := models.CreateCustomerRequest{Name: "Acme", Email: "acme@example.com"}
+	if w := doWithAPIKey(router, "POST", "/api/customers", created.Key, body); w.Code != http.StatusCreated {
+		t.Fatalf("Expected create with key to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The customer landed in alice's organization, not bob's
+	w := doJSON(router, "GET", "/api/customers", bobToken, nil)
+	var page CustomerListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if page.Total != 0 {
+		t.Errorf("Expected bob to see no customers, got %d", page.Total)
+	}
+
+	// Bob cannot see or revoke alice's key
+	path := "/api/api-keys/" + strconv.Itoa(created.ID)
+	if w := doJSON(router, "GET", path, bobToken, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected %d for another organization's key, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := doJSON(router, "DELETE", path, bobToken, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected %d revoking another organization's key, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRevokedAndInvalidAPIKeys(t *testing.T) {
+	router, store := newAPIKeyRouter(t)
+	token := registerTestUser(t, store, "owner")
+	created := mintAPIKey(t, router, token, "customers:read")
+
+	w := doJSON(router, "DELETE", "/api/api-keys/"+strconv.Itoa(created.ID), token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected revoke to return %d, got %d", http.StatusOK, w.Code)
+	}
+
+	for name, key := range map[string]string{
+		"revoked":      created.Key,
+		"wrong secret": created.Key[:len(created.Key)-4] + "AAAA",
+		"malformed":    "not-a-key",
+		"unknown":      "sk_00000000_secret",
+	} {
+		if w := doWithAPIKey(router, "GET", "/api/customers", key, nil); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s key: expected status %d, got %d", name, http.StatusUnauthorized, w.Code)
+		}
+	}
+}
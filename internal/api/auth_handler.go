@@ -1,29 +1,55 @@
 package api
 
 import (
-	"database/sql"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"saas-go-app/internal/auth"
-	"saas-go-app/internal/db"
+	"saas-go-app/internal/logging"
+	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
+// AuthHandler serves the login, registration and session endpoints
+type AuthHandler struct {
+	users  repository.UserRepository
+	orgs   repository.OrganizationRepository
+	tokens repository.TokenRepository
+}
+
+// NewAuthHandler creates an auth handler backed by the given repositories
+func NewAuthHandler(users repository.UserRepository, orgs repository.OrganizationRepository, tokens repository.TokenRepository) *AuthHandler {
+	return &AuthHandler{users: users, orgs: orgs, tokens: tokens}
+}
+
 // LoginRequest represents the login request payload
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// OrgID selects the organization to act in; defaults to the user's first
+	OrgID int `json:"org_id"`
 }
 
 // LoginResponse represents the login response
 type LoginResponse struct {
+	// Token is the access token to send as "Authorization: Bearer <token>"
 	Token string `json:"token"`
+	// ExpiresIn is the access token lifetime in seconds
+	ExpiresIn int `json:"expires_in"`
+	// RefreshToken can be exchanged once at /auth/refresh for a new pair
+	RefreshToken string `json:"refresh_token"`
+	OrgID        int    `json:"org_id"`
+	Role         string `json:"role"`
 }
 
 // Login handles user authentication
 // @Summary      Login user
-// @Description  Authenticate a user and return a JWT token
+// @Description  Authenticate a user and return an access token and a refresh token
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -32,54 +58,127 @@ type LoginResponse struct {
 // @Failure      400          {object}  map[string]string
 // @Failure      401          {object}  map[string]string
 // @Router       /auth/login [post]
-func Login(c *gin.Context) {
+func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
 		return
 	}
 
-	// Query user from database
-	var passwordHash string
-	err := db.PrimaryDB.QueryRow(
-		"SELECT password_hash FROM users WHERE username = $1",
-		req.Username,
-	).Scan(&passwordHash)
-
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	// Look up user
+	user, err := h.users.GetByUsername(c.Request.Context(), req.Username)
+	if errors.Is(err, repository.ErrNotFound) {
+		loginAttempts.WithLabelValues(loginInvalidCredentials).Inc()
+		c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, "Invalid credentials"))
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		loginAttempts.WithLabelValues(loginError).Inc()
+		internalError(c, "Database error", err)
 		return
 	}
 
 	// Verify password
-	if !auth.CheckPasswordHash(req.Password, passwordHash) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	if !auth.CheckPasswordHash(req.Password, user.PasswordHash) {
+		loginAttempts.WithLabelValues(loginInvalidCredentials).Inc()
+		c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, "Invalid credentials"))
+		return
+	}
+
+	// Pick the organization the token is scoped to
+	orgs, err := h.orgs.ListForUser(c.Request.Context(), user.ID)
+	if err != nil {
+		loginAttempts.WithLabelValues(loginError).Inc()
+		internalError(c, "Database error", err)
+		return
+	}
+	org, ok := selectOrganization(orgs, req.OrgID)
+	if !ok {
+		loginAttempts.WithLabelValues(loginForbidden).Inc()
+		c.JSON(http.StatusForbidden, logging.ErrorBody(c, "User is not a member of the requested organization"))
 		return
 	}
 
-	// Generate JWT token
-	token, err := auth.GenerateToken(req.Username)
+	// Start a new session
+	identity := auth.Identity{
+		UserID:   user.ID,
+		Username: user.Username,
+		OrgID:    org.ID,
+		Role:     auth.Role(org.Role),
+		Admin:    user.IsAdmin,
+	}
+	response, err := startSession(c.Request.Context(), h.tokens, identity)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		loginAttempts.WithLabelValues(loginError).Inc()
+		internalError(c, "Failed to generate token", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, LoginResponse{Token: token})
+	loginAttempts.WithLabelValues(loginSuccess).Inc()
+	c.JSON(http.StatusOK, response)
+}
+
+// startSession issues an access token and the first refresh token of a new family
+func startSession(ctx context.Context, tokens repository.TokenRepository, identity auth.Identity) (LoginResponse, error) {
+	familyID, err := auth.NewTokenFamily()
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return LoginResponse{}, err
+	}
+	_, err = tokens.CreateRefreshToken(ctx, models.RefreshToken{
+		TokenHash: hash,
+		FamilyID:  familyID,
+		UserID:    identity.UserID,
+		OrgID:     identity.OrgID,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL),
+	})
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	return newLoginResponse(identity, refreshToken)
+}
+
+func newLoginResponse(identity auth.Identity, refreshToken string) (LoginResponse, error) {
+	token, err := auth.GenerateToken(identity)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+	return LoginResponse{
+		Token:        token,
+		ExpiresIn:    int(auth.AccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+		OrgID:        identity.OrgID,
+		Role:         string(identity.Role),
+	}, nil
+}
+
+// selectOrganization returns requested if the user belongs to it, or the
+// user's first organization when requested is zero
+func selectOrganization(orgs []models.Organization, requested int) (models.Organization, bool) {
+	for _, org := range orgs {
+		if requested == 0 || org.ID == requested {
+			return org, true
+		}
+	}
+	return models.Organization{}, false
 }
 
 // RegisterRequest represents the registration request payload
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required,min=6"`
+	// Organization names the organization created for the new user
+	Organization string `json:"organization"`
 }
 
 // Register handles user registration
 // @Summary      Register new user
-// @Description  Create a new user account
+// @Description  Create a new user account together with an organization they belong to
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -88,30 +187,197 @@ type RegisterRequest struct {
 // @Failure      400   {object}  map[string]string
 // @Failure      409   {object}  map[string]string
 // @Router       /auth/register [post]
-func Register(c *gin.Context) {
+func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
 		return
 	}
 
 	// Hash password
 	passwordHash, err := auth.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		internalError(c, "Failed to hash password", err)
 		return
 	}
 
-	// Insert user into database
-	_, err = db.PrimaryDB.Exec(
-		"INSERT INTO users (username, password_hash) VALUES ($1, $2)",
-		req.Username, passwordHash,
-	)
+	orgName := req.Organization
+	if orgName == "" {
+		orgName = req.Username + "'s Organization"
+	}
+
+	// Create user and their organization
+	_, _, err = h.users.Register(c.Request.Context(), req.Username, passwordHash, orgName)
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, logging.ErrorBody(c, "Username already exists"))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+		internalError(c, "Failed to register user", err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully"})
 }
 
+// RefreshRequest represents the token refresh request payload
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh exchanges a refresh token for a new access token and refresh token
+// @Summary      Refresh session
+// @Description  Exchange a refresh token for a new access token and a new refresh token. Each refresh token works once; presenting a used one revokes every token descended from the same login.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      RefreshRequest  true  "Refresh token"
+// @Success      200      {object}  LoginResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      401      {object}  map[string]string
+// @Router       /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	current, err := h.tokens.GetRefreshToken(ctx, auth.HashRefreshToken(req.RefreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, "Invalid refresh token"))
+		return
+	}
+	if err != nil {
+		internalError(c, "Database error", err)
+		return
+	}
+
+	// A used or revoked token means it leaked or the session was ended
+	if current.UsedAt != nil || current.RevokedAt != nil {
+		h.revokeFamily(ctx, current, "refresh token reuse")
+		c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, "Refresh token has already been used"))
+		return
+	}
+	if time.Now().After(current.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, "Refresh token has expired"))
+		return
+	}
+
+	// Re-read the user and role so membership changes take effect
+	user, err := h.users.GetByID(ctx, current.UserID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		internalError(c, "Database error", err)
+		return
+	}
+	role, roleErr := h.orgs.MemberRole(ctx, current.OrgID, current.UserID)
+	if roleErr != nil && !errors.Is(roleErr, repository.ErrNotFound) {
+		internalError(c, "Database error", roleErr)
+		return
+	}
+	if err != nil || roleErr != nil {
+		h.revokeFamily(ctx, current, "membership ended")
+		c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, "User is no longer a member of this organization"))
+		return
+	}
+
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		internalError(c, "Failed to generate token", err)
+		return
+	}
+	_, err = h.tokens.RotateRefreshToken(ctx, current.ID, models.RefreshToken{
+		TokenHash: hash,
+		FamilyID:  current.FamilyID,
+		UserID:    current.UserID,
+		OrgID:     current.OrgID,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL),
+	})
+	if errors.Is(err, repository.ErrConflict) {
+		// Lost a race with another use of the same token
+		h.revokeFamily(ctx, current, "concurrent refresh token reuse")
+		c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, "Refresh token has already been used"))
+		return
+	}
+	if err != nil {
+		internalError(c, "Failed to rotate refresh token", err)
+		return
+	}
+
+	response, err := newLoginResponse(auth.Identity{
+		UserID:   user.ID,
+		Username: user.Username,
+		OrgID:    current.OrgID,
+		Role:     auth.Role(role),
+		Admin:    user.IsAdmin,
+	}, refreshToken)
+	if err != nil {
+		internalError(c, "Failed to generate token", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// revokeFamily ends every session descended from token's login
+func (h *AuthHandler) revokeFamily(ctx context.Context, token models.RefreshToken, reason string) {
+	slog.WarnContext(ctx, "Revoking refresh token family", "family_id", token.FamilyID, "user_id", token.UserID, "reason", reason)
+	if err := h.tokens.RevokeTokenFamily(ctx, token.FamilyID); err != nil {
+		slog.ErrorContext(ctx, "Failed to revoke refresh token family", "family_id", token.FamilyID, "error", err)
+	}
+}
+
+// LogoutRequest represents the logout request payload
+type LogoutRequest struct {
+	// RefreshToken, when given, is revoked along with its whole family
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Logout revokes the caller's access token and, optionally, their refresh token
+// @Summary      Logout
+// @Description  Revoke the access token used for this request and, if given, the refresh token session it belongs to
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      LogoutRequest  false  "Refresh token to revoke"
+// @Success      200      {object}  map[string]string
+// @Failure      400      {object}  map[string]string
+// @Failure      401      {object}  map[string]string
+// @Router       /auth/logout [post]
+// @Security     BearerAuth
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	claims := auth.CurrentClaims(c)
+	if claims != nil && claims.ID != "" && claims.ExpiresAt != nil {
+		if err := h.tokens.RevokeAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			internalError(c, "Failed to revoke token", err)
+			return
+		}
+	}
+
+	if req.RefreshToken != "" {
+		token, err := h.tokens.GetRefreshToken(ctx, auth.HashRefreshToken(req.RefreshToken))
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			internalError(c, "Database error", err)
+			return
+		}
+		// Only the owner can end a session; unknown tokens are ignored
+		if err == nil && token.UserID == auth.CurrentIdentity(c).UserID {
+			if err := h.tokens.RevokeTokenFamily(ctx, token.FamilyID); err != nil {
+				internalError(c, "Failed to revoke refresh token", err)
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newAuthRouter(t *testing.T) (*gin.Engine, *repository.MemoryStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	if err := auth.InitJWT(auth.JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+
+	store := repository.NewMemoryStore()
+	auth.SetDenylist(store.Tokens())
+	t.Cleanup(func() { auth.SetDenylist(nil) })
+
+	handler := NewAuthHandler(store.Users(), store.Organizations(), store.Tokens())
+	customers := NewCustomerHandler(store.Customers())
+
+	router := gin.New()
+	apiRoutes := router.Group("/api")
+	apiRoutes.POST("/auth/login", handler.Login)
+	apiRoutes.POST("/auth/register", handler.Register)
+	apiRoutes.POST("/auth/refresh", handler.Refresh)
+
+	protected := apiRoutes.Group("")
+	protected.Use(auth.AuthMiddleware())
+	protected.POST("/auth/logout", handler.Logout)
+	protected.GET("/customers", customers.GetCustomers)
+
+	return router, store
+}
+
+// loginTestUser registers a user through the API and logs them in
+func loginTestUser(t *testing.T, router *gin.Engine, username string) LoginResponse {
+	t.Helper()
+
+	credentials := gin.H{"username": username, "password": "secret123"}
+	if w := doJSON(router, "POST", "/api/auth/register", "", credentials); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to register: %d %s", w.Code, w.Body.String())
+	}
+	w := doJSON(router, "POST", "/api/auth/login", "", credentials)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to log in: %d %s", w.Code, w.Body.String())
+	}
+	return decodeLoginResponse(t, w.Body.Bytes())
+}
+
+func decodeLoginResponse(t *testing.T, body []byte) LoginResponse {
+	t.Helper()
+
+	var response LoginResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response
+}
+
+func TestLoginIssuesRefreshToken(t *testing.T) {
+	router, _ := newAuthRouter(t)
+	session := loginTestUser(t, router, "alice")
+
+	if session.Token == "" || session.RefreshToken == "" {
+		t.Fatalf("Expected access and refresh tokens, got %+v", session)
+	}
+	if session.ExpiresIn != int(auth.AccessTokenTTL.Seconds()) {
+		t.Errorf("Expected expires_in %d, got %d", int(auth.AccessTokenTTL.Seconds()), session.ExpiresIn)
+	}
+	if session.Role != string(auth.RoleOwner) {
+		t.Errorf("Expected owner role, got %q", session.Role)
+	}
+}
+
+func TestLoginCountsAttempts(t *testing.T) {
+	router, _ := newAuthRouter(t)
+	success := testutil.ToFloat64(loginAttempts.WithLabelValues(loginSuccess))
+	invalid := testutil.ToFloat64(loginAttempts.WithLabelValues(loginInvalidCredentials))
+
+	loginTestUser(t, router, "counted")
+	doJSON(router, "POST", "/api/auth/login", "", gin.H{"username": "counted", "password": "wrong-password"})
+	doJSON(router, "POST", "/api/auth/login", "", gin.H{"username": "nobody", "password": "secret123"})
+
+	if got := testutil.ToFloat64(loginAttempts.WithLabelValues(loginSuccess)) - success; got != 1 {
+		t.Errorf("Expected 1 successful login, got %v", got)
+	}
+	if got := testutil.ToFloat64(loginAttempts.WithLabelValues(loginInvalidCredentials)) - invalid; got != 2 {
+		t.Errorf("Expected 2 failed logins, got %v", got)
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	router, _ := newAuthRouter(t)
+	session := loginTestUser(t, router, "alice")
+
+	w := doJSON(router, "POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	rotated := decodeLoginResponse(t, w.Body.Bytes())
+
+	if rotated.RefreshToken == "" || rotated.RefreshToken == session.RefreshToken {
+		t.Fatal("Expected a new refresh token")
+	}
+	if rotated.OrgID != session.OrgID {
+		t.Errorf("Expected org %d to carry over, got %d", session.OrgID, rotated.OrgID)
+	}
+	if w := doJSON(router, "GET", "/api/customers", rotated.Token, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected refreshed access token to work, got %d", w.Code)
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	router, _ := newAuthRouter(t)
+	session := loginTestUser(t, router, "alice")
+
+	w := doJSON(router, "POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected first refresh to succeed, got %d", w.Code)
+	}
+	rotated := decodeLoginResponse(t, w.Body.Bytes())
+
+	// Replaying the used token is rejected...
+	w = doJSON(router, "POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected reuse to return %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// ...and kills the legitimate descendant too
+	w = doJSON(router, "POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: rotated.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected family to be revoked after reuse, got %d", w.Code)
+	}
+}
+
+func TestRefreshRejectsUnknownToken(t *testing.T) {
+	router, _ := newAuthRouter(t)
+
+	w := doJSON(router, "POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: "not-a-token"})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestRefreshPicksUpRoleChanges(t *testing.T) {
+	router, store := newAuthRouter(t)
+	session := loginTestUser(t, router, "alice")
+
+	claims, err := auth.ValidateToken(context.Background(), session.Token)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	err = store.Organizations().SetMemberRole(context.Background(), session.OrgID, claims.UserID, string(auth.RoleReadOnly))
+	if err != nil {
+		t.Fatalf("Failed to set role: %v", err)
+	}
+
+	w := doJSON(router, "POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if role := decodeLoginResponse(t, w.Body.Bytes()).Role; role != string(auth.RoleReadOnly) {
+		t.Errorf("Expected refreshed role %q, got %q", auth.RoleReadOnly, role)
+	}
+}
+
+func TestLogoutRevokesTokens(t *testing.T) {
+	router, _ := newAuthRouter(t)
+	session := loginTestUser(t, router, "alice")
+
+	w := doJSON(router, "POST", "/api/auth/logout", session.Token, LogoutRequest{RefreshToken: session.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	if w := doJSON(router, "GET", "/api/customers", session.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected revoked access token to be rejected, got %d", w.Code)
+	}
+	w = doJSON(router, "POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected revoked refresh token to be rejected, got %d", w.Code)
+	}
+}
@@ -1,47 +1,100 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
-	"saas-go-app/internal/db"
+	"saas-go-app/internal/logging"
 	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-// GetCustomers retrieves all customers
-// @Summary      List all customers
-// @Description  Get a list of all customers
+// CustomerHandler serves the customer endpoints
+type CustomerHandler struct {
+	customers repository.CustomerRepository
+}
+
+// NewCustomerHandler creates a customer handler backed by the given repository
+func NewCustomerHandler(customers repository.CustomerRepository) *CustomerHandler {
+	return &CustomerHandler{customers: customers}
+}
+
+// GetCustomers retrieves a page of customers
+// @Summary      List customers
+// @Description  Get a page of customers, newest first by default. Use limit and cursor for keyset pagination, or page and per_page for offset pagination. Page links are returned in the Link header.
 // @Tags         customers
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   models.Customer
-// @Failure      500  {object}  map[string]string
+// @Param        q               query     string  false  "Fuzzy search on customer name or email"
+// @Param        created_after   query     string  false  "Only customers created at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param        created_before  query     string  false  "Only customers created before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param        sort            query     string  false  "Sort field, prefix with - for descending (created_at, updated_at, name, email, id)"  default(-created_at)
+// @Param        limit           query     int     false  "Page size for cursor pagination (default 50, max 200)"
+// @Param        cursor          query     string  false  "Cursor from next_cursor of the previous page"
+// @Param        page            query     int     false  "Page number for offset pagination"
+// @Param        per_page        query     int     false  "Page size for offset pagination (default 50, max 200)"
+// @Success      200             {object}  CustomerListResponse
+// @Failure      400             {object}  map[string]string
+// @Failure      403             {object}  map[string]string
+// @Failure      500             {object}  map[string]string
 // @Router       /customers [get]
 // @Security     BearerAuth
-func GetCustomers(c *gin.Context) {
-	rows, err := db.PrimaryDB.Query(
-		"SELECT id, name, email, created_at, updated_at FROM customers ORDER BY created_at DESC",
-	)
+// @Security     ApiKeyAuth
+func (h *CustomerHandler) GetCustomers(c *gin.Context) {
+	filter, err := parseCustomerFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+
+	pageReq, err := parsePageRequest(c, repository.ParseCustomerSort)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch customers"})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
 		return
 	}
-	defer rows.Close()
 
-	var customers []models.Customer
-	for rows.Next() {
-		var customer models.Customer
-		if err := rows.Scan(&customer.ID, &customer.Name, &customer.Email, &customer.CreatedAt, &customer.UpdatedAt); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scan customer"})
-			return
-		}
-		customers = append(customers, customer)
+	page, err := h.customers.List(c.Request.Context(), filter, pageReq)
+	if isBadListRequest(err) {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+	if err != nil {
+		internalError(c, "Failed to fetch customers", err)
+		return
+	}
+
+	setLinkHeader(c, pageReq, page.Total, page.NextCursor)
+	pageNumber, perPage := pageNumbers(pageReq)
+	c.JSON(http.StatusOK, CustomerListResponse{
+		Data:       page.Items,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+		Page:       pageNumber,
+		PerPage:    perPage,
+	})
+}
+
+// parseCustomerFilter reads the customer list filters from the query string
+func parseCustomerFilter(c *gin.Context) (repository.CustomerFilter, error) {
+	var filter repository.CustomerFilter
+	var err error
+
+	filter.Search = strings.TrimSpace(c.Query("q"))
+	if len(filter.Search) > 100 {
+		return filter, errors.New("q must be at most 100 characters")
 	}
 
-	c.JSON(http.StatusOK, customers)
+	if filter.CreatedAfter, err = parseTimeQuery(c, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseTimeQuery(c, "created_before"); err != nil {
+		return filter, err
+	}
+	return filter, nil
 }
 
 // GetCustomer retrieves a single customer by ID
@@ -53,28 +106,25 @@ func GetCustomers(c *gin.Context) {
 // @Param        id   path      int  true  "Customer ID"
 // @Success      200  {object}  models.Customer
 // @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
 // @Router       /customers/{id} [get]
 // @Security     BearerAuth
-func GetCustomer(c *gin.Context) {
+// @Security     ApiKeyAuth
+func (h *CustomerHandler) GetCustomer(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Invalid customer ID"))
 		return
 	}
 
-	var customer models.Customer
-	err = db.PrimaryDB.QueryRow(
-		"SELECT id, name, email, created_at, updated_at FROM customers WHERE id = $1",
-		id,
-	).Scan(&customer.ID, &customer.Name, &customer.Email, &customer.CreatedAt, &customer.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+	customer, err := h.customers.Get(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Customer not found"))
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch customer"})
+		internalError(c, "Failed to fetch customer", err)
 		return
 	}
 
@@ -90,23 +140,25 @@ func GetCustomer(c *gin.Context) {
 // @Param        customer  body      models.CreateCustomerRequest  true  "Customer data"
 // @Success      201       {object}  models.Customer
 // @Failure      400       {object}  map[string]string
+// @Failure      403       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
 // @Router       /customers [post]
 // @Security     BearerAuth
-func CreateCustomer(c *gin.Context) {
+// @Security     ApiKeyAuth
+func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
 	var req models.CreateCustomerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
 		return
 	}
 
-	var customer models.Customer
-	err := db.PrimaryDB.QueryRow(
-		"INSERT INTO customers (name, email) VALUES ($1, $2) RETURNING id, name, email, created_at, updated_at",
-		req.Name, req.Email,
-	).Scan(&customer.ID, &customer.Name, &customer.Email, &customer.CreatedAt, &customer.UpdatedAt)
-
+	customer, err := h.customers.Create(c.Request.Context(), req)
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, logging.ErrorBody(c, "A customer with this email already exists"))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create customer"})
+		internalError(c, "Failed to create customer", err)
 		return
 	}
 
@@ -123,34 +175,36 @@ func CreateCustomer(c *gin.Context) {
 // @Param        customer   body      models.UpdateCustomerRequest  true  "Updated customer data"
 // @Success      200        {object}  models.Customer
 // @Failure      400        {object}  map[string]string
+// @Failure      403        {object}  map[string]string
 // @Failure      404        {object}  map[string]string
+// @Failure      409        {object}  map[string]string
 // @Router       /customers/{id} [put]
 // @Security     BearerAuth
-func UpdateCustomer(c *gin.Context) {
+// @Security     ApiKeyAuth
+func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Invalid customer ID"))
 		return
 	}
 
 	var req models.UpdateCustomerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
 		return
 	}
 
-	var customer models.Customer
-	err = db.PrimaryDB.QueryRow(
-		"UPDATE customers SET name = $1, email = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3 RETURNING id, name, email, created_at, updated_at",
-		req.Name, req.Email, id,
-	).Scan(&customer.ID, &customer.Name, &customer.Email, &customer.CreatedAt, &customer.UpdatedAt)
-
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+	customer, err := h.customers.Update(c.Request.Context(), id, req)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Customer not found"))
+		return
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, logging.ErrorBody(c, "A customer with this email already exists"))
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update customer"})
+		internalError(c, "Failed to update customer", err)
 		return
 	}
 
@@ -166,28 +220,27 @@ func UpdateCustomer(c *gin.Context) {
 // @Param        id   path      int  true  "Customer ID"
 // @Success      200  {object}  map[string]string
 // @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
 // @Router       /customers/{id} [delete]
 // @Security     BearerAuth
-func DeleteCustomer(c *gin.Context) {
+// @Security     ApiKeyAuth
+func (h *CustomerHandler) DeleteCustomer(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Invalid customer ID"))
 		return
 	}
 
-	result, err := db.PrimaryDB.Exec("DELETE FROM customers WHERE id = $1", id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete customer"})
+	err = h.customers.Delete(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Customer not found"))
 		return
 	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+	if err != nil {
+		internalError(c, "Failed to delete customer", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Customer deleted successfully"})
 }
-
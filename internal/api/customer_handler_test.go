@@ -2,48 +2,392 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"saas-go-app/internal/apierror"
 	"saas-go-app/internal/auth"
 	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-func setupTestDB(t *testing.T) {
-	// This would require a test database setup
-	// For now, this is a placeholder
+// setupCustomerRouter wires the customer handler to an in-memory store and
+// returns a token for a user in a fresh organization
+func setupCustomerRouter(t *testing.T) (*gin.Engine, string) {
+	t.Helper()
+	router, store := newCustomerRouter(t)
+	return router, registerTestUser(t, store, "testuser")
 }
 
-func TestCreateCustomer(t *testing.T) {
+func newCustomerRouter(t *testing.T) (*gin.Engine, *repository.MemoryStore) {
+	t.Helper()
 	gin.SetMode(gin.TestMode)
 
 	// Initialize auth for testing
-	_ = auth.InitJWT()
+	if err := auth.InitJWT(auth.JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+
+	store := repository.NewMemoryStore()
+	handler := NewCustomerHandler(store.Customers())
 
 	router := gin.New()
 	apiRoutes := router.Group("/api")
 	apiRoutes.Use(auth.AuthMiddleware())
-	apiRoutes.POST("/customers", CreateCustomer)
+	apiRoutes.GET("/customers", auth.RequirePermission(auth.PermCustomersRead), handler.GetCustomers)
+	apiRoutes.GET("/customers/:id", auth.RequirePermission(auth.PermCustomersRead), handler.GetCustomer)
+	apiRoutes.POST("/customers", auth.RequirePermission(auth.PermCustomersWrite), handler.CreateCustomer)
+	apiRoutes.DELETE("/customers/:id", auth.RequirePermission(auth.PermCustomersDelete), handler.DeleteCustomer)
 
-	reqBody := models.CreateCustomerRequest{
-		Name:  "Test Customer",
-		Email: "test@example.com",
+	return router, store
+}
+
+// registerTestUser creates a user owning their own organization and returns a token
+func registerTestUser(t *testing.T, store *repository.MemoryStore, username string) string {
+	t.Helper()
+	return registerTestUserWithRole(t, store, username, auth.RoleOwner)
+}
+
+// registerTestUserWithRole is registerTestUser with the user's role in their
+// organization changed to role
+func registerTestUserWithRole(t *testing.T, store *repository.MemoryStore, username string, role auth.Role) string {
+	t.Helper()
+
+	ctx := context.Background()
+	user, org, err := store.Users().Register(ctx, username, "unused", username+" org")
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	if err := store.Organizations().SetMemberRole(ctx, org.ID, user.ID, string(role)); err != nil {
+		t.Fatalf("Failed to set role: %v", err)
+	}
+	token, err := auth.GenerateToken(auth.Identity{UserID: user.ID, Username: user.Username, OrgID: org.ID, Role: role})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	return token
+}
+
+func doJSON(router *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
 	}
-	jsonBody, _ := json.Marshal(reqBody)
 
-	req, _ := http.NewRequest("POST", "/api/customers", bytes.NewBuffer(jsonBody))
+	req, _ := http.NewRequest(method, path, &buf)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer test-token")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateCustomer(t *testing.T) {
+	router, token := setupCustomerRouter(t)
+
+	reqBody := models.CreateCustomerRequest{
+		Name:  "Test Customer",
+		Email: "test@example.com",
+	}
+
+	w := doJSON(router, "POST", "/api/customers", token, reqBody)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var customer models.Customer
+	if err := json.Unmarshal(w.Body.Bytes(), &customer); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if customer.ID == 0 || customer.Name != reqBody.Name || customer.Email != reqBody.Email {
+		t.Errorf("Unexpected customer in response: %+v", customer)
+	}
+
+	// The created customer can be fetched back
+	w = doJSON(router, "GET", "/api/customers/1", token, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d fetching created customer, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestCreateCustomerDuplicateEmail(t *testing.T) {
+	router, token := setupCustomerRouter(t)
+
+	reqBody := models.CreateCustomerRequest{Name: "First", Email: "dup@example.com"}
+	if w := doJSON(router, "POST", "/api/customers", token, reqBody); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	reqBody.Name = "Second"
+	if w := doJSON(router, "POST", "/api/customers", token, reqBody); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestCreateCustomerValidation(t *testing.T) {
+	router, token := setupCustomerRouter(t)
+
+	reqBody := models.CreateCustomerRequest{Name: "No Email"}
+	if w := doJSON(router, "POST", "/api/customers", token, reqBody); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestCreateCustomerRequiresAuth(t *testing.T) {
+	router, _ := setupCustomerRouter(t)
+
+	reqBody := models.CreateCustomerRequest{Name: "Test Customer", Email: "test@example.com"}
+	if w := doJSON(router, "POST", "/api/customers", "test-token", reqBody); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestGetCustomerNotFound(t *testing.T) {
+	router, token := setupCustomerRouter(t)
+
+	if w := doJSON(router, "GET", "/api/customers/999", token, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// failingCustomers fails every Get with err, after cancelling the
+// request's context with cancel when it is set
+type failingCustomers struct {
+	repository.CustomerRepository
+	err    error
+	cancel context.CancelFunc
+}
+
+func (r failingCustomers) Get(ctx context.Context, id int) (models.Customer, error) {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	return models.Customer{}, r.err
+}
 
-	if w.Code != http.StatusCreated && w.Code != http.StatusUnauthorized {
+func TestGetCustomerDatabaseErrors(t *testing.T) {
+	_, store := newCustomerRouter(t)
+	token := registerTestUser(t, store, "testuser")
+	canceled := fmt.Errorf("get customer: %w", repository.ErrQueryCanceled)
+
+	tests := []struct {
+		name     string
+		err      error
+		cancel   bool
+		deadline bool
+		want     int
+	}{
+		{"unavailable", fmt.Errorf("begin transaction: %w", repository.ErrUnavailable), false, false, http.StatusServiceUnavailable},
+		{"statement timeout", canceled, false, false, http.StatusGatewayTimeout},
+		{"route deadline", canceled, false, true, http.StatusGatewayTimeout},
+		{"client gone", canceled, true, false, apierror.StatusClientClosedRequest},
+		{"other", errors.New("boom"), false, false, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		repo := failingCustomers{err: tt.err}
+		router := gin.New()
+		router.GET("/api/customers/:id", auth.AuthMiddleware(), func(c *gin.Context) {
+			ctx, cancel := context.WithCancel(c.Request.Context())
+			defer cancel()
+			if tt.deadline {
+				ctx, cancel = context.WithDeadline(ctx, time.Now())
+				defer cancel()
+			}
+			if tt.cancel {
+				repo.cancel = cancel
+			}
+			c.Request = c.Request.WithContext(ctx)
+			NewCustomerHandler(repo).GetCustomer(c)
+		})
+
+		if w := doJSON(router, "GET", "/api/customers/1", token, nil); w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, w.Code)
+		}
+	}
+}
+
+func TestGetCustomersPagination(t *testing.T) {
+	router, token := setupCustomerRouter(t)
+
+	for i := 1; i <= 5; i++ {
+		reqBody := models.CreateCustomerRequest{
+			Name:  "Customer",
+			Email: "customer" + strconv.Itoa(i) + "@example.com",
+		}
+		if w := doJSON(router, "POST", "/api/customers", token, reqBody); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+	}
+
+	// Walk the list with cursors, two at a time
+	var seen []int
+	path := "/api/customers?limit=2"
+	for pages := 0; path != ""; pages++ {
+		if pages > 5 {
+			t.Fatal("Cursor pagination did not terminate")
+		}
+
+		w := doJSON(router, "GET", path, token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var resp CustomerListResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Total != 5 {
+			t.Errorf("Expected total 5, got %d", resp.Total)
+		}
+		for _, customer := range resp.Data {
+			seen = append(seen, customer.ID)
+		}
+
+		path = ""
+		if resp.NextCursor != "" {
+			if !strings.Contains(w.Header().Get("Link"), `rel="next"`) {
+				t.Errorf("Expected a next Link header, got %q", w.Header().Get("Link"))
+			}
+			path = "/api/customers?limit=2&cursor=" + resp.NextCursor
+		}
+	}
+
+	if len(seen) != 5 || seen[0] != 5 || seen[4] != 1 {
+		t.Errorf("Expected customers 5..1 newest first, got %v", seen)
+	}
+
+	// Offset mode
+	w := doJSON(router, "GET", "/api/customers?page=3&per_page=2", token, nil)
+	var resp CustomerListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Data) != 1 || resp.Data[0].ID != 1 || resp.Page != 3 {
+		t.Errorf("Unexpected last page: %+v", resp)
+	}
+	if !strings.Contains(w.Header().Get("Link"), `rel="prev"`) {
+		t.Errorf("Expected a prev Link header, got %q", w.Header().Get("Link"))
+	}
+
+	// Mixing modes is rejected
+	if w := doJSON(router, "GET", "/api/customers?limit=2&page=1", token, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestGetCustomersSearchAndSort(t *testing.T) {
+	router, token := setupCustomerRouter(t)
+
+	for _, customer := range []models.CreateCustomerRequest{
+		{Name: "Acme Corporation", Email: "contact@acme.com"},
+		{Name: "Globex", Email: "info@globex.com"},
+		{Name: "Acme Labs", Email: "labs@example.com"},
+	} {
+		if w := doJSON(router, "POST", "/api/customers", token, customer); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+	}
+
+	w := doJSON(router, "GET", "/api/customers?q=ACME&sort=name", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp CustomerListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Total != 2 || resp.Data[0].Name != "Acme Corporation" || resp.Data[1].Name != "Acme Labs" {
+		t.Errorf("Unexpected search results: %+v", resp.Data)
+	}
+
+	if w := doJSON(router, "GET", "/api/customers?sort=password_hash", token, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for unknown sort field, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := doJSON(router, "GET", "/api/customers?created_after=yesterday", token, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for invalid date, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestCustomersAreIsolatedByOrganization(t *testing.T) {
+	router, store := newCustomerRouter(t)
+	aliceToken := registerTestUser(t, store, "alice")
+	bobToken := registerTestUser(t, store, "bob")
+
+	reqBody := models.CreateCustomerRequest{Name: "Alice's Customer", Email: "shared@example.com"}
+	if w := doJSON(router, "POST", "/api/customers", aliceToken, reqBody); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	// Bob cannot see or fetch Alice's customer
+	w := doJSON(router, "GET", "/api/customers", bobToken, nil)
+	var resp CustomerListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Total != 0 {
+		t.Errorf("Expected bob to see no customers, got %d", resp.Total)
+	}
+	if w := doJSON(router, "GET", "/api/customers/1", bobToken, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	// Emails are only unique within an organization
+	reqBody.Name = "Bob's Customer"
+	if w := doJSON(router, "POST", "/api/customers", bobToken, reqBody); w.Code != http.StatusCreated {
 		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
 	}
 }
 
+func TestCustomerRoutesEnforceRoles(t *testing.T) {
+	router, store := newCustomerRouter(t)
+	memberToken := registerTestUserWithRole(t, store, "member", auth.RoleMember)
+	readOnlyToken := registerTestUserWithRole(t, store, "viewer", auth.RoleReadOnly)
+
+	reqBody := models.CreateCustomerRequest{Name: "Acme", Email: "acme@example.com"}
+
+	// Read-only users can list but not create
+	if w := doJSON(router, "GET", "/api/customers", readOnlyToken, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected read-only list to return %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := doJSON(router, "POST", "/api/customers", readOnlyToken, reqBody); w.Code != http.StatusForbidden {
+		t.Errorf("Expected read-only create to return %d, got %d", http.StatusForbidden, w.Code)
+	}
+
+	// Members can create but not delete
+	w := doJSON(router, "POST", "/api/customers", memberToken, reqBody)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected member create to return %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var customer models.Customer
+	if err := json.Unmarshal(w.Body.Bytes(), &customer); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	path := "/api/customers/" + strconv.Itoa(customer.ID)
+	w = doJSON(router, "DELETE", path, memberToken, nil)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected member delete to return %d, got %d", http.StatusForbidden, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "customers:delete") {
+		t.Errorf("Expected 403 body to name the missing permission, got %s", w.Body.String())
+	}
+
+	// The customer is untouched
+	if w := doJSON(router, "GET", path, memberToken, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected customer to survive forbidden delete, got %d", w.Code)
+	}
+}
@@ -0,0 +1,13 @@
+package api
+
+import (
+	"saas-go-app/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// internalError responds to a failure the client cannot fix, with the
+// status apierror.Respond picks for err
+func internalError(c *gin.Context, message string, err error) {
+	apierror.Respond(c, message, err)
+}
@@ -3,56 +3,50 @@ package api
 import (
 	"net/http"
 
-	"saas-go-app/internal/db"
+	"saas-go-app/internal/health"
 
 	"github.com/gin-gonic/gin"
 )
 
-// HealthResponse represents the health check response
-type HealthResponse struct {
-	Status      string `json:"status"`
-	Database    string `json:"database"`
-	AnalyticsDB string `json:"analytics_db"`
+// LivenessResponse represents the liveness check response
+type LivenessResponse struct {
+	Status health.Status `json:"status" example:"up"`
 }
 
-// HealthCheck performs a health check on the service
-// @Summary      Health check
-// @Description  Check the health status of the service and database connections
+// HealthHandler handles the liveness and readiness checks
+type HealthHandler struct {
+	checks *health.Registry
+}
+
+// NewHealthHandler creates a health handler reporting on checks
+func NewHealthHandler(checks *health.Registry) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Live reports that the process is running
+// @Summary      Liveness check
+// @Description  Report that the process is up and serving requests. Dependencies are not checked, so a database outage does not get the process restarted.
 // @Tags         health
-// @Accept       json
 // @Produce      json
-// @Success      200  {object}  HealthResponse
-// @Failure      503  {object}  HealthResponse
-// @Router       /health [get]
-func HealthCheck(c *gin.Context) {
-	response := HealthResponse{
-		Status: "healthy",
-	}
+// @Success      200  {object}  LivenessResponse
+// @Router       /health/live [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, LivenessResponse{Status: health.StatusUp})
+}
 
-	// Check primary database
-	if err := db.PrimaryDB.Ping(); err != nil {
-		response.Status = "unhealthy"
-		response.Database = "disconnected"
-		c.JSON(http.StatusServiceUnavailable, response)
+// Ready checks every dependency
+// @Summary      Readiness check
+// @Description  Check every dependency, reporting each check's status, latency and most recent error, summarized; full errors are only logged. The service is down, and the response 503, when a critical dependency fails; it is degraded, but ready, when another one does. /health is an alias.
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  health.Report
+// @Failure      503  {object}  health.Report
+// @Router       /health/ready [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.checks.Run(c.Request.Context())
+	if !report.Ready() {
+		c.JSON(http.StatusServiceUnavailable, report)
 		return
 	}
-	response.Database = "connected"
-
-	// Check analytics database
-	if db.AnalyticsDB != nil && db.AnalyticsDB != db.PrimaryDB {
-		if err := db.AnalyticsDB.Ping(); err != nil {
-			response.AnalyticsDB = "disconnected"
-		} else {
-			response.AnalyticsDB = "connected"
-		}
-	} else {
-		response.AnalyticsDB = "using primary"
-	}
-
-	if response.Status == "healthy" {
-		c.JSON(http.StatusOK, response)
-	} else {
-		c.JSON(http.StatusServiceUnavailable, response)
-	}
+	c.JSON(http.StatusOK, report)
 }
-
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+
+	"saas-go-app/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadyReportsChecks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var primaryErr error
+	checks := health.NewRegistry(0)
+	checks.Register("primary", true, func(context.Context) error { return primaryErr })
+	checks.Register("queue", false, func(context.Context) error { return fmt.Errorf("dial tcp 10.0.0.7:6379: %w", syscall.ECONNREFUSED) })
+
+	handler := NewHealthHandler(checks)
+	router := gin.New()
+	router.GET("/health/live", handler.Live)
+	router.GET("/health/ready", handler.Ready)
+
+	w := doJSON(router, http.MethodGet, "/health/ready", "", nil)
+	var report health.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if w.Code != http.StatusOK || report.Status != health.StatusDegraded {
+		t.Errorf("Expected a degraded but ready service, got %d %s", w.Code, w.Body.String())
+	}
+	if queue := report.Checks["queue"]; queue.Critical || queue.Error != "connection refused" || queue.LastError != "connection refused" || queue.LastErrorAt == nil {
+		t.Errorf("Expected the queue check's error, got %+v", queue)
+	}
+	// Driver errors name hosts and stay in the logs
+	if strings.Contains(w.Body.String(), "10.0.0.7") {
+		t.Errorf("Expected the report to leave out the queue's address, got %s", w.Body.String())
+	}
+
+	primaryErr = errors.New("database is shutting down")
+	if w := doJSON(router, http.MethodGet, "/health/ready", "", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 with the primary down, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodGet, "/health/live", "", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected liveness not to depend on the primary, got %d", w.Code)
+	}
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"net/http"
+
+	"saas-go-app/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publishes the public keys access tokens are signed with
+// @Summary      JSON Web Key Set
+// @Description  Public keys for verifying access tokens issued by this service, identified by the token's kid header. Keys appear here before they are used to sign and stay after they are retired.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  auth.JWKSet
+// @Router       /.well-known/jwks.json [get]
+func JWKS(c *gin.Context) {
+	// Short enough that verifiers see a new key well before it signs
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, auth.PublicKeys())
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/logging"
+	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MemberHandler serves the endpoints managing the active organization's members
+type MemberHandler struct {
+	orgs  repository.OrganizationRepository
+	users repository.UserRepository
+}
+
+// NewMemberHandler creates a member handler backed by the given repositories
+func NewMemberHandler(orgs repository.OrganizationRepository, users repository.UserRepository) *MemberHandler {
+	return &MemberHandler{orgs: orgs, users: users}
+}
+
+// GetMembers lists the organization's members
+// @Summary      List members
+// @Description  Get the members of the active organization and their roles
+// @Tags         members
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   models.Member
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /members [get]
+// @Security     BearerAuth
+func (h *MemberHandler) GetMembers(c *gin.Context) {
+	members, err := h.orgs.ListMembers(c.Request.Context(), auth.CurrentIdentity(c).OrgID)
+	if err != nil {
+		internalError(c, "Failed to fetch members", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// AddMember adds an existing user to the organization
+// @Summary      Add member
+// @Description  Add a registered user to the active organization, as a member unless another role is given. Only owners may add owners.
+// @Tags         members
+// @Accept       json
+// @Produce      json
+// @Param        member  body      models.AddMemberRequest  true  "Member data"
+// @Success      201     {object}  models.Member
+// @Failure      400     {object}  map[string]string
+// @Failure      403     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      409     {object}  map[string]string
+// @Router       /members [post]
+// @Security     BearerAuth
+func (h *MemberHandler) AddMember(c *gin.Context) {
+	var req models.AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+	if req.Role == "" {
+		req.Role = string(auth.RoleMember)
+	}
+	identity := auth.CurrentIdentity(c)
+	if !checkGrantableRole(c, identity, req.Role) {
+		return
+	}
+
+	user, err := h.users.GetByUsername(c.Request.Context(), req.Username)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "User not found"))
+		return
+	}
+	if err != nil {
+		internalError(c, "Failed to fetch user", err)
+		return
+	}
+
+	member, err := h.orgs.AddMember(c.Request.Context(), identity.OrgID, user.ID, req.Role)
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, logging.ErrorBody(c, "User is already a member"))
+		return
+	}
+	if err != nil {
+		internalError(c, "Failed to add member", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// UpdateMember changes a member's role
+// @Summary      Change member role
+// @Description  Change the role of a member of the active organization. Only owners may grant the owner role or change an owner's. Nobody can change their own role. The change applies when the member next logs in, refreshes their token or switches organization.
+// @Tags         members
+// @Accept       json
+// @Produce      json
+// @Param        user_id  path      int                         true  "User ID"
+// @Param        member   body      models.UpdateMemberRequest  true  "Member data"
+// @Success      200      {object}  models.Member
+// @Failure      400      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Router       /members/{user_id} [put]
+// @Security     BearerAuth
+func (h *MemberHandler) UpdateMember(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Invalid user ID"))
+		return
+	}
+	var req models.UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+
+	identity := auth.CurrentIdentity(c)
+	// An owner demoting themselves could leave the organization without one
+	if userID == identity.UserID {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "You cannot change your own role"))
+		return
+	}
+	if !checkGrantableRole(c, identity, req.Role) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	current, err := h.orgs.MemberRole(ctx, identity.OrgID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Member not found"))
+		return
+	}
+	if err != nil {
+		internalError(c, "Failed to fetch member", err)
+		return
+	}
+	if auth.Role(current) == auth.RoleOwner && identity.Role != auth.RoleOwner {
+		c.JSON(http.StatusForbidden, logging.ErrorBody(c, "Only owners can change an owner's role"))
+		return
+	}
+
+	user, err := h.users.GetByID(ctx, userID)
+	if err != nil {
+		internalError(c, "Failed to fetch user", err)
+		return
+	}
+	err = h.orgs.SetMemberRole(ctx, identity.OrgID, userID, req.Role)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Member not found"))
+		return
+	}
+	if err != nil {
+		internalError(c, "Failed to change member role", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Member{UserID: user.ID, Username: user.Username, Role: req.Role})
+}
+
+// checkGrantableRole responds with 400 or 403 and returns false unless the
+// identity may give someone role: a defined role, and owner only by owners
+func checkGrantableRole(c *gin.Context, identity auth.Identity, role string) bool {
+	if !auth.ValidRole(role) {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Invalid role"))
+		return false
+	}
+	if auth.Role(role) == auth.RoleOwner && identity.Role != auth.RoleOwner {
+		c.JSON(http.StatusForbidden, logging.ErrorBody(c, "Only owners can grant the owner role"))
+		return false
+	}
+	return true
+}
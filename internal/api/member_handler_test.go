@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newMemberRouter(t *testing.T) (*gin.Engine, *repository.MemoryStore) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	if err := auth.InitJWT(auth.JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+
+	store := repository.NewMemoryStore()
+	authHandler := NewAuthHandler(store.Users(), store.Organizations(), store.Tokens())
+	orgs := NewOrganizationHandler(store.Organizations(), store.Tokens())
+	members := NewMemberHandler(store.Organizations(), store.Users())
+	customers := NewCustomerHandler(store.Customers())
+
+	router := gin.New()
+	apiRoutes := router.Group("/api")
+	apiRoutes.POST("/auth/login", authHandler.Login)
+	apiRoutes.POST("/auth/register", authHandler.Register)
+	apiRoutes.POST("/auth/refresh", authHandler.Refresh)
+
+	protected := apiRoutes.Group("")
+	protected.Use(auth.AuthMiddleware())
+	protected.POST("/organizations/:id/switch", auth.RequireUser(), orgs.SwitchOrganization)
+	memberRoutes := protected.Group("/members")
+	memberRoutes.Use(auth.RequireUser(), auth.RequirePermission(auth.PermMembersManage))
+	memberRoutes.GET("", members.GetMembers)
+	memberRoutes.POST("", members.AddMember)
+	memberRoutes.PUT("/:user_id", members.UpdateMember)
+	protected.POST("/customers", auth.RequirePermission(auth.PermCustomersWrite), customers.CreateCustomer)
+	protected.DELETE("/customers/:id", auth.RequirePermission(auth.PermCustomersDelete), customers.DeleteCustomer)
+
+	return router, store
+}
+
+// switchTestUser starts a session of the user behind token in orgID
+func switchTestUser(t *testing.T, router *gin.Engine, token string, orgID int) LoginResponse {
+	t.Helper()
+
+	w := doJSON(router, "POST", "/api/organizations/"+strconv.Itoa(orgID)+"/switch", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to switch organization: %d %s", w.Code, w.Body.String())
+	}
+	return decodeLoginResponse(t, w.Body.Bytes())
+}
+
+func TestAddMember(t *testing.T) {
+	router, _ := newMemberRouter(t)
+	alice := loginTestUser(t, router, "alice")
+	bob := loginTestUser(t, router, "bob")
+
+	// bob is no member of alice's organization yet
+	if w := doJSON(router, "POST", "/api/organizations/"+strconv.Itoa(alice.OrgID)+"/switch", bob.Token, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status %d switching to a foreign organization, got %d", http.StatusForbidden, w.Code)
+	}
+
+	w := doJSON(router, "POST", "/api/members", alice.Token, models.AddMemberRequest{Username: "bob"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var member models.Member
+	if err := json.Unmarshal(w.Body.Bytes(), &member); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if member.Username != "bob" || member.Role != string(auth.RoleMember) {
+		t.Errorf("Expected bob to join as a member, got %+v", member)
+	}
+
+	session := switchTestUser(t, router, bob.Token, alice.OrgID)
+	if session.Role != string(auth.RoleMember) {
+		t.Errorf("Expected bob's session in alice's organization to be a member's, got %q", session.Role)
+	}
+
+	w = doJSON(router, "GET", "/api/members", alice.Token, nil)
+	var members []models.Member
+	if err := json.Unmarshal(w.Body.Bytes(), &members); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(members) != 2 {
+		t.Errorf("Expected alice and bob to be listed, got %+v", members)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		req   models.AddMemberRequest
+		want  int
+	}{
+		{"already a member", alice.Token, models.AddMemberRequest{Username: "bob"}, http.StatusConflict},
+		{"unknown user", alice.Token, models.AddMemberRequest{Username: "mallory"}, http.StatusNotFound},
+		{"invalid role", alice.Token, models.AddMemberRequest{Username: "carol", Role: "superuser"}, http.StatusBadRequest},
+		{"member adding", session.Token, models.AddMemberRequest{Username: "carol"}, http.StatusForbidden},
+	}
+	loginTestUser(t, router, "carol")
+	for _, tt := range tests {
+		if w := doJSON(router, "POST", "/api/members", tt.token, tt.req); w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, w.Code)
+		}
+	}
+}
+
+func TestUpdateMemberRole(t *testing.T) {
+	router, store := newMemberRouter(t)
+	alice := loginTestUser(t, router, "alice")
+	bob := loginTestUser(t, router, "bob")
+	if w := doJSON(router, "POST", "/api/members", alice.Token, models.AddMemberRequest{Username: "bob", Role: "admin"}); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to add member: %d %s", w.Code, w.Body.String())
+	}
+	users, err := store.Organizations().ListMembers(context.Background(), alice.OrgID)
+	if err != nil || len(users) != 2 {
+		t.Fatalf("Failed to list members: %v %+v", err, users)
+	}
+	aliceID, bobID := users[0].UserID, users[1].UserID
+	session := switchTestUser(t, router, bob.Token, alice.OrgID)
+
+	// As an admin bob may delete customers, but not touch the owner
+	created := 0
+	createCustomer := func() string {
+		created++
+		w := doJSON(router, "POST", "/api/customers", alice.Token, models.CreateCustomerRequest{Name: "Acme", Email: "acme" + strconv.Itoa(created) + "@example.com"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create customer: %d %s", w.Code, w.Body.String())
+		}
+		var customer models.Customer
+		if err := json.Unmarshal(w.Body.Bytes(), &customer); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return "/api/customers/" + strconv.Itoa(customer.ID)
+	}
+	if w := doJSON(router, "DELETE", createCustomer(), session.Token, nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected an admin to delete customers, got %d", w.Code)
+	}
+	aliceURL, bobURL := "/api/members/"+strconv.Itoa(aliceID), "/api/members/"+strconv.Itoa(bobID)
+	if w := doJSON(router, "PUT", aliceURL, session.Token, models.UpdateMemberRequest{Role: "member"}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected an admin demoting the owner to get %d, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := doJSON(router, "PUT", aliceURL, alice.Token, models.UpdateMemberRequest{Role: "member"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected changing your own role to get %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := doJSON(router, "PUT", "/api/members/999", alice.Token, models.UpdateMemberRequest{Role: "member"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected a non-member to get %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	// Once demoted, bob's next session can no longer delete
+	w := doJSON(router, "PUT", bobURL, alice.Token, models.UpdateMemberRequest{Role: "read_only"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	w = doJSON(router, "POST", "/api/auth/refresh", "", RefreshRequest{RefreshToken: session.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Failed to refresh: %d %s", w.Code, w.Body.String())
+	}
+	session = decodeLoginResponse(t, w.Body.Bytes())
+	if w := doJSON(router, "DELETE", createCustomer(), session.Token, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected a demoted member to get %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
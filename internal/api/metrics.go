@@ -0,0 +1,19 @@
+package api
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Login results
+const (
+	loginSuccess            = "success"
+	loginInvalidCredentials = "invalid_credentials"
+	loginForbidden          = "forbidden"
+	loginError              = "error"
+)
+
+var loginAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "auth_login_attempts_total",
+	Help: "Password logins, by result: success, invalid_credentials, forbidden (not a member of the requested organization) or error.",
+}, []string{"result"})
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/logging"
+	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHandler serves the organization endpoints
+type OrganizationHandler struct {
+	orgs   repository.OrganizationRepository
+	tokens repository.TokenRepository
+}
+
+// NewOrganizationHandler creates an organization handler backed by the given repositories
+func NewOrganizationHandler(orgs repository.OrganizationRepository, tokens repository.TokenRepository) *OrganizationHandler {
+	return &OrganizationHandler{orgs: orgs, tokens: tokens}
+}
+
+// GetOrganizations lists the organizations the caller belongs to
+// @Summary      List my organizations
+// @Description  Get the organizations the authenticated user is a member of
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   models.Organization
+// @Failure      500  {object}  map[string]string
+// @Router       /organizations [get]
+// @Security     BearerAuth
+func (h *OrganizationHandler) GetOrganizations(c *gin.Context) {
+	orgs, err := h.orgs.ListForUser(c.Request.Context(), auth.CurrentIdentity(c).UserID)
+	if err != nil {
+		internalError(c, "Failed to fetch organizations", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, orgs)
+}
+
+// CreateOrganization creates an organization owned by the caller
+// @Summary      Create organization
+// @Description  Create a new organization with the authenticated user as its first member
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Param        organization  body      models.CreateOrganizationRequest  true  "Organization data"
+// @Success      201           {object}  models.Organization
+// @Failure      400           {object}  map[string]string
+// @Router       /organizations [post]
+// @Security     BearerAuth
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var req models.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, err.Error()))
+		return
+	}
+
+	org, err := h.orgs.Create(c.Request.Context(), req.Name, auth.CurrentIdentity(c).UserID)
+	if err != nil {
+		internalError(c, "Failed to create organization", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, org)
+}
+
+// SwitchOrganization issues a token scoped to another of the caller's organizations
+// @Summary      Switch active organization
+// @Description  Start a new session (access and refresh token) whose active organization is the given one
+// @Tags         organizations
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "Organization ID"
+// @Success      200  {object}  LoginResponse
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Router       /organizations/{id}/switch [post]
+// @Security     BearerAuth
+func (h *OrganizationHandler) SwitchOrganization(c *gin.Context) {
+	orgID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, logging.ErrorBody(c, "Invalid organization ID"))
+		return
+	}
+
+	identity := auth.CurrentIdentity(c)
+	role, err := h.orgs.MemberRole(c.Request.Context(), orgID, identity.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusForbidden, logging.ErrorBody(c, "User is not a member of this organization"))
+		return
+	}
+	if err != nil {
+		internalError(c, "Failed to check membership", err)
+		return
+	}
+
+	identity.OrgID = orgID
+	identity.Role = auth.Role(role)
+	response, err := startSession(c.Request.Context(), h.tokens, identity)
+	if err != nil {
+		internalError(c, "Failed to generate token", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultPageSize is used when neither limit nor per_page is given
+	DefaultPageSize = 50
+
+	// MaxPageSize caps limit and per_page
+	MaxPageSize = 200
+)
+
+// CustomerListResponse is one page of customers
+type CustomerListResponse struct {
+	Data       []models.Customer `json:"data"`
+	Total      int               `json:"total"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	Page       int               `json:"page,omitempty"`
+	PerPage    int               `json:"per_page,omitempty"`
+}
+
+// AccountListResponse is one page of accounts
+type AccountListResponse struct {
+	Data       []models.Account `json:"data"`
+	Total      int              `json:"total"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	Page       int              `json:"page,omitempty"`
+	PerPage    int              `json:"per_page,omitempty"`
+}
+
+// parsePageRequest reads sort plus limit/cursor or page/per_page from the
+// query string. The two pagination modes cannot be mixed.
+func parsePageRequest(c *gin.Context, parseSort func(string) (repository.Sort, error)) (repository.PageRequest, error) {
+	var req repository.PageRequest
+
+	sort, err := parseSort(c.Query("sort"))
+	if err != nil {
+		return req, err
+	}
+	req.Sort = sort
+
+	limit, hasLimit := c.GetQuery("limit")
+	cursor, hasCursor := c.GetQuery("cursor")
+	page, hasPage := c.GetQuery("page")
+	perPage, hasPerPage := c.GetQuery("per_page")
+
+	if (hasLimit || hasCursor) && (hasPage || hasPerPage) {
+		return req, errors.New("use either limit/cursor or page/per_page, not both")
+	}
+
+	size := limit
+	if hasPage || hasPerPage {
+		size = perPage
+		req.Page = 1
+		if hasPage {
+			n, err := strconv.Atoi(page)
+			if err != nil || n < 1 {
+				return req, errors.New("page must be a positive integer")
+			}
+			req.Page = n
+		}
+	}
+
+	req.Limit = DefaultPageSize
+	if size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 || n > MaxPageSize {
+			return req, fmt.Errorf("page size must be between 1 and %d", MaxPageSize)
+		}
+		req.Limit = n
+	}
+
+	if hasCursor && cursor != "" {
+		decoded, err := repository.DecodeCursor(cursor)
+		if err != nil {
+			return req, err
+		}
+		if decoded.Sort != req.Sort.String() {
+			return req, errors.New("cursor was issued for a different sort order")
+		}
+		req.Cursor = &decoded
+	}
+
+	return req, nil
+}
+
+// setLinkHeader writes RFC 8288 Link headers for the pages around the current one
+func setLinkHeader(c *gin.Context, req repository.PageRequest, total int, nextCursor string) {
+	var links []string
+	link := func(rel string, params map[string]string) {
+		query := c.Request.URL.Query()
+		for key, value := range params {
+			query.Set(key, value)
+		}
+		u := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+		links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", u.String(), rel))
+	}
+
+	if req.Page > 0 {
+		perPage := strconv.Itoa(req.Limit)
+		lastPage := max(1, (total+req.Limit-1)/req.Limit)
+
+		link("first", map[string]string{"page": "1", "per_page": perPage})
+		if req.Page > 1 {
+			link("prev", map[string]string{"page": strconv.Itoa(min(req.Page-1, lastPage)), "per_page": perPage})
+		}
+		if req.Page < lastPage {
+			link("next", map[string]string{"page": strconv.Itoa(req.Page + 1), "per_page": perPage})
+		}
+		link("last", map[string]string{"page": strconv.Itoa(lastPage), "per_page": perPage})
+	} else if nextCursor != "" {
+		link("next", map[string]string{"cursor": nextCursor, "limit": strconv.Itoa(req.Limit)})
+	}
+
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+}
+
+// parseTimeQuery reads an optional RFC 3339 timestamp or YYYY-MM-DD date
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s must be an RFC 3339 timestamp or YYYY-MM-DD date", name)
+}
+
+// isBadListRequest reports whether a repository error was caused by client input
+func isBadListRequest(err error) bool {
+	return errors.Is(err, repository.ErrInvalidCursor) || errors.Is(err, repository.ErrInvalidSort)
+}
+
+// pageNumbers returns the page and per_page values echoed in list responses
+func pageNumbers(req repository.PageRequest) (int, int) {
+	if req.Page == 0 {
+		return 0, 0
+	}
+	return req.Page, req.Limit
+}
@@ -0,0 +1,38 @@
+// Package apierror responds to requests whose data access failed, with a
+// status that tells clients whether and when to retry
+package apierror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"saas-go-app/internal/logging"
+	"saas-go-app/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest is nginx's status for requests the client
+// abandoned; it only reaches the logs and metrics
+const StatusClientClosedRequest = 499
+
+// Respond responds with a 500 carrying message, and records err for the
+// request log, which clients never see. Requests whose database work was
+// cut short get 499 when the client went away, 504 when the route's
+// deadline or the statement timeout passed and 503 while the database is
+// unreachable.
+func Respond(c *gin.Context, message string, err error) {
+	c.Error(err)
+	ctxErr := c.Request.Context().Err()
+	switch {
+	case errors.Is(ctxErr, context.Canceled):
+		c.JSON(StatusClientClosedRequest, logging.ErrorBody(c, "Client closed request"))
+	case errors.Is(ctxErr, context.DeadlineExceeded), errors.Is(err, context.DeadlineExceeded), errors.Is(err, repository.ErrQueryCanceled):
+		c.JSON(http.StatusGatewayTimeout, logging.ErrorBody(c, "Request timed out"))
+	case errors.Is(err, repository.ErrUnavailable):
+		c.JSON(http.StatusServiceUnavailable, logging.ErrorBody(c, "Service temporarily unavailable"))
+	default:
+		c.JSON(http.StatusInternalServerError, logging.ErrorBody(c, message))
+	}
+}
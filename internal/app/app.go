@@ -0,0 +1,145 @@
+// Package app wires the application's services together for the commands
+// in main: database pools, repositories, token signing, background jobs and
+// the HTTP router
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/config"
+	"saas-go-app/internal/db"
+	"saas-go-app/internal/jobs"
+	"saas-go-app/internal/metrics"
+	"saas-go-app/internal/repository"
+)
+
+// Connect opens the primary database pool and a pool to each configured
+// follower, and exports their stats. Analytics fall back to the primary
+// while no follower is fresh enough.
+func Connect(ctx context.Context, cfg *config.Config) error {
+	if err := db.InitPrimaryDB(ctx, cfg.Database.URL, PoolOptions(cfg.Database.Pool)); err != nil {
+		return fmt.Errorf("failed to initialize primary database: %w", err)
+	}
+	routing := db.RoutingOptions{
+		MaxStaleness:    cfg.Database.MaxStaleness,
+		ConsistencyWait: cfg.Database.ConsistencyWait,
+	}
+	if err := db.InitFollowers(ctx, cfg.Database.FollowerURLs(), PoolOptions(cfg.Database.FollowerPool), routing); err != nil {
+		slog.Warn("Failed to initialize follower databases", "error", err)
+	}
+
+	metrics.RegisterDBPool(db.PrimaryPool, db.PrimaryDB)
+	for _, follower := range db.Followers {
+		metrics.RegisterDBPool(follower.Name, follower.DB)
+	}
+	return nil
+}
+
+// NewStore returns the repositories over the open database pools
+func NewStore() repository.Store {
+	return repository.NewPostgresStore(db.PrimaryDB, db.Analytics)
+}
+
+// InitAuth configures token signing with keys shared between instances
+// through store, and the token denylist and API key lookups
+func InitAuth(ctx context.Context, cfg *config.Config, store repository.Store) error {
+	err := auth.InitJWT(auth.JWTConfig{
+		SigningAlg:          cfg.Auth.SigningAlg,
+		KeyRotationInterval: cfg.Auth.KeyRotationInterval,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize JWT: %w", err)
+	}
+
+	auth.SetDenylist(store.Tokens())
+	auth.SetAPIKeyStore(store.APIKeys())
+
+	if err := auth.UseKeyStore(ctx, store.SigningKeys()); err != nil {
+		return fmt.Errorf("failed to load JWT signing keys: %w", err)
+	}
+	return nil
+}
+
+// PoolOptions returns the settings of a database pool
+func PoolOptions(pool config.PoolConfig) db.PoolOptions {
+	return db.PoolOptions{
+		MaxOpenConns:     pool.MaxOpenConns,
+		MaxIdleConns:     pool.MaxIdleConns,
+		ConnMaxLifetime:  pool.ConnMaxLifetime,
+		ConnMaxIdleTime:  pool.ConnMaxIdleTime,
+		StatementTimeout: pool.StatementTimeout,
+		ConnectTimeout:   pool.ConnectTimeout,
+		BreakerThreshold: pool.BreakerThreshold,
+		BreakerCooldown:  pool.BreakerCooldown,
+	}
+}
+
+// RouteTimeouts are the deadlines of API requests by route
+type RouteTimeouts struct {
+	// API bounds every API request but analytics
+	API time.Duration
+	// Analytics bounds /api/analytics requests
+	Analytics time.Duration
+}
+
+// Timeouts returns the configured request deadlines
+func Timeouts(cfg *config.Config) RouteTimeouts {
+	return RouteTimeouts{API: cfg.Server.RequestTimeout, Analytics: cfg.Server.AnalyticsTimeout}
+}
+
+// SeedOptions returns the configured sample data settings
+func SeedOptions(cfg *config.Config) db.SeedOptions {
+	return db.SeedOptions{
+		Performance:         cfg.Seed.Performance,
+		Customers:           cfg.Seed.Customers,
+		AccountsPerCustomer: cfg.Seed.AccountsPerCustomer,
+	}
+}
+
+// StartJobs opens the job queue and runs the periodic job scheduler until
+// ctx is done. With process set, this instance also runs enqueued tasks.
+// Every instance may run the scheduler; task IDs make sure each run is
+// enqueued once.
+func StartJobs(ctx context.Context, cfg *config.Config, store repository.Store, process bool) (jobs.Queue, *jobs.Scheduler, error) {
+	queue, err := jobs.NewQueue(cfg.Jobs.RedisURL, jobs.Options{
+		Concurrency: cfg.Jobs.Concurrency,
+		Priorities:  cfg.Jobs.Queues,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open job queue: %w", err)
+	}
+	if process {
+		if err := queue.Start(jobs.NewServeMux(store.DailyMetrics())); err != nil {
+			queue.Close()
+			return nil, nil, fmt.Errorf("failed to start background job processor: %w", err)
+		}
+	}
+
+	scheduler := jobs.NewScheduler(queue)
+	for _, job := range jobs.DefaultPeriodicJobs() {
+		if spec, ok := cfg.Jobs.Schedules[job.Name]; ok {
+			job.Spec = spec
+		}
+		if err := scheduler.Register(job); err != nil {
+			queue.Close()
+			return nil, nil, fmt.Errorf("failed to schedule periodic jobs: %w", err)
+		}
+	}
+	go scheduler.Run(ctx)
+
+	return queue, scheduler, nil
+}
+
+// StopJobs waits for running tasks until ctx is done, then closes queue
+func StopJobs(ctx context.Context, queue jobs.Queue) {
+	if err := queue.Shutdown(ctx); err != nil {
+		slog.Warn("Background jobs did not finish in time and will be retried")
+	}
+	if err := queue.Close(); err != nil {
+		slog.Warn("Failed to close job queue", "error", err)
+	}
+}
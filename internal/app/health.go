@@ -0,0 +1,55 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+
+	"saas-go-app/internal/config"
+	"saas-go-app/internal/db"
+	"saas-go-app/internal/db/migrations"
+	"saas-go-app/internal/health"
+	"saas-go-app/internal/jobs"
+)
+
+// NewHealthChecks returns the checks of the web process's dependencies.
+// Only the primary database is critical; without a follower, Redis or a
+// migration the API still serves most requests, so they degrade it.
+func NewHealthChecks(cfg *config.Config, inspector jobs.Inspector) *health.Registry {
+	checks := health.NewRegistry(cfg.Health.CheckTimeout)
+	checks.Register(db.PrimaryPool, true, health.Ping(db.PrimaryDB))
+	for _, follower := range db.Followers {
+		checks.Register(follower.Name, false, health.Ping(follower.DB))
+		checks.Register(follower.Name+"_replication_lag", false, health.ReplicationLag(follower.DB, cfg.Health.MaxReplicationLag))
+	}
+	// The in-process queue has nothing to check
+	if cfg.Jobs.RedisURL != "" {
+		checks.Register("queue", false, health.Queue(inspector))
+	}
+	registerMigrations(checks)
+	return checks
+}
+
+// NewWorkerHealthChecks returns the checks of a worker's dependencies: the
+// primary database its jobs write to and the queue it takes them from are
+// both critical
+func NewWorkerHealthChecks(cfg *config.Config, inspector jobs.Inspector) *health.Registry {
+	checks := health.NewRegistry(cfg.Health.CheckTimeout)
+	checks.Register(db.PrimaryPool, true, health.Ping(db.PrimaryDB))
+	checks.Register("queue", true, health.Queue(inspector))
+	registerMigrations(checks)
+	return checks
+}
+
+// registerMigrations checks that the primary has every migration this
+// build knows, which it lacks after a failed release phase
+func registerMigrations(checks *health.Registry) {
+	migrator, err := db.NewMigrator(db.PrimaryDB, migrations.FS)
+	if err != nil {
+		slog.Error("Failed to load migrations", "error", err)
+		checks.Register("migrations", false, func(context.Context) error {
+			return health.Errorf("failed to load migrations")
+		})
+		return
+	}
+	checks.Register("migrations", false, health.Migrations(migrator))
+}
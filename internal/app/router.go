@@ -0,0 +1,231 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"saas-go-app/internal/api"
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/consistency"
+	"saas-go-app/internal/health"
+	"saas-go-app/internal/jobs"
+	"saas-go-app/internal/logging"
+	"saas-go-app/internal/metrics"
+	"saas-go-app/internal/repository"
+	"saas-go-app/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+
+	_ "saas-go-app/docs" // Swagger docs
+)
+
+// NewRouter returns the HTTP handler for the API, the frontend and the
+// operational endpoints. API requests are bounded by timeouts.
+func NewRouter(store repository.Store, scheduler *jobs.Scheduler, inspector jobs.Inspector, checks *health.Registry, timeouts RouteTimeouts) *gin.Engine {
+	authHandler := api.NewAuthHandler(store.Users(), store.Organizations(), store.Tokens())
+	organizationHandler := api.NewOrganizationHandler(store.Organizations(), store.Tokens())
+	memberHandler := api.NewMemberHandler(store.Organizations(), store.Users())
+	apiKeyHandler := api.NewAPIKeyHandler(store.APIKeys())
+	customerHandler := api.NewCustomerHandler(store.Customers())
+	accountHandler := api.NewAccountHandler(store.Accounts())
+	analyticsHandler := api.NewAnalyticsHandler(store.Analytics())
+	adminHandler := api.NewAdminHandler(scheduler, inspector)
+	healthHandler := api.NewHealthHandler(checks)
+
+	// Set up Gin router with traces, request IDs, structured access logs,
+	// request metrics and consistency tokens. Tracing comes first so log
+	// lines carry the request's span.
+	router := gin.New()
+	router.Use(tracing.Middleware(), logging.Middleware(), metrics.Middleware(), logging.Recovery(), consistency.Middleware())
+
+	// Serve static files from frontend build (if it exists)
+	// In production, the frontend should be built and placed in web/frontend/dist
+	if _, err := os.Stat("web/frontend/dist"); err == nil {
+		// Serve static files
+		router.Static("/assets", "web/frontend/dist/assets")
+		router.StaticFile("/favicon.ico", "web/frontend/dist/favicon.ico")
+
+		// Serve index.html for root route
+		router.GET("/", func(c *gin.Context) {
+			c.File("web/frontend/dist/index.html")
+		})
+
+		// Serve index.html for all other non-API routes (SPA routing)
+		router.NoRoute(func(c *gin.Context) {
+			path := c.Request.URL.Path
+			// Don't serve frontend for API routes, health, or metrics
+			if len(path) >= 4 && path[:4] == "/api" {
+				c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Not found"))
+			} else if strings.HasPrefix(path, "/health") || path == "/metrics" {
+				c.JSON(http.StatusNotFound, logging.ErrorBody(c, "Not found"))
+			} else {
+				// Serve the SPA index.html for all other routes
+				c.File("web/frontend/dist/index.html")
+			}
+		})
+	} else {
+		// If frontend is not built, show API info at root
+		router.GET("/", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{
+				"message": "SaaS Go App API",
+				"version": "1.0.0",
+				"note":    "Frontend not built. Run 'cd web/frontend && npm install && npm run build' to build the frontend.",
+				"endpoints": gin.H{
+					"health":  "/health/live, /health/ready",
+					"jwks":    "/.well-known/jwks.json",
+					"metrics": "/metrics",
+					"auth": gin.H{
+						"login":    "POST /api/auth/login",
+						"register": "POST /api/auth/register",
+						"refresh":  "POST /api/auth/refresh",
+						"logout":   "POST /api/auth/logout",
+					},
+					"organizations": "GET, POST /api/organizations",
+					"api_keys":      "GET, POST, DELETE /api/api-keys",
+					"customers":     "GET, POST, PUT, DELETE /api/customers",
+					"accounts":      "GET, POST, PUT, DELETE /api/accounts",
+					"analytics":     "GET /api/analytics",
+				},
+			})
+		})
+	}
+
+	// Prometheus metrics endpoint
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Health check endpoints; /health predates the split
+	registerHealth(router, healthHandler)
+
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", api.JWKS)
+
+	// Swagger documentation
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Public routes
+	apiRoutes := router.Group("/api")
+	apiRoutes.Use(withDeadline(timeouts))
+	{
+		apiRoutes.POST("/auth/login", authHandler.Login)
+		apiRoutes.POST("/auth/register", authHandler.Register)
+		apiRoutes.POST("/auth/refresh", authHandler.Refresh)
+	}
+
+	// Protected routes
+	protectedRoutes := apiRoutes.Group("")
+	protectedRoutes.Use(auth.AuthMiddleware())
+	{
+		protectedRoutes.POST("/auth/logout", auth.RequireUser(), authHandler.Logout)
+
+		// Organization routes
+		organizations := protectedRoutes.Group("/organizations")
+		organizations.Use(auth.RequireUser())
+		{
+			organizations.GET("", organizationHandler.GetOrganizations)
+			organizations.POST("", organizationHandler.CreateOrganization)
+			organizations.POST("/:id/switch", organizationHandler.SwitchOrganization)
+		}
+
+		// Member routes for the active organization; keys cannot manage members
+		members := protectedRoutes.Group("/members")
+		members.Use(auth.RequireUser(), auth.RequirePermission(auth.PermMembersManage))
+		{
+			members.GET("", memberHandler.GetMembers)
+			members.POST("", memberHandler.AddMember)
+			members.PUT("/:user_id", memberHandler.UpdateMember)
+		}
+
+		// API key routes; keys cannot mint or revoke keys
+		apiKeys := protectedRoutes.Group("/api-keys")
+		apiKeys.Use(auth.RequireUser(), auth.RequirePermission(auth.PermAPIKeysManage))
+		{
+			apiKeys.GET("", apiKeyHandler.GetAPIKeys)
+			apiKeys.GET("/:id", apiKeyHandler.GetAPIKey)
+			apiKeys.POST("", apiKeyHandler.CreateAPIKey)
+			apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+		}
+
+		// Customer routes
+		customers := protectedRoutes.Group("/customers")
+		{
+			customers.GET("", auth.RequirePermission(auth.PermCustomersRead), customerHandler.GetCustomers)
+			customers.GET("/:id", auth.RequirePermission(auth.PermCustomersRead), customerHandler.GetCustomer)
+			customers.POST("", auth.RequirePermission(auth.PermCustomersWrite), customerHandler.CreateCustomer)
+			customers.PUT("/:id", auth.RequirePermission(auth.PermCustomersWrite), customerHandler.UpdateCustomer)
+			customers.DELETE("/:id", auth.RequirePermission(auth.PermCustomersDelete), customerHandler.DeleteCustomer)
+		}
+
+		// Account routes
+		accounts := protectedRoutes.Group("/accounts")
+		{
+			accounts.GET("", auth.RequirePermission(auth.PermAccountsRead), accountHandler.GetAccounts)
+			accounts.GET("/:id", auth.RequirePermission(auth.PermAccountsRead), accountHandler.GetAccount)
+			accounts.POST("", auth.RequirePermission(auth.PermAccountsWrite), accountHandler.CreateAccount)
+			accounts.PUT("/:id", auth.RequirePermission(auth.PermAccountsWrite), accountHandler.UpdateAccount)
+			accounts.DELETE("/:id", auth.RequirePermission(auth.PermAccountsDelete), accountHandler.DeleteAccount)
+		}
+
+		// Analytics routes
+		analytics := protectedRoutes.Group("/analytics")
+		{
+			analytics.GET("", auth.RequirePermission(auth.PermAnalyticsRead), analyticsHandler.GetAnalytics)
+			analytics.GET("/customers/:customer_id", auth.RequirePermission(auth.PermAnalyticsRead), analyticsHandler.GetCustomerAnalytics)
+			analytics.GET("/timeseries", auth.RequirePermission(auth.PermAnalyticsRead), analyticsHandler.GetTimeseries)
+		}
+
+		// System administration routes
+		admin := protectedRoutes.Group("/admin")
+		admin.Use(auth.RequireAdmin())
+		{
+			admin.GET("/schedules", adminHandler.GetSchedules)
+			admin.GET("/queues", adminHandler.GetQueues)
+			admin.GET("/queues/:queue/tasks", adminHandler.GetQueueTasks)
+			admin.GET("/queues/:queue/tasks/:task_id", adminHandler.GetQueueTask)
+			admin.POST("/queues/:queue/tasks/:task_id/run", adminHandler.RunQueueTask)
+			admin.DELETE("/queues/:queue/tasks/:task_id", adminHandler.DeleteQueueTask)
+			admin.POST("/queues/:queue/archived/run", adminHandler.RunArchivedTasks)
+			admin.DELETE("/queues/:queue/archived", adminHandler.DeleteArchivedTasks)
+			admin.POST("/queues/:queue/pause", adminHandler.PauseQueue)
+			admin.POST("/queues/:queue/resume", adminHandler.ResumeQueue)
+		}
+	}
+
+	return router
+}
+
+// NewWorkerRouter returns the HTTP handler for a worker's health checks
+// and metrics
+func NewWorkerRouter(checks *health.Registry) *gin.Engine {
+	router := gin.New()
+	router.Use(logging.Recovery())
+	registerHealth(router, api.NewHealthHandler(checks))
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	return router
+}
+
+// withDeadline bounds the context of each request, and so its queries,
+// by the timeout of its route
+func withDeadline(timeouts RouteTimeouts) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := timeouts.API
+		if strings.HasPrefix(c.FullPath(), "/api/analytics") {
+			timeout = timeouts.Analytics
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// registerHealth routes the liveness and readiness checks
+func registerHealth(router *gin.Engine, handler *api.HealthHandler) {
+	router.GET("/health/live", handler.Live)
+	router.GET("/health/ready", handler.Ready)
+	router.GET("/health", handler.Ready)
+}
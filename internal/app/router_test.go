@@ -0,0 +1,107 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/health"
+	"saas-go-app/internal/jobs"
+	"saas-go-app/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := auth.InitJWT(auth.JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+
+	store := repository.NewMemoryStore()
+	auth.SetDenylist(store.Tokens())
+	auth.SetAPIKeyStore(store.APIKeys())
+	if err := auth.UseKeyStore(context.Background(), store.SigningKeys()); err != nil {
+		t.Fatalf("Failed to use key store: %v", err)
+	}
+	queue := jobs.NewMemoryQueue(jobs.Options{Concurrency: 1})
+	router := NewRouter(store, jobs.NewScheduler(queue), queue.Inspector(), health.NewRegistry(0), RouteTimeouts{API: 10 * time.Second, Analytics: 30 * time.Second})
+
+	do := func(method, path, token string, body any) *httptest.ResponseRecorder {
+		var payload bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&payload).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &payload)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	credentials := map[string]string{"username": "router", "password": "secret123"}
+	if w := do(http.MethodPost, "/api/auth/register", "", credentials); w.Code != http.StatusCreated {
+		t.Fatalf("Failed to register: %d %s", w.Code, w.Body.String())
+	}
+	w := do(http.MethodPost, "/api/auth/login", "", credentials)
+	var login struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &login); err != nil || login.Token == "" {
+		t.Fatalf("Failed to log in: %d %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		method, path, token string
+		want                int
+	}{
+		{http.MethodGet, "/.well-known/jwks.json", "", http.StatusOK},
+		{http.MethodGet, "/health/live", "", http.StatusOK},
+		{http.MethodGet, "/health/ready", "", http.StatusOK},
+		{http.MethodGet, "/api/customers", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/customers", login.Token, http.StatusOK},
+		{http.MethodGet, "/api/organizations", login.Token, http.StatusOK},
+		{http.MethodGet, "/api/admin/queues", login.Token, http.StatusForbidden},
+		{http.MethodGet, "/api/missing", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := do(tt.method, tt.path, tt.token, nil); w.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.want, w.Code)
+		}
+	}
+}
+
+func TestWithDeadlineByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	apiRoutes := router.Group("/api")
+	apiRoutes.Use(withDeadline(RouteTimeouts{API: time.Second, Analytics: time.Minute}))
+	remaining := map[string]time.Duration{}
+	record := func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		if !ok {
+			t.Errorf("%s: expected a deadline", c.FullPath())
+		}
+		remaining[c.FullPath()] = time.Until(deadline)
+	}
+	apiRoutes.GET("/customers", record)
+	apiRoutes.GET("/analytics/timeseries", record)
+
+	for _, path := range []string{"/api/customers", "/api/analytics/timeseries"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if d := remaining["/api/customers"]; d <= 0 || d > time.Second {
+		t.Errorf("Expected API requests to get the API timeout, got %v", d)
+	}
+	if d := remaining["/api/analytics/timeseries"]; d <= time.Second || d > time.Minute {
+		t.Errorf("Expected analytics requests to get the analytics timeout, got %v", d)
+	}
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"saas-go-app/internal/models"
+	"saas-go-app/internal/repository"
+)
+
+// API keys look like sk_<prefix>_<secret>. The prefix is 8 hex characters
+// stored in clear to find the key; the whole key is only stored hashed.
+const (
+	apiKeyMarker    = "sk_"
+	apiKeyPrefixLen = 8
+)
+
+// errInvalidAPIKey covers malformed, unknown, revoked and expired keys alike
+var errInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyStore looks up API keys for AuthMiddleware
+type APIKeyStore interface {
+	GetByPrefix(ctx context.Context, prefix string) (models.APIKey, error)
+	Touch(ctx context.Context, id int) error
+}
+
+var apiKeys APIKeyStore
+
+// SetAPIKeyStore makes AuthMiddleware accept "ApiKey <key>" credentials
+// from store
+func SetAPIKeyStore(store APIKeyStore) {
+	apiKeys = store
+}
+
+// NewAPIKey returns a new API key, its visible prefix and the hash to store
+func NewAPIKey() (key, prefix, hash string, err error) {
+	prefixBytes := make([]byte, apiKeyPrefixLen/2)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	key = apiKeyMarker + prefix + "_" + secret
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex SHA-256 of an API key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyPrefix extracts the lookup prefix from a key
+func apiKeyPrefix(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, apiKeyMarker)
+	if !ok || len(rest) < apiKeyPrefixLen+2 || rest[apiKeyPrefixLen] != '_' {
+		return "", false
+	}
+	return rest[:apiKeyPrefixLen], true
+}
+
+// authenticateAPIKey returns the stored key matching raw if it is usable,
+// errInvalidAPIKey if not, or the store's error
+func authenticateAPIKey(ctx context.Context, raw string) (models.APIKey, error) {
+	prefix, ok := apiKeyPrefix(raw)
+	if !ok || apiKeys == nil {
+		return models.APIKey{}, errInvalidAPIKey
+	}
+
+	key, err := apiKeys.GetByPrefix(ctx, prefix)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.APIKey{}, errInvalidAPIKey
+	}
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(raw)), []byte(key.KeyHash)) != 1 {
+		return models.APIKey{}, errInvalidAPIKey
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+		return models.APIKey{}, errInvalidAPIKey
+	}
+
+	if err := apiKeys.Touch(ctx, key.ID); err != nil {
+		slog.WarnContext(ctx, "Failed to record use of API key", "prefix", key.Prefix, "error", err)
+	}
+	return key, nil
+}
@@ -1,54 +1,114 @@
 package auth
 
 import (
-	"crypto/rand"
-	"encoding/base64"
+	"context"
 	"errors"
-	"log"
-	"os"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtSecret []byte
+// Token lifetimes. Access tokens are short-lived; sessions are extended
+// with refresh tokens, each of which can be used once.
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
 
 // Claims represents JWT claims
 type Claims struct {
 	Username string `json:"username"`
+	UserID   int    `json:"user_id"`
+	OrgID    int    `json:"org_id"`
+	Role     Role   `json:"role"`
+	Admin    bool   `json:"admin,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// InitJWT initializes JWT secret from environment or generates one
-func InitJWT() error {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		// Generate a random secret for development
-		secretBytes := make([]byte, 32)
-		if _, err := rand.Read(secretBytes); err != nil {
-			return err
+// Identity is the user, active organization and role a token is issued
+// for. Requests authenticated with an API key have APIKeyID and Scopes set
+// instead of a user and role.
+type Identity struct {
+	UserID   int
+	Username string
+	OrgID    int
+	Role     Role
+	Admin    bool
+	APIKeyID int
+	Scopes   []Permission
+}
+
+// JWTConfig selects how tokens are signed. Zero values take the defaults.
+type JWTConfig struct {
+	// SigningAlg is EdDSA or RS256
+	SigningAlg string
+	// KeyRotationInterval is how long each signing key is used
+	KeyRotationInterval time.Duration
+}
+
+// InitJWT configures token signing, starting with a key held in memory.
+// Call UseKeyStore to share keys between instances.
+func InitJWT(cfg JWTConfig) error {
+	alg := cfg.SigningAlg
+	if alg == "" {
+		alg = AlgEdDSA
+	}
+
+	rotation := DefaultKeyRotationInterval
+	if cfg.KeyRotationInterval != 0 {
+		// Retired keys must outlive the tokens they signed
+		if cfg.KeyRotationInterval < 2*AccessTokenTTL {
+			return fmt.Errorf("key rotation interval must be at least %s", 2*AccessTokenTTL)
 		}
-		secret = base64.URLEncoding.EncodeToString(secretBytes)
-		log.Println("WARNING: JWT_SECRET not set, using generated secret (not secure for production)")
+		rotation = cfg.KeyRotationInterval
 	}
-	jwtSecret = []byte(secret)
+
+	key, err := generateSigningKey(alg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	keys.mu.Lock()
+	defer keys.mu.Unlock()
+	keys.alg = alg
+	keys.rotation = rotation
+	keys.keys = []signingKey{key}
+	keys.store = nil
 	return nil
 }
 
-// GenerateToken generates a JWT token for a user
-func GenerateToken(username string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// GenerateToken generates a short-lived access token for a user acting in
+// an organization
+func GenerateToken(identity Identity) (string, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	expirationTime := time.Now().Add(AccessTokenTTL)
 	claims := &Claims{
-		Username: username,
+		Username: identity.Username,
+		UserID:   identity.UserID,
+		OrgID:    identity.OrgID,
+		Role:     identity.Role,
+		Admin:    identity.Admin,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtSecret)
+	key, err := keys.signingKey(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	tokenString, err := token.SignedString(key.private)
 	if err != nil {
 		return "", err
 	}
@@ -56,19 +116,35 @@ func GenerateToken(username string) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
-func ValidateToken(tokenString string) (*Claims, error) {
+// ValidateToken validates a JWT token and returns the claims. Tokens on the
+// denylist set with SetDenylist are rejected with ErrTokenRevoked, and
+// other bad tokens with ErrInvalidToken. Failures to reach the key store
+// or the denylist are returned as they are.
+func ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+		return keys.verificationKey(ctx, token)
+	}, jwt.WithValidMethods([]string{AlgEdDSA, AlgRS256}))
 
-	if err != nil {
+	if errors.Is(err, errKeyStore) {
 		return nil, err
 	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
 
 	if !token.Valid {
-		return nil, errors.New("invalid token")
+		return nil, ErrInvalidToken
+	}
+
+	if denylist != nil && claims.ID != "" {
+		revoked, err := denylist.IsAccessTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
 	}
 
 	return claims, nil
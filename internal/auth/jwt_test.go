@@ -1,26 +1,28 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
 
 func TestInitJWT(t *testing.T) {
-	err := InitJWT()
+	err := InitJWT(JWTConfig{})
 	if err != nil {
 		t.Fatalf("Failed to initialize JWT: %v", err)
 	}
 
-	if len(jwtSecret) == 0 {
-		t.Fatal("JWT secret is empty")
+	if _, err := keys.signingKey(time.Now()); err != nil {
+		t.Fatalf("No signing key after InitJWT: %v", err)
 	}
 }
 
 func TestGenerateAndValidateToken(t *testing.T) {
-	_ = InitJWT()
+	_ = InitJWT(JWTConfig{})
 
 	username := "testuser"
-	token, err := GenerateToken(username)
+	token, err := GenerateToken(Identity{UserID: 1, Username: username, OrgID: 7})
 	if err != nil {
 		t.Fatalf("Failed to generate token: %v", err)
 	}
@@ -29,7 +31,7 @@ func TestGenerateAndValidateToken(t *testing.T) {
 		t.Fatal("Generated token is empty")
 	}
 
-	claims, err := ValidateToken(token)
+	claims, err := ValidateToken(context.Background(), token)
 	if err != nil {
 		t.Fatalf("Failed to validate token: %v", err)
 	}
@@ -37,6 +39,10 @@ func TestGenerateAndValidateToken(t *testing.T) {
 	if claims.Username != username {
 		t.Errorf("Expected username %s, got %s", username, claims.Username)
 	}
+
+	if claims.UserID != 1 || claims.OrgID != 7 {
+		t.Errorf("Expected user 1 in org 7, got user %d in org %d", claims.UserID, claims.OrgID)
+	}
 }
 
 func TestHashPassword(t *testing.T) {
@@ -72,17 +78,16 @@ func TestCheckPasswordHash(t *testing.T) {
 }
 
 func TestTokenExpiration(t *testing.T) {
-	_ = InitJWT()
+	_ = InitJWT(JWTConfig{})
 
 	// This test would require mocking time or using a very short expiration
 	// For now, we'll just verify the token structure
-	username := "testuser"
-	token, err := GenerateToken(username)
+	token, err := GenerateToken(Identity{UserID: 1, Username: "testuser", OrgID: 1})
 	if err != nil {
 		t.Fatalf("Failed to generate token: %v", err)
 	}
 
-	claims, err := ValidateToken(token)
+	claims, err := ValidateToken(context.Background(), token)
 	if err != nil {
 		t.Fatalf("Failed to validate token: %v", err)
 	}
@@ -97,3 +102,51 @@ func TestTokenExpiration(t *testing.T) {
 	}
 }
 
+// denylistFunc adapts a function to the Denylist interface
+type denylistFunc func(jti string) (bool, error)
+
+func (f denylistFunc) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return f(jti)
+}
+
+func TestValidateTokenDenylist(t *testing.T) {
+	_ = InitJWT(JWTConfig{})
+
+	revoked, err := GenerateToken(Identity{UserID: 1, Username: "testuser", OrgID: 1})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	live, err := GenerateToken(Identity{UserID: 1, Username: "testuser", OrgID: 1})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	revokedClaims, err := ValidateToken(context.Background(), revoked)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if revokedClaims.ID == "" {
+		t.Fatal("Token has no jti")
+	}
+
+	SetDenylist(denylistFunc(func(jti string) (bool, error) {
+		return jti == revokedClaims.ID, nil
+	}))
+	defer SetDenylist(nil)
+
+	if _, err := ValidateToken(context.Background(), revoked); !errors.Is(err, ErrTokenRevoked) || !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrTokenRevoked for denylisted token, got %v", err)
+	}
+	if _, err := ValidateToken(context.Background(), live); err != nil {
+		t.Errorf("Expected other tokens to stay valid, got %v", err)
+	}
+
+	// A denylist that cannot be consulted fails closed, without calling
+	// the token invalid
+	SetDenylist(denylistFunc(func(jti string) (bool, error) {
+		return false, errors.New("database unavailable")
+	}))
+	if _, err := ValidateToken(context.Background(), live); err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected validation to fail without ErrInvalidToken when the denylist errors, got %v", err)
+	}
+}
@@ -0,0 +1,357 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	"saas-go-app/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+// DefaultKeyRotationInterval is how long a signing key is used before the
+// next one takes over
+const DefaultKeyRotationInterval = 30 * 24 * time.Hour
+
+const (
+	rsaKeyBits = 2048
+	// keyReloadThrottle bounds how often an unknown kid reloads the key store
+	keyReloadThrottle = 10 * time.Second
+)
+
+// KeyStore persists signing keys so that every instance signs and verifies
+// with the same set
+type KeyStore interface {
+	// ListSigningKeys returns all keys, newest first
+	ListSigningKeys(ctx context.Context) ([]models.SigningKey, error)
+	CreateSigningKey(ctx context.Context, key models.SigningKey) error
+	DeleteSigningKeysBefore(ctx context.Context, before time.Time) error
+}
+
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	createdAt time.Time
+}
+
+// keyRing holds the keys this process signs and verifies with. New keys are
+// published (JWKS, other instances) for a lead time before they sign, and
+// retired keys verify for one more rotation interval.
+type keyRing struct {
+	mu         sync.RWMutex
+	keys       []signingKey // newest first
+	alg        string
+	rotation   time.Duration
+	store      KeyStore
+	lastReload time.Time
+}
+
+var keys = &keyRing{}
+
+// lead is how long a new key is published before it starts signing
+func (k *keyRing) lead() time.Duration {
+	lead := k.rotation / 4
+	if lead > time.Hour {
+		lead = time.Hour
+	}
+	return lead
+}
+
+// signingKey returns the newest key that has been published for the lead
+// time, or the oldest key if none has been yet
+func (k *keyRing) signingKey(now time.Time) (signingKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if len(k.keys) == 0 {
+		return signingKey{}, errors.New("no signing key, call InitJWT")
+	}
+	for _, key := range k.keys {
+		if !key.createdAt.After(now.Add(-k.lead())) {
+			return key, nil
+		}
+	}
+	return k.keys[len(k.keys)-1], nil
+}
+
+func (k *keyRing) lookup(kid string) (signingKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.kid == kid {
+			return key, true
+		}
+	}
+	return signingKey{}, false
+}
+
+// errKeyStore wraps failures to reload the ring, after which a token
+// signed by an unknown key can be neither accepted nor rejected
+var errKeyStore = errors.New("reload signing keys")
+
+// verificationKey is the jwt.Keyfunc for tokens signed by the ring. An
+// unknown kid may come from a key another instance just created, so it
+// triggers a (throttled) reload from the store.
+func (k *keyRing) verificationKey(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.lookup(kid)
+	if !ok && k.claimReload(time.Now()) {
+		if err := k.reload(ctx); err != nil {
+			return nil, fmt.Errorf("%w: %w", errKeyStore, err)
+		}
+		key, ok = k.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("signing key %q does not use %s", kid, token.Method.Alg())
+	}
+	return key.private.Public(), nil
+}
+
+// claimReload reports whether a store reload may run now, and records it
+func (k *keyRing) claimReload(now time.Time) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.store == nil || now.Sub(k.lastReload) < keyReloadThrottle {
+		return false
+	}
+	k.lastReload = now
+	return true
+}
+
+// reload replaces the ring with the keys in the store
+func (k *keyRing) reload(ctx context.Context) error {
+	k.mu.RLock()
+	store := k.store
+	k.mu.RUnlock()
+
+	stored, err := store.ListSigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+	ring, err := decodeSigningKeys(stored)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(ring) > 0 {
+		k.keys = ring
+	}
+	return nil
+}
+
+// rotate loads the stored keys, creates the next key when the newest is due
+// to be replaced, and deletes keys past retention
+func (k *keyRing) rotate(ctx context.Context, now time.Time) error {
+	k.mu.RLock()
+	store, alg, rotation, lead := k.store, k.alg, k.rotation, k.lead()
+	k.mu.RUnlock()
+
+	stored, err := store.ListSigningKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list signing keys: %w", err)
+	}
+	ring, err := decodeSigningKeys(stored)
+	if err != nil {
+		return err
+	}
+
+	// Create the next key a lead time before the current one is due, so it
+	// is published everywhere before it signs anything
+	if len(ring) == 0 || !ring[0].createdAt.After(now.Add(lead-rotation)) {
+		key, err := generateSigningKey(alg, now)
+		if err != nil {
+			return fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		encoded, err := encodeSigningKey(key)
+		if err != nil {
+			return err
+		}
+		if err := store.CreateSigningKey(ctx, encoded); err != nil {
+			return fmt.Errorf("failed to store signing key: %w", err)
+		}
+		slog.InfoContext(ctx, "Created signing key", "alg", alg, "kid", key.kid)
+		ring = append([]signingKey{key}, ring...)
+	}
+
+	cutoff := now.Add(-2 * rotation)
+	if err := store.DeleteSigningKeysBefore(ctx, cutoff); err != nil {
+		return fmt.Errorf("failed to delete expired signing keys: %w", err)
+	}
+	kept := ring[:1]
+	for _, key := range ring[1:] {
+		if !key.createdAt.Before(cutoff) {
+			kept = append(kept, key)
+		}
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = kept
+	return nil
+}
+
+// UseKeyStore switches token signing to keys shared through store, creating
+// the first key if there is none, and rotates them in the background until
+// ctx is done. Keys are kept for verification for two rotation intervals.
+func UseKeyStore(ctx context.Context, store KeyStore) error {
+	keys.mu.Lock()
+	keys.store = store
+	// Check well within the lead time so every instance has a new key
+	// before any of them signs with it
+	checkInterval := keys.lead() / 4
+	keys.mu.Unlock()
+
+	if err := keys.rotate(ctx, time.Now()); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := keys.rotate(ctx, now); err != nil {
+					slog.ErrorContext(ctx, "Failed to rotate signing keys", "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func generateSigningKey(alg string, now time.Time) (signingKey, error) {
+	kid, err := randomToken(12)
+	if err != nil {
+		return signingKey{}, err
+	}
+
+	key := signingKey{kid: kid, createdAt: now}
+	switch alg {
+	case AlgEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return signingKey{}, err
+		}
+		key.method, key.private = jwt.SigningMethodEdDSA, private
+	case AlgRS256:
+		private, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return signingKey{}, err
+		}
+		key.method, key.private = jwt.SigningMethodRS256, private
+	default:
+		return signingKey{}, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	return key, nil
+}
+
+func encodeSigningKey(key signingKey) (models.SigningKey, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return models.SigningKey{}, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	return models.SigningKey{
+		KID:        key.kid,
+		Algorithm:  key.method.Alg(),
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		CreatedAt:  key.createdAt,
+	}, nil
+}
+
+func decodeSigningKeys(stored []models.SigningKey) ([]signingKey, error) {
+	ring := make([]signingKey, 0, len(stored))
+	for _, s := range stored {
+		block, _ := pem.Decode([]byte(s.PrivateKey))
+		if block == nil {
+			return nil, fmt.Errorf("signing key %s is not PEM encoded", s.KID)
+		}
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signing key %s: %w", s.KID, err)
+		}
+
+		key := signingKey{kid: s.KID, createdAt: s.CreatedAt}
+		switch private := parsed.(type) {
+		case ed25519.PrivateKey:
+			key.method, key.private = jwt.SigningMethodEdDSA, private
+		case *rsa.PrivateKey:
+			key.method, key.private = jwt.SigningMethodRS256, private
+		default:
+			return nil, fmt.Errorf("signing key %s has unsupported type %T", s.KID, parsed)
+		}
+		if key.method.Alg() != s.Algorithm {
+			return nil, fmt.Errorf("signing key %s is not a %s key", s.KID, s.Algorithm)
+		}
+		ring = append(ring, key)
+	}
+	return ring, nil
+}
+
+// JWK is a public signing key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKeys returns every key tokens may currently be signed or verified
+// with, including keys not yet used for signing
+func PublicKeys() JWKSet {
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range keys.keys {
+		jwk := JWK{Kid: key.kid, Alg: key.method.Alg(), Use: "sig"}
+		switch public := key.private.Public().(type) {
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
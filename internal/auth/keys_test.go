@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"saas-go-app/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// memoryKeyStore is a KeyStore shared by the key rings in a test
+type memoryKeyStore struct {
+	mu   sync.Mutex
+	keys []models.SigningKey
+}
+
+func (s *memoryKeyStore) ListSigningKeys(ctx context.Context) ([]models.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := append([]models.SigningKey{}, s.keys...)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (s *memoryKeyStore) CreateSigningKey(ctx context.Context, key models.SigningKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+func (s *memoryKeyStore) DeleteSigningKeysBefore(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.keys[:0]
+	for _, key := range s.keys {
+		if !key.CreatedAt.Before(before) {
+			kept = append(kept, key)
+		}
+	}
+	s.keys = kept
+	return nil
+}
+
+func kids(k *keyRing) []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	result := []string{}
+	for _, key := range k.keys {
+		result = append(result, key.kid)
+	}
+	return result
+}
+
+func TestSigningAlgorithms(t *testing.T) {
+	for _, tt := range []struct{ alg, kty string }{
+		{AlgEdDSA, "OKP"},
+		{AlgRS256, "RSA"},
+	} {
+		t.Run(tt.alg, func(t *testing.T) {
+			if err := InitJWT(JWTConfig{SigningAlg: tt.alg}); err != nil {
+				t.Fatalf("Failed to initialize JWT: %v", err)
+			}
+
+			token, err := GenerateToken(Identity{UserID: 1, Username: "testuser", OrgID: 1})
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			if err != nil {
+				t.Fatalf("Failed to parse token: %v", err)
+			}
+			if parsed.Method.Alg() != tt.alg {
+				t.Errorf("Expected alg %s, got %s", tt.alg, parsed.Method.Alg())
+			}
+			kid, _ := parsed.Header["kid"].(string)
+			if kid == "" {
+				t.Fatal("Token has no kid header")
+			}
+
+			if _, err := ValidateToken(context.Background(), token); err != nil {
+				t.Errorf("Failed to validate token: %v", err)
+			}
+
+			set := PublicKeys()
+			if len(set.Keys) != 1 || set.Keys[0].Kid != kid || set.Keys[0].Kty != tt.kty || set.Keys[0].Alg != tt.alg {
+				t.Errorf("Unexpected JWKS for kid %s: %+v", kid, set)
+			}
+		})
+	}
+}
+
+func TestInitJWTRejectsBadConfig(t *testing.T) {
+	if err := InitJWT(JWTConfig{SigningAlg: "HS256"}); err == nil {
+		t.Error("Expected HS256 to be rejected")
+	}
+
+	if err := InitJWT(JWTConfig{KeyRotationInterval: 10 * time.Minute}); err == nil {
+		t.Error("Expected a rotation interval shorter than two access token lifetimes to be rejected")
+	}
+}
+
+func TestValidateTokenRejectsUnknownKeysAndAlgorithms(t *testing.T) {
+	if err := InitJWT(JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+	token, err := GenerateToken(Identity{UserID: 1, Username: "testuser", OrgID: 1})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	// A symmetric token naming a real kid, signed with public material
+	key, _ := keys.signingKey(time.Now())
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: 1, OrgID: 1})
+	forged.Header["kid"] = key.kid
+	forgedString, err := forged.SignedString([]byte(key.kid))
+	if err != nil {
+		t.Fatalf("Failed to sign forged token: %v", err)
+	}
+	if _, err := ValidateToken(context.Background(), forgedString); !errors.Is(err, ErrInvalidToken) {
+		t.Error("Expected HS256 token to be rejected")
+	}
+
+	// Replacing the key ring invalidates tokens from the old one
+	if err := InitJWT(JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+	if _, err := ValidateToken(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Error("Expected token signed by an unknown key to be rejected")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryKeyStore{}
+	ring := &keyRing{alg: AlgEdDSA, rotation: 24 * time.Hour, store: store}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := ring.rotate(ctx, start); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	first := kids(ring)
+	if len(first) != 1 {
+		t.Fatalf("Expected one key after first rotation, got %v", first)
+	}
+
+	// Nothing is due halfway through the interval
+	if err := ring.rotate(ctx, start.Add(12*time.Hour)); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	if got := kids(ring); len(got) != 1 {
+		t.Fatalf("Expected no new key mid-interval, got %v", got)
+	}
+
+	// The next key is created a lead time early but does not sign yet
+	if err := ring.rotate(ctx, start.Add(23*time.Hour+30*time.Minute)); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	second := kids(ring)
+	if len(second) != 2 {
+		t.Fatalf("Expected a second key before the interval ends, got %v", second)
+	}
+	if key, _ := ring.signingKey(start.Add(24 * time.Hour)); key.kid != first[0] {
+		t.Errorf("Expected the old key to sign during the lead time, got %s", key.kid)
+	}
+	if key, _ := ring.signingKey(start.Add(25 * time.Hour)); key.kid != second[0] {
+		t.Errorf("Expected the new key to sign after the lead time, got %s", key.kid)
+	}
+
+	// The first key is kept for one more interval, then deleted
+	if _, ok := ring.lookup(first[0]); !ok {
+		t.Error("Expected the retired key to remain for verification")
+	}
+	if err := ring.rotate(ctx, start.Add(48*time.Hour+time.Minute)); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	if _, ok := ring.lookup(first[0]); ok {
+		t.Error("Expected the first key to be gone after retention")
+	}
+	stored, _ := store.ListSigningKeys(ctx)
+	for _, key := range stored {
+		if key.KID == first[0] {
+			t.Error("Expected the first key to be deleted from the store")
+		}
+	}
+}
+
+func TestVerificationReloadsKeysFromOtherInstances(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryKeyStore{}
+	start := time.Now().Add(-23*time.Hour - 30*time.Minute)
+
+	// Two instances share the store; only the first sees the next key
+	instanceA := &keyRing{alg: AlgEdDSA, rotation: 24 * time.Hour, store: store}
+	instanceB := &keyRing{alg: AlgEdDSA, rotation: 24 * time.Hour, store: store}
+	if err := instanceA.rotate(ctx, start); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	if err := instanceB.rotate(ctx, start); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	if err := instanceA.rotate(ctx, time.Now()); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+
+	newest := instanceA.keys[0]
+	token := jwt.NewWithClaims(newest.method, &Claims{UserID: 1, OrgID: 1})
+	token.Header["kid"] = newest.kid
+	tokenString, err := token.SignedString(newest.private)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	_, err = jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return instanceB.verificationKey(ctx, token)
+	})
+	if err != nil {
+		t.Errorf("Expected instance B to pick up the new key, got %v", err)
+	}
+}
+
+func TestUseKeyStoreSharesKeys(t *testing.T) {
+	if err := InitJWT(JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &memoryKeyStore{}
+	if err := UseKeyStore(ctx, store); err != nil {
+		t.Fatalf("Failed to use key store: %v", err)
+	}
+	token, err := GenerateToken(Identity{UserID: 1, Username: "testuser", OrgID: 1})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	// A restarted process loads the same key and accepts the token
+	if err := InitJWT(JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+	if err := UseKeyStore(ctx, store); err != nil {
+		t.Fatalf("Failed to use key store: %v", err)
+	}
+	if _, err := ValidateToken(ctx, token); err != nil {
+		t.Errorf("Expected token to survive a restart, got %v", err)
+	}
+
+	stored, _ := store.ListSigningKeys(ctx)
+	if len(stored) != 1 {
+		t.Errorf("Expected the restarted process to reuse the stored key, got %d keys", len(stored))
+	}
+}
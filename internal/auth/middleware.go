@@ -1,41 +1,125 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"saas-go-app/internal/apierror"
+	"saas-go-app/internal/logging"
+	"saas-go-app/internal/tenant"
+
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware validates JWT tokens for protected routes
+// AuthMiddleware authenticates protected routes with either a JWT
+// ("Bearer <token>") or an organization API key ("ApiKey <key>")
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, "Authorization header required"))
 			c.Abort()
 			return
 		}
 
-		// Extract token from "Bearer <token>"
+		// Extract credentials from "Bearer <token>" or "ApiKey <key>"
 		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+		if len(parts) != 2 || (parts[0] != "Bearer" && parts[0] != "ApiKey") {
+			c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, "Invalid authorization header format"))
 			c.Abort()
 			return
 		}
 
+		if parts[0] == "ApiKey" {
+			authenticateWithAPIKey(c, parts[1])
+			return
+		}
+
 		tokenString := parts[1]
-		claims, err := ValidateToken(tokenString)
+		claims, err := ValidateToken(c.Request.Context(), tokenString)
+		if errors.Is(err, ErrInvalidToken) {
+			c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, "Invalid or expired token"))
+			c.Abort()
+			return
+		}
+		// The token may be fine; a 401 would log the client out
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			apierror.Respond(c, "Failed to validate token", err)
+			c.Abort()
+			return
+		}
+
+		// Tokens issued before organizations existed carry no org_id
+		if claims.OrgID == 0 {
+			c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, "Token has no organization, please log in again"))
 			c.Abort()
 			return
 		}
 
-		// Store username in context for use in handlers
+		// Store identity in context for use in handlers, and scope the
+		// request context to the caller's organization for repositories
 		c.Set("username", claims.Username)
+		c.Set("user_id", claims.UserID)
+		c.Set("org_id", claims.OrgID)
+		c.Set("role", string(claims.Role))
+		c.Set("admin", claims.Admin)
+		c.Set("claims", claims)
+		c.Request = c.Request.WithContext(tenant.WithOrgID(c.Request.Context(), claims.OrgID))
 		c.Next()
 	}
 }
 
+// authenticateWithAPIKey is AuthMiddleware for "ApiKey" credentials. The
+// key acts in its organization with exactly its scopes.
+func authenticateWithAPIKey(c *gin.Context, raw string) {
+	key, err := authenticateAPIKey(c.Request.Context(), raw)
+	if errors.Is(err, errInvalidAPIKey) {
+		c.JSON(http.StatusUnauthorized, logging.ErrorBody(c, "Invalid, revoked or expired API key"))
+		c.Abort()
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, "Failed to check API key", err)
+		c.Abort()
+		return
+	}
+
+	scopes := make([]Permission, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = Permission(scope)
+	}
+
+	c.Set("username", "api_key:"+key.Prefix)
+	c.Set("org_id", key.OrgID)
+	c.Set("api_key_id", key.ID)
+	c.Set("scopes", scopes)
+	c.Request = c.Request.WithContext(tenant.WithOrgID(c.Request.Context(), key.OrgID))
+	c.Next()
+}
+
+// CurrentIdentity returns the identity stored by AuthMiddleware
+func CurrentIdentity(c *gin.Context) Identity {
+	return Identity{
+		UserID:   c.GetInt("user_id"),
+		Username: c.GetString("username"),
+		OrgID:    c.GetInt("org_id"),
+		Role:     Role(c.GetString("role")),
+		Admin:    c.GetBool("admin"),
+		APIKeyID: c.GetInt("api_key_id"),
+		Scopes:   scopesFromContext(c),
+	}
+}
+
+func scopesFromContext(c *gin.Context) []Permission {
+	scopes, _ := c.Get("scopes")
+	result, _ := scopes.([]Permission)
+	return result
+}
+
+// CurrentClaims returns the validated token claims stored by AuthMiddleware
+func CurrentClaims(c *gin.Context) *Claims {
+	claims, _ := c.Get("claims")
+	result, _ := claims.(*Claims)
+	return result
+}
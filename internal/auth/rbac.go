@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"net/http"
+
+	"saas-go-app/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Role is a user's role within an organization
+type Role string
+
+const (
+	RoleOwner    Role = "owner"
+	RoleAdmin    Role = "admin"
+	RoleMember   Role = "member"
+	RoleReadOnly Role = "read_only"
+)
+
+// Permission names an action on a resource, in "resource:action" form
+type Permission string
+
+const (
+	PermCustomersRead   Permission = "customers:read"
+	PermCustomersWrite  Permission = "customers:write"
+	PermCustomersDelete Permission = "customers:delete"
+	PermAccountsRead    Permission = "accounts:read"
+	PermAccountsWrite   Permission = "accounts:write"
+	PermAccountsDelete  Permission = "accounts:delete"
+	PermAnalyticsRead   Permission = "analytics:read"
+	PermAPIKeysManage   Permission = "api_keys:manage"
+	PermMembersManage   Permission = "members:manage"
+)
+
+// rolePermissions is the policy table: what each role may do
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: {
+		PermCustomersRead, PermCustomersWrite, PermCustomersDelete,
+		PermAccountsRead, PermAccountsWrite, PermAccountsDelete,
+		PermAnalyticsRead,
+		PermAPIKeysManage,
+		PermMembersManage,
+	},
+	RoleAdmin: {
+		PermCustomersRead, PermCustomersWrite, PermCustomersDelete,
+		PermAccountsRead, PermAccountsWrite, PermAccountsDelete,
+		PermAnalyticsRead,
+		PermAPIKeysManage,
+		PermMembersManage,
+	},
+	RoleMember: {
+		PermCustomersRead, PermCustomersWrite,
+		PermAccountsRead, PermAccountsWrite,
+		PermAnalyticsRead,
+	},
+	RoleReadOnly: {
+		PermCustomersRead,
+		PermAccountsRead,
+		PermAnalyticsRead,
+	},
+}
+
+// ValidRole reports whether role is one of the defined roles
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[Role(role)]
+	return ok
+}
+
+// ValidPermission reports whether any role is granted perm
+func ValidPermission(perm string) bool {
+	for _, granted := range rolePermissions {
+		for _, p := range granted {
+			if string(p) == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether the policy grants perm to role
+func HasPermission(role Role, perm Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether the identity may perform perm: through its
+// scopes for an API key, through its role otherwise
+func (i Identity) HasPermission(perm Permission) bool {
+	if i.APIKeyID != 0 {
+		for _, scope := range i.Scopes {
+			if scope == perm {
+				return true
+			}
+		}
+		return false
+	}
+	return HasPermission(i.Role, perm)
+}
+
+// RequirePermission rejects requests whose role, or API key scopes, lack
+// perm with 403. It must run after AuthMiddleware.
+func RequirePermission(perm Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CurrentIdentity(c).HasPermission(perm) {
+			body := logging.ErrorBody(c, "Insufficient permissions")
+			body["permission"] = string(perm)
+			c.JSON(http.StatusForbidden, body)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireUser rejects requests authenticated with an API key with 403, for
+// routes that act on a user's own account or sessions
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if CurrentIdentity(c).APIKeyID != 0 {
+			c.JSON(http.StatusForbidden, logging.ErrorBody(c, "This endpoint requires a user token, not an API key"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects requests from anyone but a system administrator with
+// 403. Organization roles do not apply: every user owns an organization.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CurrentIdentity(c).Admin {
+			c.JSON(http.StatusForbidden, logging.ErrorBody(c, "Administrator access required"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"saas-go-app/internal/logging"
+	"saas-go-app/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRolePolicy(t *testing.T) {
+	tests := []struct {
+		role Role
+		perm Permission
+		want bool
+	}{
+		{RoleOwner, PermCustomersDelete, true},
+		{RoleOwner, PermAccountsDelete, true},
+		{RoleAdmin, PermCustomersDelete, true},
+		{RoleAdmin, PermAccountsWrite, true},
+		{RoleMember, PermCustomersRead, true},
+		{RoleMember, PermCustomersWrite, true},
+		{RoleMember, PermCustomersDelete, false},
+		{RoleMember, PermAccountsDelete, false},
+		{RoleMember, PermAnalyticsRead, true},
+		{RoleReadOnly, PermCustomersRead, true},
+		{RoleReadOnly, PermAccountsRead, true},
+		{RoleReadOnly, PermAnalyticsRead, true},
+		{RoleReadOnly, PermCustomersWrite, false},
+		{RoleReadOnly, PermAccountsWrite, false},
+		{RoleReadOnly, PermCustomersDelete, false},
+		{RoleOwner, PermAPIKeysManage, true},
+		{RoleAdmin, PermAPIKeysManage, true},
+		{RoleMember, PermAPIKeysManage, false},
+		{RoleReadOnly, PermAPIKeysManage, false},
+		{RoleOwner, PermMembersManage, true},
+		{RoleAdmin, PermMembersManage, true},
+		{RoleMember, PermMembersManage, false},
+		{RoleReadOnly, PermMembersManage, false},
+		{Role(""), PermCustomersRead, false},
+		{Role("superuser"), PermCustomersRead, false},
+	}
+
+	for _, tt := range tests {
+		if got := HasPermission(tt.role, tt.perm); got != tt.want {
+			t.Errorf("HasPermission(%q, %q) = %v, want %v", tt.role, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestValidRole(t *testing.T) {
+	for _, role := range []string{"owner", "admin", "member", "read_only"} {
+		if !ValidRole(role) {
+			t.Errorf("Expected %q to be a valid role", role)
+		}
+	}
+	for _, role := range []string{"", "Owner", "read-only", "root"} {
+		if ValidRole(role) {
+			t.Errorf("Expected %q to be rejected", role)
+		}
+	}
+}
+
+func TestValidPermission(t *testing.T) {
+	if !ValidPermission("customers:read") || !ValidPermission("api_keys:manage") {
+		t.Error("Expected granted permissions to be valid")
+	}
+	if ValidPermission("customers:nuke") || ValidPermission("") {
+		t.Error("Expected unknown permissions to be rejected")
+	}
+}
+
+func TestAPIKeyIdentityUsesScopes(t *testing.T) {
+	identity := Identity{OrgID: 1, APIKeyID: 5, Scopes: []Permission{PermCustomersRead}}
+
+	if !identity.HasPermission(PermCustomersRead) {
+		t.Error("Expected scoped permission to be granted")
+	}
+	if identity.HasPermission(PermCustomersWrite) {
+		t.Error("Expected permission outside scopes to be denied")
+	}
+
+	// A role never widens an API key's scopes
+	identity.Role = RoleOwner
+	if identity.HasPermission(PermCustomersDelete) {
+		t.Error("Expected API key identity to ignore role")
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := InitJWT(JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(logging.Middleware(), AuthMiddleware())
+	router.DELETE("/customers/1", RequirePermission(PermCustomersDelete), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		role Role
+		want int
+	}{
+		{RoleOwner, http.StatusNoContent},
+		{RoleAdmin, http.StatusNoContent},
+		{RoleMember, http.StatusForbidden},
+		{RoleReadOnly, http.StatusForbidden},
+		{Role(""), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		token, err := GenerateToken(Identity{UserID: 1, Username: "user", OrgID: 1, Role: tt.role})
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+
+		req, _ := http.NewRequest("DELETE", "/customers/1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(logging.RequestIDHeader, "req-42")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("Role %q: expected status %d, got %d", tt.role, tt.want, w.Code)
+		}
+		if w.Code != http.StatusForbidden {
+			continue
+		}
+		var body map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if body["permission"] != string(PermCustomersDelete) || body["request_id"] != "req-42" {
+			t.Errorf("Role %q: expected the permission and request ID in the body, got %v", tt.role, body)
+		}
+	}
+}
+
+func TestAuthMiddlewareDatabaseErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := InitJWT(JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+	token, err := GenerateToken(Identity{UserID: 1, Username: "user", OrgID: 1, Role: RoleOwner})
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	defer SetDenylist(nil)
+
+	router := gin.New()
+	router.Use(AuthMiddleware())
+	router.GET("/customers", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// Only tokens found bad log the client out; a denylist that cannot be
+	// read says nothing about the token
+	tests := []struct {
+		name  string
+		token string
+		err   error
+		want  int
+	}{
+		{"valid", token, nil, http.StatusOK},
+		{"malformed", "not-a-token", nil, http.StatusUnauthorized},
+		{"unavailable", token, fmt.Errorf("begin transaction: %w", repository.ErrUnavailable), http.StatusServiceUnavailable},
+		{"other", token, errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		SetDenylist(denylistFunc(func(jti string) (bool, error) {
+			return false, tt.err
+		}))
+
+		req, _ := http.NewRequest("GET", "/customers", nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, w.Code)
+		}
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := InitJWT(JWTConfig{}); err != nil {
+		t.Fatalf("Failed to initialize JWT: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(AuthMiddleware())
+	router.GET("/admin", RequireAdmin(), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name     string
+		identity Identity
+		want     int
+	}{
+		{"system admin", Identity{UserID: 1, Username: "root", OrgID: 1, Role: RoleMember, Admin: true}, http.StatusNoContent},
+		{"organization owner", Identity{UserID: 2, Username: "owner", OrgID: 1, Role: RoleOwner}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		token, err := GenerateToken(tt.identity)
+		if err != nil {
+			t.Fatalf("Failed to generate token: %v", err)
+		}
+
+		req, _ := http.NewRequest("GET", "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidToken is returned by ValidateToken for tokens that are
+// malformed, badly signed, expired or revoked. Other errors mean the token
+// could not be checked.
+var ErrInvalidToken = errors.New("invalid token")
+
+// ErrTokenRevoked is returned by ValidateToken for denylisted tokens
+var ErrTokenRevoked = fmt.Errorf("%w: token has been revoked", ErrInvalidToken)
+
+// Denylist reports whether an access token was revoked before expiring
+type Denylist interface {
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+var denylist Denylist
+
+// SetDenylist makes ValidateToken reject tokens whose jti is in d
+func SetDenylist(d Denylist) {
+	denylist = d
+}
+
+// NewRefreshToken returns a random refresh token and the hash to store for it
+func NewRefreshToken() (token, hash string, err error) {
+	token, err = randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the hex SHA-256 of a refresh token. Refresh
+// tokens are random, so an unsalted fast hash is enough.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewTokenFamily returns an ID for a new chain of rotated refresh tokens
+func NewTokenFamily() (string, error) {
+	return randomToken(16)
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,565 @@
+// Package config loads the application's settings into one typed struct
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.yaml.in/yaml/v3"
+)
+
+// Config is the application's configuration. Each setting can come from,
+// in increasing precedence, its default, the YAML file, .env and the
+// environment. Fields tagged env are read from that variable; fields
+// tagged secret are redacted when printed.
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Jobs     JobsConfig     `yaml:"jobs"`
+	Seed     SeedConfig     `yaml:"seed"`
+	Log      LogConfig      `yaml:"log"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+	Health   HealthConfig   `yaml:"health"`
+}
+
+type ServerConfig struct {
+	Port int `yaml:"port" env:"PORT" default:"8080"`
+	// ShutdownTimeout bounds the wait for in-flight requests and jobs on
+	// SIGTERM; Heroku kills the dyno 30 seconds after sending it
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"25s"`
+	// RequestTimeout is the deadline of API requests; their queries are
+	// cancelled when it passes
+	RequestTimeout time.Duration `yaml:"request_timeout" env:"REQUEST_TIMEOUT" default:"10s"`
+	// AnalyticsTimeout is the deadline of analytics requests, whose
+	// queries scan more rows
+	AnalyticsTimeout time.Duration `yaml:"analytics_timeout" env:"ANALYTICS_REQUEST_TIMEOUT" default:"30s"`
+}
+
+type DatabaseConfig struct {
+	// URL is overridden by a HEROKU_POSTGRESQL_<COLOR>_URL variable, which
+	// Heroku Postgres Advanced sets for the database it provisions
+	URL string `yaml:"url" env:"DATABASE_URL" secret:"true"`
+	// AnalyticsURL is a follower pool for analytics, named analytics
+	AnalyticsURL string `yaml:"analytics_url" env:"ANALYTICS_DB_URL" secret:"true"`
+	// Followers are further followers by name. HEROKU_POSTGRESQL_<COLOR>_FOLLOWER_URL
+	// variables add the follower <color>.
+	Followers map[string]string `yaml:"followers,omitempty" secret:"true"`
+	// MaxStaleness is how far behind the primary a follower may be and
+	// still serve analytics; the primary serves them otherwise
+	MaxStaleness time.Duration `yaml:"max_staleness" env:"ANALYTICS_MAX_STALENESS" default:"10s"`
+	// FollowerCheckInterval is how often followers' lag is measured
+	FollowerCheckInterval time.Duration `yaml:"follower_check_interval" env:"FOLLOWER_CHECK_INTERVAL" default:"5s"`
+	// ConsistencyWait is how long a read carrying a consistency token
+	// waits for a follower to replay the client's writes before it uses
+	// the primary
+	ConsistencyWait time.Duration `yaml:"consistency_wait" env:"CONSISTENCY_WAIT" default:"250ms"`
+	// Pool configures the primary's pool, from DB_* variables
+	Pool PoolConfig `yaml:"pool" env:"DB_"`
+	// FollowerPool configures each follower's pool, from FOLLOWER_DB_*
+	// variables
+	FollowerPool PoolConfig `yaml:"follower_pool" env:"FOLLOWER_DB_"`
+}
+
+// PoolConfig configures one database pool. Its variables are prefixed by
+// the pool's, such as DB_MAX_OPEN_CONNS for the primary.
+type PoolConfig struct {
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"MAX_OPEN_CONNS" default:"20"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"MAX_IDLE_CONNS" default:"10"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"CONN_MAX_LIFETIME" default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"CONN_MAX_IDLE_TIME" default:"5m"`
+	// StatementTimeout is set as statement_timeout on each connection;
+	// 0 leaves the server's
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"STATEMENT_TIMEOUT" default:"30s"`
+	// ConnectTimeout bounds opening a connection
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"CONNECT_TIMEOUT" default:"5s"`
+	// BreakerThreshold consecutive failures to connect open the pool's
+	// circuit breaker, failing queries at once for BreakerCooldown
+	// before a connection is tried again
+	BreakerThreshold int           `yaml:"breaker_threshold" env:"BREAKER_THRESHOLD" default:"5"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" env:"BREAKER_COOLDOWN" default:"10s"`
+}
+
+// validate returns the problems with the pool whose variables start
+// with prefix
+func (c PoolConfig) validate(prefix string) []string {
+	var problems []string
+	if c.MaxOpenConns < 1 {
+		problems = append(problems, prefix+"MAX_OPEN_CONNS: must be positive")
+	}
+	if c.MaxIdleConns < 0 || c.MaxIdleConns > c.MaxOpenConns {
+		problems = append(problems, prefix+"MAX_IDLE_CONNS: must be between 0 and "+prefix+"MAX_OPEN_CONNS")
+	}
+	if c.ConnMaxLifetime < 0 {
+		problems = append(problems, prefix+"CONN_MAX_LIFETIME: must not be negative")
+	}
+	if c.ConnMaxIdleTime < 0 {
+		problems = append(problems, prefix+"CONN_MAX_IDLE_TIME: must not be negative")
+	}
+	if c.StatementTimeout < 0 {
+		problems = append(problems, prefix+"STATEMENT_TIMEOUT: must not be negative")
+	}
+	if c.ConnectTimeout <= 0 {
+		problems = append(problems, prefix+"CONNECT_TIMEOUT: must be positive")
+	}
+	if c.BreakerThreshold < 1 {
+		problems = append(problems, prefix+"BREAKER_THRESHOLD: must be positive")
+	}
+	if c.BreakerCooldown <= 0 {
+		problems = append(problems, prefix+"BREAKER_COOLDOWN: must be positive")
+	}
+	return problems
+}
+
+// FollowerURLs returns every follower's URL by name, analytics for
+// AnalyticsURL
+func (c DatabaseConfig) FollowerURLs() map[string]string {
+	urls := make(map[string]string, len(c.Followers)+1)
+	for name, url := range c.Followers {
+		urls[name] = url
+	}
+	if c.AnalyticsURL != "" {
+		urls["analytics"] = c.AnalyticsURL
+	}
+	return urls
+}
+
+type AuthConfig struct {
+	SigningAlg          string        `yaml:"signing_alg" env:"JWT_SIGNING_ALG" default:"EdDSA"`
+	KeyRotationInterval time.Duration `yaml:"key_rotation_interval" env:"JWT_KEY_ROTATION_INTERVAL" default:"720h"`
+}
+
+type JobsConfig struct {
+	// RedisURL selects the Redis-backed queue; jobs run in process when empty
+	RedisURL string `yaml:"redis_url" env:"REDIS_URL" secret:"true"`
+	// ProcessInWeb runs tasks in the web process as well as in workers, for
+	// deployments without a worker dyno. Without Redis the web process
+	// always runs them.
+	ProcessInWeb bool `yaml:"process_in_web" env:"JOBS_PROCESS_IN_WEB"`
+	// Concurrency is how many tasks each processing instance runs at once
+	Concurrency int `yaml:"concurrency" env:"JOBS_CONCURRENCY" default:"10"`
+	// Queues are the queues processed, with their relative weights, as
+	// "critical=6,default=3,low=1" in JOBS_QUEUES
+	Queues map[string]int `yaml:"queues" env:"JOBS_QUEUES" default:"critical=6,default=3,low=1"`
+	// WorkerPort serves the worker's health check and metrics; 0 turns
+	// the server off
+	WorkerPort int `yaml:"worker_port" env:"WORKER_PORT" default:"8081"`
+	// Schedules overrides periodic job specs by job name, "off" disabling
+	// a job. SCHEDULE_<NAME> variables override the file.
+	Schedules map[string]string `yaml:"schedules,omitempty"`
+}
+
+type SeedConfig struct {
+	// OnStartup seeds an empty database when the server starts
+	OnStartup bool `yaml:"on_startup" env:"SEED_DATA"`
+	// Force clears existing data before seeding on startup
+	Force               bool `yaml:"force" env:"FORCE_RESEED"`
+	Performance         bool `yaml:"performance" env:"SEED_PERFORMANCE_DATA"`
+	Customers           int  `yaml:"customers" env:"SEED_CUSTOMERS" default:"1000"`
+	AccountsPerCustomer int  `yaml:"accounts_per_customer" env:"SEED_ACCOUNTS_PER_CUSTOMER" default:"5"`
+}
+
+type LogConfig struct {
+	// Format is json, which log drains can parse, or text
+	Format string `yaml:"format" env:"LOG_FORMAT" default:"json"`
+	// Level is the least severe level logged: debug, info, warn or error
+	Level string `yaml:"level" env:"LOG_LEVEL" default:"info"`
+}
+
+type TracingConfig struct {
+	// Exporter is otlp, configured by the OTEL_EXPORTER_OTLP_* variables,
+	// stdout or none
+	Exporter    string `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" default:"none"`
+	ServiceName string `yaml:"service_name" env:"OTEL_SERVICE_NAME" default:"saas-go-app"`
+}
+
+type HealthConfig struct {
+	// CheckTimeout bounds each dependency check of the readiness endpoint
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+	// MaxReplicationLag is how far the analytics follower may fall behind
+	// before the service reports itself degraded
+	MaxReplicationLag time.Duration `yaml:"max_replication_lag" env:"HEALTH_MAX_REPLICATION_LAG" default:"30s"`
+}
+
+// ValidationError lists every problem found in the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load reads the configuration from the YAML file at path, or at
+// CONFIG_FILE when path is empty, and from .env and the environment. The
+// returned Config is never nil, so it can be printed even when invalid; a
+// *ValidationError lists every invalid setting.
+func Load(path string) (*Config, error) {
+	// Variables already set take precedence over .env
+	_ = godotenv.Load()
+
+	cfg := &Config{}
+	var problems []string
+	// The file merges into maps instead of replacing them, so their
+	// defaults wait until it has been read
+	setDefaults(cfg, func(field reflect.Value) bool { return field.Kind() != reflect.Map })
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := readFile(cfg, path); err != nil {
+			return cfg, err
+		}
+	}
+	setDefaults(cfg, func(field reflect.Value) bool { return field.Kind() == reflect.Map && field.IsNil() })
+
+	walk(reflect.ValueOf(cfg).Elem(), func(field reflect.Value, _ reflect.StructTag, name string) {
+		value, ok := os.LookupEnv(name)
+		if name == "" || !ok || value == "" {
+			return
+		}
+		if err := set(field, value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	})
+	if url := herokuPostgresURL(); url != "" {
+		cfg.Database.URL = url
+	}
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if color, ok := herokuFollower(name); ok && value != "" {
+			if cfg.Database.Followers == nil {
+				cfg.Database.Followers = make(map[string]string)
+			}
+			cfg.Database.Followers[color] = value
+		}
+		if job, ok := strings.CutPrefix(name, "SCHEDULE_"); ok && value != "" {
+			if cfg.Jobs.Schedules == nil {
+				cfg.Jobs.Schedules = make(map[string]string)
+			}
+			cfg.Jobs.Schedules[strings.ToLower(job)] = value
+		}
+	}
+
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return cfg, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// ParseFlags defines the --config and --print-config flags, parses the
+// command line and loads the configuration. It exits after printing the
+// configuration for --print-config, and on invalid configuration.
+func ParseFlags() *Config {
+	path := flag.String("config", "", "YAML configuration file (default $CONFIG_FILE)")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration, secrets redacted, and exit")
+	flag.Parse()
+
+	cfg, err := Load(*path)
+	if *printConfig {
+		if err := cfg.Write(os.Stdout); err != nil {
+			log.Fatal("Failed to print configuration:", err)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cfg
+}
+
+// setDefaults sets the fields of cfg selected by include to their defaults
+func setDefaults(cfg *Config, include func(field reflect.Value) bool) {
+	walk(reflect.ValueOf(cfg).Elem(), func(field reflect.Value, tag reflect.StructTag, _ string) {
+		value, ok := tag.Lookup("default")
+		if !ok || !include(field) {
+			return
+		}
+		if err := set(field, value); err != nil {
+			panic(fmt.Sprintf("config: bad default %q: %v", value, err))
+		}
+	})
+}
+
+// Values accepted by the auth, jobs, logging and tracing packages, kept
+// here so config need not import them; the tests check they agree
+var (
+	signingAlgs    = []string{"EdDSA", "RS256"}
+	redisSchemes   = []string{"redis", "rediss"}
+	logFormats     = []string{"json", "text"}
+	logLevels      = []string{"debug", "info", "warn", "error"}
+	traceExporters = []string{"otlp", "stdout", "none"}
+)
+
+// minKeyRotationInterval is twice the access token lifetime, so retired
+// keys outlive the tokens they signed
+const minKeyRotationInterval = 30 * time.Minute
+
+// herokuPostgresURL returns the first HEROKU_POSTGRESQL_<COLOR>_URL by
+// name, which Heroku Postgres Advanced sets for each database, skipping
+// followers
+func herokuPostgresURL() string {
+	var names []string
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if _, follower := herokuFollower(name); follower {
+			continue
+		}
+		if strings.HasPrefix(name, "HEROKU_POSTGRESQL_") && strings.HasSuffix(name, "_URL") && value != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	slog.Info("Using " + names[0] + " for the primary database")
+	return os.Getenv(names[0])
+}
+
+// herokuFollower returns the lowercased color of a
+// HEROKU_POSTGRESQL_<COLOR>_FOLLOWER_URL variable name
+func herokuFollower(name string) (string, bool) {
+	color, ok := strings.CutPrefix(name, "HEROKU_POSTGRESQL_")
+	if !ok {
+		return "", false
+	}
+	color, ok = strings.CutSuffix(color, "_FOLLOWER_URL")
+	if !ok || color == "" {
+		return "", false
+	}
+	return strings.ToLower(color), true
+}
+
+// readFile decodes the YAML file at path over cfg, rejecting unknown keys
+func readFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("PORT: %d is not a valid port", c.Server.Port))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "SHUTDOWN_TIMEOUT: must be positive")
+	}
+	if c.Server.RequestTimeout <= 0 {
+		problems = append(problems, "REQUEST_TIMEOUT: must be positive")
+	}
+	if c.Server.AnalyticsTimeout <= 0 {
+		problems = append(problems, "ANALYTICS_REQUEST_TIMEOUT: must be positive")
+	}
+
+	if c.Database.URL == "" {
+		problems = append(problems, "DATABASE_URL: is required")
+	}
+	if _, ok := c.Database.Followers["primary"]; ok {
+		problems = append(problems, "database.followers: primary is the name of DATABASE_URL")
+	}
+	if _, ok := c.Database.Followers["analytics"]; ok && c.Database.AnalyticsURL != "" {
+		problems = append(problems, "database.followers: analytics is the name of ANALYTICS_DB_URL")
+	}
+	if c.Database.MaxStaleness <= 0 {
+		problems = append(problems, "ANALYTICS_MAX_STALENESS: must be positive")
+	}
+	if c.Database.FollowerCheckInterval <= 0 {
+		problems = append(problems, "FOLLOWER_CHECK_INTERVAL: must be positive")
+	}
+	if c.Database.ConsistencyWait < 0 {
+		problems = append(problems, "CONSISTENCY_WAIT: must not be negative")
+	}
+	problems = append(problems, c.Database.Pool.validate("DB_")...)
+	problems = append(problems, c.Database.FollowerPool.validate("FOLLOWER_DB_")...)
+
+	if !slices.Contains(signingAlgs, c.Auth.SigningAlg) {
+		problems = append(problems, fmt.Sprintf("JWT_SIGNING_ALG: %q is not supported, use %s", c.Auth.SigningAlg, strings.Join(signingAlgs, " or ")))
+	}
+	if c.Auth.KeyRotationInterval < minKeyRotationInterval {
+		problems = append(problems, fmt.Sprintf("JWT_KEY_ROTATION_INTERVAL: must be at least %s", minKeyRotationInterval))
+	}
+
+	if c.Jobs.RedisURL != "" {
+		if u, err := url.Parse(c.Jobs.RedisURL); err != nil || !slices.Contains(redisSchemes, u.Scheme) || u.Host == "" {
+			problems = append(problems, "REDIS_URL: invalid Redis URL, expected redis://[:password@]host:port[/db] or rediss://")
+		}
+	}
+	if c.Jobs.Concurrency < 1 {
+		problems = append(problems, "JOBS_CONCURRENCY: must be positive")
+	}
+	// Tasks go to the default queue unless they name another
+	if _, ok := c.Jobs.Queues["default"]; !ok {
+		problems = append(problems, "JOBS_QUEUES: must include the default queue")
+	}
+	queues := make([]string, 0, len(c.Jobs.Queues))
+	for queue := range c.Jobs.Queues {
+		queues = append(queues, queue)
+	}
+	sort.Strings(queues)
+	for _, queue := range queues {
+		if c.Jobs.Queues[queue] < 1 {
+			problems = append(problems, fmt.Sprintf("JOBS_QUEUES: priority of %s must be positive", queue))
+		}
+	}
+	if c.Jobs.WorkerPort < 0 || c.Jobs.WorkerPort > 65535 {
+		problems = append(problems, fmt.Sprintf("WORKER_PORT: %d is not a valid port", c.Jobs.WorkerPort))
+	}
+
+	if !slices.Contains(logFormats, strings.ToLower(c.Log.Format)) {
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT: unknown log format %q, use %s", c.Log.Format, strings.Join(logFormats, " or ")))
+	}
+	if !slices.Contains(logLevels, strings.ToLower(c.Log.Level)) {
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL: unknown log level %q, use %s", c.Log.Level, strings.Join(logLevels, ", ")))
+	}
+	if !slices.Contains(traceExporters, strings.ToLower(c.Tracing.Exporter)) {
+		problems = append(problems, fmt.Sprintf("OTEL_TRACES_EXPORTER: %q is not supported, use %s", c.Tracing.Exporter, strings.Join(traceExporters, ", ")))
+	}
+
+	if c.Health.CheckTimeout <= 0 {
+		problems = append(problems, "HEALTH_CHECK_TIMEOUT: must be positive")
+	}
+	if c.Health.MaxReplicationLag <= 0 {
+		problems = append(problems, "HEALTH_MAX_REPLICATION_LAG: must be positive")
+	}
+
+	if c.Seed.Customers < 1 {
+		problems = append(problems, "SEED_CUSTOMERS: must be positive")
+	}
+	if c.Seed.AccountsPerCustomer < 1 {
+		problems = append(problems, "SEED_ACCOUNTS_PER_CUSTOMER: must be positive")
+	}
+	return problems
+}
+
+// Write prints the configuration as YAML with secrets redacted
+func (c *Config) Write(w io.Writer) error {
+	redacted := *c
+	walk(reflect.ValueOf(&redacted).Elem(), func(field reflect.Value, tag reflect.StructTag, _ string) {
+		if tag.Get("secret") != "true" {
+			return
+		}
+		switch secret := field.Interface().(type) {
+		case string:
+			if secret != "" {
+				field.SetString(redact(secret))
+			}
+		case map[string]string:
+			// The copy shares maps with c
+			redactedMap := make(map[string]string, len(secret))
+			for key, value := range secret {
+				redactedMap[key] = redact(value)
+			}
+			field.Set(reflect.ValueOf(redactedMap))
+		}
+	})
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&redacted); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// redact hides a secret, keeping the scheme, user and host of URLs
+func redact(secret string) string {
+	u, err := url.Parse(secret)
+	if err != nil || u.Host == "" {
+		return "REDACTED"
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "REDACTED")
+	}
+	u.RawQuery = ""
+	return u.String()
+}
+
+// walk calls fn for every leaf field of the struct v with the name of
+// its variable. The env tag of a struct field prefixes the variables of
+// the fields in it.
+func walk(v reflect.Value, fn func(field reflect.Value, tag reflect.StructTag, env string)) {
+	walkPrefixed(v, "", fn)
+}
+
+func walkPrefixed(v reflect.Value, prefix string, fn func(field reflect.Value, tag reflect.StructTag, env string)) {
+	for i := 0; i < v.NumField(); i++ {
+		field, info := v.Field(i), v.Type().Field(i)
+		if field.Kind() == reflect.Struct {
+			walkPrefixed(field, prefix+info.Tag.Get("env"), fn)
+			continue
+		}
+		env := info.Tag.Get("env")
+		if env != "" {
+			env = prefix + env
+		}
+		fn(field, info.Tag, env)
+	}
+}
+
+// set parses value into field according to its type
+func set(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		field.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		field.SetInt(int64(n))
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 1h", value)
+		}
+		field.SetInt(int64(d))
+	case map[string]int:
+		m := make(map[string]int)
+		for _, entry := range strings.Split(value, ",") {
+			key, n, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			weight, err := strconv.Atoi(n)
+			if !ok || key == "" || err != nil {
+				return fmt.Errorf("%q is not a list such as a=1,b=2", value)
+			}
+			m[key] = weight
+		}
+		field.Set(reflect.ValueOf(m))
+	}
+	return nil
+}
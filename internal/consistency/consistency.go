@@ -0,0 +1,97 @@
+// Package consistency gives clients read-your-writes consistency over
+// followers. A request that writes gets a token naming the primary's WAL
+// position after its commit; reads from a request carrying the token only
+// use a follower that has replayed up to it.
+package consistency
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LSN is a position in the primary's write-ahead log
+type LSN uint64
+
+// ParseLSN parses the text form Postgres uses, such as 16/B374D848
+func ParseLSN(s string) (LSN, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	h, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	l, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	return LSN(h<<32 | l), nil
+}
+
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint64(l)>>32, uint64(l)&0xFFFFFFFF)
+}
+
+type minLSNKey struct{}
+
+type trackerKey struct{}
+
+// tracker collects the LSN after the writes of one request
+type tracker struct {
+	mu  sync.Mutex
+	lsn LSN
+}
+
+// WithMinLSN returns a copy of ctx whose reads must see the primary's
+// state at lsn
+func WithMinLSN(ctx context.Context, lsn LSN) context.Context {
+	return context.WithValue(ctx, minLSNKey{}, lsn)
+}
+
+// WithTracking returns a copy of ctx in which RecordWrite collects the
+// LSN for the response's token
+func WithTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, trackerKey{}, &tracker{})
+}
+
+// Tracking reports whether writes from ctx should record their LSN
+func Tracking(ctx context.Context) bool {
+	_, ok := ctx.Value(trackerKey{}).(*tracker)
+	return ok
+}
+
+// RecordWrite notes that a write from ctx committed at or before lsn
+func RecordWrite(ctx context.Context, lsn LSN) {
+	t, ok := ctx.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lsn = max(t.lsn, lsn)
+}
+
+// written returns the LSN recorded by the writes from ctx
+func written(ctx context.Context) (LSN, bool) {
+	t, ok := ctx.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return 0, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lsn, t.lsn != 0
+}
+
+// MinLSN returns the LSN reads from ctx must see: the client's token or
+// the request's own writes, whichever is later
+func MinLSN(ctx context.Context) (LSN, bool) {
+	lsn, ok := ctx.Value(minLSNKey{}).(LSN)
+	if own, wrote := written(ctx); wrote && own > lsn {
+		return own, true
+	}
+	return lsn, ok && lsn != 0
+}
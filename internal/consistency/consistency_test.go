@@ -0,0 +1,121 @@
+package consistency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseLSN(t *testing.T) {
+	lsn, err := ParseLSN("16/B374D848")
+	if err != nil {
+		t.Fatalf("Failed to parse LSN: %v", err)
+	}
+	if lsn != 0x16B374D848 || lsn.String() != "16/B374D848" {
+		t.Errorf("Expected 16/B374D848 to round trip, got %d (%s)", uint64(lsn), lsn)
+	}
+	for _, invalid := range []string{"", "16", "16/", "G/0", "0/100000000"} {
+		if _, err := ParseLSN(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestMinLSN(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := MinLSN(ctx); ok {
+		t.Error("Expected no LSN without a token or writes")
+	}
+
+	ctx = WithTracking(WithMinLSN(ctx, 100))
+	RecordWrite(ctx, 50)
+	if lsn, _ := MinLSN(ctx); lsn != 100 {
+		t.Errorf("Expected the client's later token to win, got %d", lsn)
+	}
+	RecordWrite(ctx, 200)
+	RecordWrite(ctx, 150)
+	if lsn, _ := MinLSN(ctx); lsn != 200 {
+		t.Errorf("Expected the request's latest write to win, got %d", lsn)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	var seen LSN
+	router.GET("/read", func(c *gin.Context) {
+		seen, _ = MinLSN(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	router.POST("/write", func(c *gin.Context) {
+		RecordWrite(c.Request.Context(), 0x1A0)
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+	router.DELETE("/write", func(c *gin.Context) {
+		RecordWrite(c.Request.Context(), 0x1A0)
+		c.Status(http.StatusNoContent)
+	})
+	router.POST("/noop", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, method := range []string{http.MethodPost, http.MethodDelete} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/write", nil))
+		if got := w.Header().Get(TokenHeader); got != "0/1A0" {
+			t.Errorf("%s: expected the write's token in the response, got %q", method, got)
+		}
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != TokenCookie || cookies[0].Value != "0/1A0" || !cookies[0].HttpOnly {
+			t.Errorf("%s: expected the token as an HttpOnly cookie, got %v", method, cookies)
+		}
+		if len(cookies) == 1 && (cookies[0].Secure || cookies[0].SameSite != http.SameSiteLaxMode) {
+			t.Errorf("%s: expected a SameSite=Lax cookie that is not Secure over HTTP, got %v", method, cookies)
+		}
+	}
+
+	// Over HTTPS, directly or behind Heroku's router, the cookie is Secure
+	tls := httptest.NewRequest(http.MethodPost, "https://example.com/write", nil)
+	forwarded := httptest.NewRequest(http.MethodPost, "/write", nil)
+	forwarded.Header.Set("X-Forwarded-Proto", "https")
+	for _, req := range []*http.Request{tls, forwarded} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if cookies := w.Result().Cookies(); len(cookies) != 1 || !cookies[0].Secure || cookies[0].SameSite != http.SameSiteLaxMode {
+			t.Errorf("Expected a Secure, SameSite=Lax cookie over HTTPS, got %v", cookies)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/noop", nil))
+	if got := w.Header().Get(TokenHeader); got != "" {
+		t.Errorf("Expected no token without writes, got %q", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/read", nil)
+	req.Header.Set(TokenHeader, "0/1A0")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if seen != 0x1A0 {
+		t.Errorf("Expected the header's token in the context, got %d", seen)
+	}
+
+	seen = 0
+	req = httptest.NewRequest(http.MethodGet, "/read", nil)
+	req.AddCookie(&http.Cookie{Name: TokenCookie, Value: "0/1B0"})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if seen != 0x1B0 {
+		t.Errorf("Expected the cookie's token in the context, got %d", seen)
+	}
+
+	seen = 0
+	req = httptest.NewRequest(http.MethodGet, "/read", nil)
+	req.Header.Set(TokenHeader, "garbage")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if seen != 0 {
+		t.Errorf("Expected an invalid token to be ignored, got %d", seen)
+	}
+}
@@ -0,0 +1,112 @@
+package consistency
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenHeader carries the consistency token, in responses to writes and
+// in requests that must see them. Browsers get it as TokenCookie too.
+const (
+	TokenHeader = "X-Consistency-Token"
+	TokenCookie = "consistency_token"
+)
+
+// tokenMaxAge bounds how long browsers send the cookie; followers have
+// long replayed a write by then, or are too far behind to be used anyway
+const tokenMaxAge = 300
+
+// Middleware reads the client's token, from TokenHeader or TokenCookie,
+// into the request's context, and returns a token in responses to
+// requests whose writes recorded an LSN. Invalid tokens are ignored.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if lsn, ok := requestToken(c.Request); ok {
+			ctx = WithMinLSN(ctx, lsn)
+		}
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+			return
+		}
+
+		ctx = WithTracking(ctx)
+		c.Request = c.Request.WithContext(ctx)
+		writer := &tokenWriter{ResponseWriter: c.Writer, ctx: ctx, secure: isHTTPS(c.Request)}
+		c.Writer = writer
+		c.Next()
+		// Responses without a body, such as 204s, are written after the
+		// middleware returns
+		writer.setToken()
+	}
+}
+
+// requestToken returns the LSN in r's token
+func requestToken(r *http.Request) (LSN, bool) {
+	token := r.Header.Get(TokenHeader)
+	if token == "" {
+		if cookie, err := r.Cookie(TokenCookie); err == nil {
+			token = cookie.Value
+		}
+	}
+	if token == "" {
+		return 0, false
+	}
+	lsn, err := ParseLSN(token)
+	return lsn, err == nil
+}
+
+// isHTTPS reports whether the client connected over TLS, directly or
+// through Heroku's router, which terminates it
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// tokenWriter adds the token to the response headers before they are
+// written
+type tokenWriter struct {
+	gin.ResponseWriter
+	ctx context.Context
+	// secure marks the cookie Secure, for requests made over HTTPS
+	secure bool
+	done   bool
+}
+
+func (w *tokenWriter) setToken() {
+	if w.done || w.ResponseWriter.Written() {
+		return
+	}
+	w.done = true
+	lsn, ok := written(w.ctx)
+	if !ok {
+		return
+	}
+	w.Header().Set(TokenHeader, lsn.String())
+	http.SetCookie(w, &http.Cookie{
+		Name:     TokenCookie,
+		Value:    lsn.String(),
+		Path:     "/",
+		MaxAge:   tokenMaxAge,
+		HttpOnly: true,
+		Secure:   w.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (w *tokenWriter) WriteHeaderNow() {
+	w.setToken()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *tokenWriter) Write(data []byte) (int, error) {
+	w.setToken()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *tokenWriter) WriteString(s string) (int, error) {
+	w.setToken()
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"saas-go-app/internal/repository"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	breakerOpen = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "db_circuit_breaker_open",
+		Help: "Whether each pool's circuit breaker is open, failing queries without connecting.",
+	}, []string{"pool"})
+	breakerRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_circuit_breaker_rejected_total",
+		Help: "Connections refused by each pool's open circuit breaker.",
+	}, []string{"pool"})
+)
+
+// breaker stops a pool from connecting to a database that is unreachable,
+// so requests fail at once instead of each waiting for a connect timeout.
+// It opens after threshold consecutive failures to connect. Once cooldown
+// has passed one connection is tried, closing it again if it succeeds.
+// It is safe for concurrent use.
+type breaker struct {
+	pool      string
+	threshold int
+	cooldown  time.Duration
+	// now returns the current time; tests replace it
+	now func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // zero while closed
+	probing  bool      // a connection is being tried after the cooldown
+}
+
+func newBreaker(pool string, threshold int, cooldown time.Duration) *breaker {
+	breakerOpen.WithLabelValues(pool).Set(0)
+	return &breaker{pool: pool, threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow returns an error wrapping repository.ErrUnavailable unless a
+// connection may be tried now
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return nil
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		breakerRejected.WithLabelValues(b.pool).Inc()
+		return fmt.Errorf("%w: circuit breaker for %s is open", repository.ErrUnavailable, b.pool)
+	}
+	b.probing = true
+	return nil
+}
+
+// record notes the outcome of a connection allowed through. Attempts the
+// caller gave up on count as neither success nor failure.
+func (b *breaker) record(err error, abandoned bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	probe := b.probing
+	b.probing = false
+	switch {
+	case abandoned:
+	case err == nil:
+		if !b.openedAt.IsZero() {
+			slog.Info("Database reachable again, closing circuit breaker", "pool", b.pool)
+			breakerOpen.WithLabelValues(b.pool).Set(0)
+		}
+		b.failures, b.openedAt = 0, time.Time{}
+	case probe:
+		b.openedAt = b.now()
+	default:
+		b.failures++
+		if b.failures >= b.threshold && b.openedAt.IsZero() {
+			slog.Warn("Database unreachable, opening circuit breaker", "pool", b.pool, "failures", b.failures, "cooldown", b.cooldown, "error", err)
+			b.openedAt = b.now()
+			breakerOpen.WithLabelValues(b.pool).Set(1)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"saas-go-app/internal/repository"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	now := time.Now()
+	b := newBreaker("test", 2, 10*time.Second)
+	b.now = func() time.Time { return now }
+	refused := errors.New("connection refused")
+
+	b.record(refused, false)
+	if err := b.allow(); err != nil {
+		t.Fatalf("Expected the breaker to stay closed below the threshold, got %v", err)
+	}
+	b.record(refused, false)
+	if err := b.allow(); !errors.Is(err, repository.ErrUnavailable) {
+		t.Fatalf("Expected the breaker to open at the threshold, got %v", err)
+	}
+	if open := testutil.ToFloat64(breakerOpen.WithLabelValues("test")); open != 1 {
+		t.Errorf("Expected the open breaker to be exported, got %v", open)
+	}
+
+	// After the cooldown a single connection is tried
+	now = now.Add(10 * time.Second)
+	if err := b.allow(); err != nil {
+		t.Fatalf("Expected a connection to be tried after the cooldown, got %v", err)
+	}
+	if err := b.allow(); err == nil {
+		t.Error("Expected only one connection to be tried at once")
+	}
+	b.record(refused, false)
+	if err := b.allow(); err == nil {
+		t.Error("Expected a failed try to open the breaker for another cooldown")
+	}
+
+	now = now.Add(10 * time.Second)
+	if err := b.allow(); err != nil {
+		t.Fatalf("Expected a connection to be tried after the cooldown, got %v", err)
+	}
+	b.record(nil, false)
+	if err := b.allow(); err != nil {
+		t.Errorf("Expected a successful try to close the breaker, got %v", err)
+	}
+}
+
+func TestPoolFailsFastWhenUnreachable(t *testing.T) {
+	opts := testPoolOptions
+	opts.BreakerThreshold = 1
+	conn, err := open("unreachable", "postgres://localhost:1/app?sslmode=disable", opts)
+	if err != nil {
+		t.Fatalf("Failed to open pool: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.PingContext(context.Background()); err == nil || errors.Is(err, repository.ErrUnavailable) {
+		t.Fatalf("Expected the first ping to try connecting, got %v", err)
+	}
+	if err := conn.PingContext(context.Background()); !errors.Is(err, repository.ErrUnavailable) {
+		t.Errorf("Expected the open breaker to fail the ping, got %v", err)
+	}
+}
@@ -1,171 +1,198 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
-	"log"
-	"os"
-	"strings"
+	"log/slog"
+	"sort"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/XSAM/otelsql"
+	"github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 )
 
 var (
 	// PrimaryDB is the primary database connection
 	PrimaryDB *sql.DB
 	
-	// AnalyticsDB is the follower pool connection for analytics
-	AnalyticsDB *sql.DB
+	// Followers are the read-only pools replicating from the primary
+	Followers []Pool
+
+	// Analytics routes analytics reads between the followers and the
+	// primary
+	Analytics *ReadRouter
 )
 
-// InitPrimaryDB initializes the primary database connection
-// For Next Gen Postgres Advanced, checks for HEROKU_POSTGRESQL_*_URL first
-// Falls back to DATABASE_URL if not found
-func InitPrimaryDB() error {
-	// Check for Next Gen Postgres Advanced connection string first
-	// Heroku creates config vars like HEROKU_POSTGRESQL_PURPLE_URL for NGPG databases
-	var databaseURL string
-	envVars := os.Environ()
-	for _, envVar := range envVars {
-		if len(envVar) > 20 && envVar[:20] == "HEROKU_POSTGRESQL_" {
-			// Check if it's a URL (not a color name like HEROKU_POSTGRESQL_PURPLE)
-			if len(envVar) > 24 && envVar[len(envVar)-4:] == "_URL" {
-				parts := strings.SplitN(envVar, "=", 2)
-				if len(parts) == 2 && strings.HasPrefix(parts[1], "postgres://") {
-					databaseURL = parts[1]
-					log.Printf("Using Next Gen Postgres Advanced connection: %s", parts[0])
-					break
-				}
-			}
-		}
+// PoolOptions configure a pool's limits, the statement timeout of its
+// connections and its circuit breaker
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// StatementTimeout is set on each connection; 0 leaves the server's
+	StatementTimeout time.Duration
+	// ConnectTimeout bounds opening a connection
+	ConnectTimeout time.Duration
+	// BreakerThreshold consecutive failures to connect fail the pool's
+	// queries for BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// open opens a pool whose statements are traced, each span annotated with
+// the pool's name, so spans show whether a query went to the primary or
+// the follower
+func open(pool, databaseURL string, opts PoolOptions) (*sql.DB, error) {
+	base, err := pq.NewConnector(databaseURL)
+	if err != nil {
+		return nil, err
 	}
-	
-	// Fall back to DATABASE_URL if no NGPG connection found
+	conn := otelsql.OpenDB(&connector{
+		Connector:        base,
+		breaker:          newBreaker(pool, opts.BreakerThreshold, opts.BreakerCooldown),
+		statementTimeout: opts.StatementTimeout,
+		connectTimeout:   opts.ConnectTimeout,
+	},
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBClientConnectionPoolName(pool)),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitRows:             true,
+		}),
+	)
+	conn.SetMaxOpenConns(opts.MaxOpenConns)
+	conn.SetMaxIdleConns(opts.MaxIdleConns)
+	conn.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	conn.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	return conn, nil
+}
+
+// InitPrimaryDB initializes the primary database connection
+func InitPrimaryDB(ctx context.Context, databaseURL string, opts PoolOptions) error {
 	if databaseURL == "" {
-		databaseURL = os.Getenv("DATABASE_URL")
-		if databaseURL == "" {
-			return fmt.Errorf("DATABASE_URL environment variable is not set")
-		}
+		return fmt.Errorf("DATABASE_URL is not set")
 	}
 
 	var err error
-	PrimaryDB, err = sql.Open("postgres", databaseURL)
+	PrimaryDB, err = open(PrimaryPool, databaseURL, opts)
 	if err != nil {
 		return fmt.Errorf("failed to open primary database: %w", err)
 	}
 
-	if err := PrimaryDB.Ping(); err != nil {
+	if err := PrimaryDB.PingContext(ctx); err != nil {
 		return fmt.Errorf("failed to ping primary database: %w", err)
 	}
 
-	log.Println("Primary database connection established")
+	slog.Info("Primary database connection established")
 	return nil
 }
 
-// InitAnalyticsDB initializes the analytics database connection (follower pool)
-// It checks for ANALYTICS_DB_URL first, then looks for Heroku Postgres follower pool URLs
-// 
-// With Heroku Postgres Advanced (Next Generation), if ANALYTICS_DB_URL is not set,
-// the application will use PrimaryDB for analytics. If the DATABASE_URL connection
-// has automatic read routing configured, the database will automatically route
-// read queries to the follower pool.
-func InitAnalyticsDB() error {
-	// First, check for explicit ANALYTICS_DB_URL
-	analyticsURL := os.Getenv("ANALYTICS_DB_URL")
-	
-	// If not set, check for Heroku Postgres follower pool URL pattern
-	// Heroku creates config vars like HEROKU_POSTGRESQL_PURPLE_FOLLOWER_URL
-	if analyticsURL == "" {
-		// Check all environment variables for Heroku Postgres follower URLs
-		envVars := os.Environ()
-		for _, envVar := range envVars {
-			// Look for patterns like HEROKU_POSTGRESQL_*_FOLLOWER_URL
-			if len(envVar) > 30 && envVar[:20] == "HEROKU_POSTGRESQL_" {
-				// Check if it contains FOLLOWER_URL
-				// Heroku follower pool URLs typically end with _FOLLOWER_URL
-				// But they might also be in the format HEROKU_POSTGRESQL_COLOR_URL where COLOR is the addon color
-				// For follower pools, we need to check the Heroku dashboard or use heroku pg:info
-				// For now, we'll check for explicit ANALYTICS_DB_URL or fall back to primary
-			}
-		}
+// InitFollowers opens a pool to each follower in urls, by name, and
+// routes analytics reads between them and the primary as routing says.
+// Each follower's pool is configured by pool.
+// Followers that do not answer are kept, counting as down until they do.
+//
+// Without followers analytics use the primary. With Heroku Postgres
+// Advanced automatic routing on DATABASE_URL, reads on the primary's URL
+// may still be served by the follower pool.
+func InitFollowers(ctx context.Context, urls map[string]string, pool PoolOptions, routing RoutingOptions) error {
+	names := make([]string, 0, len(urls))
+	for name := range urls {
+		names = append(names, name)
 	}
-	
-	if analyticsURL == "" {
-		log.Println("ANALYTICS_DB_URL not set, analytics endpoints will use primary DB connection")
-		log.Println("With Heroku Postgres Advanced, if DATABASE_URL has automatic routing configured,")
-		log.Println("read queries will be automatically routed to the follower pool.")
-		log.Println("To use explicit follower pool routing: Set ANALYTICS_DB_URL to the follower pool connection string")
-		log.Println("Get the follower URL from: Heroku Dashboard → Postgres addon → Follower Pool → Connection String")
-		AnalyticsDB = PrimaryDB
-		return nil
-	}
-
-	var err error
-	AnalyticsDB, err = sql.Open("postgres", analyticsURL)
-	if err != nil {
-		return fmt.Errorf("failed to open analytics database: %w", err)
+	sort.Strings(names)
+
+	var pools []Pool
+	var errs []error
+	for _, name := range names {
+		conn, err := open(name, urls[name], pool)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to open follower %s: %w", name, err))
+			continue
+		}
+		if err := conn.PingContext(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to ping follower %s: %w", name, err))
+		}
+		pools = append(pools, Pool{Name: name, DB: conn})
 	}
-
-	if err := AnalyticsDB.Ping(); err != nil {
-		return fmt.Errorf("failed to ping analytics database: %w", err)
+	Followers = pools
+
+	if len(pools) == 0 {
+		slog.Info("No follower configured, analytics endpoints will use primary DB connection",
+			"hint", "Set ANALYTICS_DB_URL to the follower pool connection string from "+
+				"Heroku Dashboard → Postgres addon → Follower Pool → Connection String; "+
+				"HEROKU_POSTGRESQL_<COLOR>_FOLLOWER_URL variables are picked up too")
+	} else {
+		slog.Info("Routing analytics reads to followers", "followers", names, "max_staleness", routing.MaxStaleness)
 	}
 
-	log.Println("Analytics database connection established (using explicit follower pool connection)")
-	return nil
+	Analytics = NewReadRouter(PrimaryDB, pools, routing)
+	refreshCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	Analytics.Refresh(refreshCtx)
+	return errors.Join(errs...)
 }
 
-// CloseDB closes all database connections
+// CloseDB closes all database connections, the followers before the
+// primary they replicate from. Close waits for queries in progress.
 func CloseDB() {
-	if PrimaryDB != nil {
-		PrimaryDB.Close()
+	for _, follower := range Followers {
+		if err := follower.DB.Close(); err != nil {
+			slog.Warn("Failed to close follower database", "pool", follower.Name, "error", err)
+		}
 	}
-	if AnalyticsDB != nil && AnalyticsDB != PrimaryDB {
-		AnalyticsDB.Close()
+	Followers = nil
+	if PrimaryDB != nil {
+		if err := PrimaryDB.Close(); err != nil {
+			slog.Warn("Failed to close primary database", "error", err)
+		}
 	}
 }
 
-// CreateTables creates the necessary database tables
-func CreateTables() error {
-	customersTable := `
-	CREATE TABLE IF NOT EXISTS customers (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(255) NOT NULL,
-		email VARCHAR(255) NOT NULL UNIQUE,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	accountsTable := `
-	CREATE TABLE IF NOT EXISTS accounts (
-		id SERIAL PRIMARY KEY,
-		customer_id INTEGER NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
-		name VARCHAR(255) NOT NULL,
-		status VARCHAR(50) NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	usersTable := `
-	CREATE TABLE IF NOT EXISTS users (
-		id SERIAL PRIMARY KEY,
-		username VARCHAR(255) NOT NULL UNIQUE,
-		password_hash VARCHAR(255) NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	if _, err := PrimaryDB.Exec(customersTable); err != nil {
-		return fmt.Errorf("failed to create customers table: %w", err)
-	}
 
-	if _, err := PrimaryDB.Exec(accountsTable); err != nil {
-		return fmt.Errorf("failed to create accounts table: %w", err)
-	}
+// connector opens connections through the pool's circuit breaker, within
+// the connect timeout, and sets their statement timeout
+type connector struct {
+	driver.Connector
+	breaker          *breaker
+	statementTimeout time.Duration
+	connectTimeout   time.Duration
+}
 
-	if _, err := PrimaryDB.Exec(usersTable); err != nil {
-		return fmt.Errorf("failed to create users table: %w", err)
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	connectCtx, cancel := context.WithTimeout(ctx, c.connectTimeout)
+	defer cancel()
+
+	conn, err := c.Connector.Connect(connectCtx)
+	if err == nil && c.statementTimeout > 0 {
+		if err = setStatementTimeout(connectCtx, conn, c.statementTimeout); err != nil {
+			conn.Close()
+			conn = nil
+		}
 	}
+	// A caller that gave up says nothing about the database
+	c.breaker.record(err, ctx.Err() != nil)
+	return conn, err
+}
 
-	log.Println("Database tables created successfully")
+// setStatementTimeout makes the server cancel statements on conn that run
+// longer than timeout
+func setStatementTimeout(ctx context.Context, conn driver.Conn, timeout time.Duration) error {
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		return fmt.Errorf("driver connection %T cannot set statement_timeout", conn)
+	}
+	query := fmt.Sprintf("SET statement_timeout = %d", timeout.Milliseconds())
+	if _, err := execer.ExecContext(ctx, query, nil); err != nil {
+		return fmt.Errorf("failed to set statement_timeout: %w", err)
+	}
 	return nil
 }
-
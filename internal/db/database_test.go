@@ -1,17 +1,31 @@
 package db
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 )
 
+// testPoolOptions are the defaults of the configuration
+var testPoolOptions = PoolOptions{
+	MaxOpenConns:     20,
+	MaxIdleConns:     10,
+	ConnMaxLifetime:  30 * time.Minute,
+	ConnMaxIdleTime:  5 * time.Minute,
+	StatementTimeout: 30 * time.Second,
+	ConnectTimeout:   5 * time.Second,
+	BreakerThreshold: 5,
+	BreakerCooldown:  10 * time.Second,
+}
+
 func TestInitPrimaryDB(t *testing.T) {
 	// Skip if DATABASE_URL is not set
 	if os.Getenv("DATABASE_URL") == "" {
 		t.Skip("DATABASE_URL not set, skipping database test")
 	}
 
-	err := InitPrimaryDB()
+	err := InitPrimaryDB(context.Background(), os.Getenv("DATABASE_URL"), testPoolOptions)
 	if err != nil {
 		t.Fatalf("Failed to initialize primary database: %v", err)
 	}
@@ -28,21 +42,27 @@ func TestInitPrimaryDB(t *testing.T) {
 	CloseDB()
 }
 
-func TestCreateTables(t *testing.T) {
+func TestRunMigrations(t *testing.T) {
 	// Skip if DATABASE_URL is not set
 	if os.Getenv("DATABASE_URL") == "" {
 		t.Skip("DATABASE_URL not set, skipping database test")
 	}
 
-	err := InitPrimaryDB()
+	err := InitPrimaryDB(context.Background(), os.Getenv("DATABASE_URL"), testPoolOptions)
 	if err != nil {
 		t.Fatalf("Failed to initialize primary database: %v", err)
 	}
 	defer CloseDB()
 
-	err = CreateTables()
+	err = RunMigrations(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	// Running again must be a no-op
+	err = RunMigrations(context.Background())
 	if err != nil {
-		t.Fatalf("Failed to create tables: %v", err)
+		t.Fatalf("Failed to re-run migrations: %v", err)
 	}
 }
 
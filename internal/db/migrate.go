@@ -0,0 +1,432 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"saas-go-app/internal/db/migrations"
+)
+
+// migrationLockID is the Postgres advisory lock key held while migrations run,
+// so that several dynos booting at once apply each migration exactly once
+const migrationLockID int64 = 7_270_310_001
+
+// Migration is a single versioned schema change with its up and down SQL
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Migrator applies and rolls back migrations against a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the migrations found in fsys
+func NewMigrator(conn *sql.DB, fsys fs.FS) (*Migrator, error) {
+	loaded, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: conn, migrations: loaded}, nil
+}
+
+// RunMigrations applies all pending embedded migrations to the primary database
+func RunMigrations(ctx context.Context) error {
+	migrator, err := NewMigrator(PrimaryDB, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Database migrations up to date", "applied", applied)
+	return nil
+}
+
+// LoadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs from fsys
+// and returns them sorted by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, file := range files {
+		version, name, direction, err := parseMigrationFilename(path.Base(file))
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", file, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(contents)
+		} else {
+			m.Down = string(contents)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no down script", m.Version, m.Name)
+		}
+		result = append(result, *m)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// parseMigrationFilename splits "0001_create_tables.up.sql" into its parts
+func parseMigrationFilename(filename string) (int64, string, string, error) {
+	base := strings.TrimSuffix(filename, ".sql")
+
+	var direction string
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		direction = "up"
+	case strings.HasSuffix(base, ".down"):
+		direction = "down"
+	default:
+		return 0, "", "", fmt.Errorf("migration %s must end in .up.sql or .down.sql", filename)
+	}
+	base = strings.TrimSuffix(base, "."+direction)
+
+	versionPart, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", "", fmt.Errorf("migration %s must be named NNNN_description.%s.sql", filename, direction)
+	}
+
+	version, err := strconv.ParseInt(versionPart, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("migration %s has an invalid version %q", filename, versionPart)
+	}
+
+	return version, name, direction, nil
+}
+
+// Up applies every pending migration in order and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the n most recently applied migrations and returns how many were rolled back
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("number of migrations to roll back must be positive, got %d", n)
+	}
+
+	rolledBack := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(done))
+		for version := range done {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, version := range versions {
+			if rolledBack == n {
+				break
+			}
+			migration, ok := m.find(version)
+			if !ok {
+				return fmt.Errorf("migration %d is applied but its files are missing from this build", version)
+			}
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Redo rolls back the most recently applied migration and applies it
+// again, holding the lock throughout so no other migrator runs in between
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		var latest int64
+		for version := range done {
+			latest = max(latest, version)
+		}
+		if latest == 0 {
+			return fmt.Errorf("no applied migrations to redo")
+		}
+		migration, ok := m.find(latest)
+		if !ok {
+			return fmt.Errorf("migration %d is applied but its files are missing from this build", latest)
+		}
+
+		if err := m.apply(ctx, conn, migration, false); err != nil {
+			return err
+		}
+		return m.apply(ctx, conn, migration, true)
+	})
+}
+
+// Status lists every known migration with its applied state. It only
+// reads: on a database never migrated every migration is pending.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	done, err := readAppliedVersions(ctx, conn)
+	if errors.Is(err, ErrNotMigrated) {
+		done, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := done[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// ErrNotMigrated is returned by Pending for a database no migration has
+// run against
+var ErrNotMigrated = errors.New("database not migrated")
+
+// Pending returns the number of known migrations not yet applied. Unlike
+// Status it only reads, so it suits frequent checks.
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	done, err := readAppliedVersions(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, migration := range m.migrations {
+		if _, ok := done[migration.Version]; !ok {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory
+// lock. Waiting for the lock is bounded by ctx alone: another instance may
+// hold it for as long as its migrations take.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	var timeout string
+	if err := conn.QueryRowContext(ctx, "SHOW statement_timeout").Scan(&timeout); err != nil {
+		return fmt.Errorf("failed to read statement timeout: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "SET statement_timeout = 0"); err != nil {
+		return fmt.Errorf("failed to lift statement timeout: %w", err)
+	}
+	defer func() {
+		// The connection goes back to the pool, which expects its timeout;
+		// discard it rather than return it without one
+		if _, err := conn.ExecContext(context.Background(), "SELECT set_config('statement_timeout', $1, false)", timeout); err != nil {
+			slog.Warn("Failed to restore statement timeout, discarding connection", "error", err)
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Use a fresh context so the lock is released even if ctx was cancelled
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+			slog.Warn("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// apply runs a single migration in one transaction and records the result
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	direction, script := "up", migration.Up
+	if !up {
+		direction, script = "down", migration.Down
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", migration.Version, err)
+	}
+	defer tx.Rollback()
+
+	// Schema changes may take longer than the pool's statement timeout, and
+	// data changes span every organization
+	if _, err := tx.ExecContext(ctx, "SET LOCAL statement_timeout = 0"); err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", migration.Version, err)
+	}
+	if _, err := tx.ExecContext(ctx, "SET LOCAL app.bypass_rls = 'on'"); err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", migration.Version, err)
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s (%s) failed: %w", migration.Version, migration.Name, direction, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+			migration.Version, migration.Name,
+		)
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", migration.Version, err)
+	}
+
+	slog.Info("Migrated "+direction, "version", migration.Version, "name", migration.Name)
+	return nil
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// readAppliedVersions is appliedVersions for callers that must not create
+// schema_migrations; it returns ErrNotMigrated when the table is missing
+func readAppliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	var exists bool
+	if err := conn.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to look up schema_migrations: %w", err)
+	}
+	if !exists {
+		return nil, ErrNotMigrated
+	}
+	return appliedVersions(ctx, conn)
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		done[version] = appliedAt
+	}
+	return done, rows.Err()
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"saas-go-app/internal/db/migrations"
+)
+
+func TestLoadMigrationsSortsByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":      {Data: []byte("CREATE INDEX idx ON t (c);")},
+		"0002_add_index.down.sql":    {Data: []byte("DROP INDEX idx;")},
+		"0001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (c INT);")},
+		"0001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+	}
+
+	loaded, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	if len(loaded) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(loaded))
+	}
+	if loaded[0].Version != 1 || loaded[0].Name != "create_table" {
+		t.Errorf("Unexpected first migration: %d_%s", loaded[0].Version, loaded[0].Name)
+	}
+	if loaded[1].Version != 2 || loaded[1].Down != "DROP INDEX idx;" {
+		t.Errorf("Unexpected second migration: %+v", loaded[1])
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"missing down": {
+			"0001_create.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"bad version": {
+			"abc_create.up.sql":   {Data: []byte("SELECT 1;")},
+			"abc_create.down.sql": {Data: []byte("SELECT 1;")},
+		},
+		"no direction": {
+			"0001_create.sql": {Data: []byte("SELECT 1;")},
+		},
+		"duplicate version": {
+			"0001_one.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_one.down.sql": {Data: []byte("SELECT 1;")},
+			"0001_two.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_two.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+
+	for name, fsys := range cases {
+		if _, err := LoadMigrations(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	loaded, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("Failed to load embedded migrations: %v", err)
+	}
+
+	if len(loaded) == 0 {
+		t.Fatal("No embedded migrations found")
+	}
+	if loaded[0].Version != 1 {
+		t.Errorf("Expected first migration to be version 1, got %d", loaded[0].Version)
+	}
+}
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_accounts_created_at_id;
+DROP INDEX IF EXISTS idx_accounts_status;
+DROP INDEX IF EXISTS idx_accounts_customer_id;
+
+DROP INDEX IF EXISTS idx_customers_created_at_id;
+DROP INDEX IF EXISTS idx_customers_email_trgm;
+DROP INDEX IF EXISTS idx_customers_name_trgm;
+
+-- pg_trgm is left installed; other objects may depend on it
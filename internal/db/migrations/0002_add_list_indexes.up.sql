@@ -0,0 +1,10 @@
+-- Trigram indexes back the fuzzy ?q= customer search (ILIKE and similarity)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_customers_name_trgm ON customers USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_customers_email_trgm ON customers USING gin (email gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_customers_created_at_id ON customers (created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_accounts_customer_id ON accounts (customer_id);
+CREATE INDEX IF NOT EXISTS idx_accounts_status ON accounts (status);
+CREATE INDEX IF NOT EXISTS idx_accounts_created_at_id ON accounts (created_at, id);
@@ -0,0 +1,18 @@
+DROP POLICY IF EXISTS accounts_org_isolation ON accounts;
+ALTER TABLE accounts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE accounts DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS customers_org_isolation ON customers;
+ALTER TABLE customers NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE customers DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS idx_accounts_org_created_at_id;
+DROP INDEX IF EXISTS idx_customers_org_created_at_id;
+DROP INDEX IF EXISTS idx_customers_org_email;
+ALTER TABLE customers ADD CONSTRAINT customers_email_key UNIQUE (email);
+
+ALTER TABLE accounts DROP COLUMN org_id;
+ALTER TABLE customers DROP COLUMN org_id;
+
+DROP TABLE organization_members;
+DROP TABLE organizations;
@@ -0,0 +1,51 @@
+CREATE TABLE organizations (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE organization_members (
+	organization_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX idx_organization_members_user_id ON organization_members (user_id);
+
+-- Existing rows and users move into a single default organization
+INSERT INTO organizations (name)
+SELECT 'Default Organization'
+WHERE EXISTS (SELECT 1 FROM customers) OR EXISTS (SELECT 1 FROM users);
+
+INSERT INTO organization_members (organization_id, user_id)
+SELECT (SELECT MIN(id) FROM organizations), id FROM users;
+
+ALTER TABLE customers ADD COLUMN org_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE customers SET org_id = (SELECT MIN(id) FROM organizations);
+ALTER TABLE customers ALTER COLUMN org_id SET NOT NULL;
+
+ALTER TABLE accounts ADD COLUMN org_id INTEGER REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE accounts SET org_id = customers.org_id FROM customers WHERE customers.id = accounts.customer_id;
+ALTER TABLE accounts ALTER COLUMN org_id SET NOT NULL;
+
+-- Emails only need to be unique within an organization
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_email_key;
+CREATE UNIQUE INDEX idx_customers_org_email ON customers (org_id, email);
+CREATE INDEX idx_customers_org_created_at_id ON customers (org_id, created_at, id);
+CREATE INDEX idx_accounts_org_created_at_id ON accounts (org_id, created_at, id);
+
+-- Row-level security. Request-scoped transactions set app.org_id and can
+-- only see their own organization's rows. Connections that never set it
+-- (migrations, seeding, background aggregation) see every row.
+ALTER TABLE customers ENABLE ROW LEVEL SECURITY;
+ALTER TABLE customers FORCE ROW LEVEL SECURITY;
+CREATE POLICY customers_org_isolation ON customers
+	USING (COALESCE(current_setting('app.org_id', true), '') = '' OR org_id = current_setting('app.org_id', true)::integer)
+	WITH CHECK (COALESCE(current_setting('app.org_id', true), '') = '' OR org_id = current_setting('app.org_id', true)::integer);
+
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE accounts FORCE ROW LEVEL SECURITY;
+CREATE POLICY accounts_org_isolation ON accounts
+	USING (COALESCE(current_setting('app.org_id', true), '') = '' OR org_id = current_setting('app.org_id', true)::integer)
+	WITH CHECK (COALESCE(current_setting('app.org_id', true), '') = '' OR org_id = current_setting('app.org_id', true)::integer);
@@ -0,0 +1,2 @@
+ALTER TABLE organization_members DROP CONSTRAINT IF EXISTS organization_members_role_check;
+ALTER TABLE organization_members DROP COLUMN role;
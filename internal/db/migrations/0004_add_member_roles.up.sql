@@ -0,0 +1,5 @@
+-- Existing members keep the unrestricted access they had before roles existed
+ALTER TABLE organization_members ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'owner';
+ALTER TABLE organization_members ALTER COLUMN role SET DEFAULT 'member';
+ALTER TABLE organization_members ADD CONSTRAINT organization_members_role_check
+	CHECK (role IN ('owner', 'admin', 'member', 'read_only'));
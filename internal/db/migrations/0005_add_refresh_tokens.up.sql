@@ -0,0 +1,21 @@
+-- Refresh tokens are stored as SHA-256 hashes. Every rotation stays in the
+-- family of the login that started it, so reuse can revoke the whole chain.
+CREATE TABLE refresh_tokens (
+	id BIGSERIAL PRIMARY KEY,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	family_id VARCHAR(64) NOT NULL,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	org_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	expires_at TIMESTAMP NOT NULL,
+	used_at TIMESTAMP,
+	revoked_at TIMESTAMP,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+-- Access tokens revoked before they expire, keyed by their jti claim
+CREATE TABLE revoked_tokens (
+	jti VARCHAR(64) PRIMARY KEY,
+	expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,8 @@
+-- JWT signing keys shared by every instance. The newest key signs; older
+-- ones stay here, and in the JWKS, until tokens they signed have expired.
+CREATE TABLE signing_keys (
+	kid VARCHAR(64) PRIMARY KEY,
+	algorithm VARCHAR(16) NOT NULL,
+	private_key TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
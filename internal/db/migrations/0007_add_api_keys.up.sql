@@ -0,0 +1,17 @@
+-- API keys for machine-to-machine access. The prefix is stored in clear to
+-- find the key and show it in listings; only a hash of the full key is kept.
+CREATE TABLE api_keys (
+	id SERIAL PRIMARY KEY,
+	org_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL,
+	prefix VARCHAR(16) NOT NULL UNIQUE,
+	key_hash CHAR(64) NOT NULL,
+	scopes TEXT[] NOT NULL,
+	created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	last_used_at TIMESTAMP,
+	expires_at TIMESTAMP,
+	revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_api_keys_org_id ON api_keys (org_id);
@@ -0,0 +1,19 @@
+-- Daily aggregates written by the aggregate:data job. One row per
+-- organization, metric, dimension and day; reruns overwrite the value.
+CREATE TABLE daily_metrics (
+	metric_date DATE NOT NULL,
+	org_id INTEGER NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+	metric VARCHAR(64) NOT NULL,
+	dimension VARCHAR(64) NOT NULL DEFAULT 'all',
+	value BIGINT NOT NULL,
+	computed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (org_id, metric, dimension, metric_date)
+);
+
+CREATE INDEX idx_daily_metrics_org_metric_date ON daily_metrics (org_id, metric, metric_date);
+
+ALTER TABLE daily_metrics ENABLE ROW LEVEL SECURITY;
+ALTER TABLE daily_metrics FORCE ROW LEVEL SECURITY;
+CREATE POLICY daily_metrics_org_isolation ON daily_metrics
+	USING (COALESCE(current_setting('app.org_id', true), '') = '' OR org_id = current_setting('app.org_id', true)::integer)
+	WITH CHECK (COALESCE(current_setting('app.org_id', true), '') = '' OR org_id = current_setting('app.org_id', true)::integer);
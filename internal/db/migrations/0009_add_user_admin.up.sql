@@ -0,0 +1,3 @@
+-- System administrators manage the application itself (background jobs,
+-- schedules) rather than an organization's data
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,14 @@
+DROP POLICY daily_metrics_org_isolation ON daily_metrics;
+CREATE POLICY daily_metrics_org_isolation ON daily_metrics
+	USING (COALESCE(current_setting('app.org_id', true), '') = '' OR org_id = current_setting('app.org_id', true)::integer)
+	WITH CHECK (COALESCE(current_setting('app.org_id', true), '') = '' OR org_id = current_setting('app.org_id', true)::integer);
+
+DROP POLICY accounts_org_isolation ON accounts;
+CREATE POLICY accounts_org_isolation ON accounts
+	USING (COALESCE(current_setting('app.org_id', true), '') = '' OR org_id = current_setting('app.org_id', true)::integer)
+	WITH CHECK (COALESCE(current_setting('app.org_id', true), '') = '' OR org_id = current_setting('app.org_id', true)::integer);
+
+DROP POLICY customers_org_isolation ON customers;
+CREATE POLICY customers_org_isolation ON customers
+	USING (COALESCE(current_setting('app.org_id', true), '') = '' OR org_id = current_setting('app.org_id', true)::integer)
+	WITH CHECK (COALESCE(current_setting('app.org_id', true), '') = '' OR org_id = current_setting('app.org_id', true)::integer);
@@ -0,0 +1,18 @@
+-- Tenant isolation fails closed: a transaction that set neither app.org_id
+-- nor app.bypass_rls sees no rows instead of every organization's. Work
+-- that spans organizations (migrations, seeding, daily aggregation) sets
+-- app.bypass_rls to 'on' explicitly.
+DROP POLICY customers_org_isolation ON customers;
+CREATE POLICY customers_org_isolation ON customers
+	USING (current_setting('app.bypass_rls', true) = 'on' OR org_id = NULLIF(current_setting('app.org_id', true), '')::integer)
+	WITH CHECK (current_setting('app.bypass_rls', true) = 'on' OR org_id = NULLIF(current_setting('app.org_id', true), '')::integer);
+
+DROP POLICY accounts_org_isolation ON accounts;
+CREATE POLICY accounts_org_isolation ON accounts
+	USING (current_setting('app.bypass_rls', true) = 'on' OR org_id = NULLIF(current_setting('app.org_id', true), '')::integer)
+	WITH CHECK (current_setting('app.bypass_rls', true) = 'on' OR org_id = NULLIF(current_setting('app.org_id', true), '')::integer);
+
+DROP POLICY daily_metrics_org_isolation ON daily_metrics;
+CREATE POLICY daily_metrics_org_isolation ON daily_metrics
+	USING (current_setting('app.bypass_rls', true) = 'on' OR org_id = NULLIF(current_setting('app.org_id', true), '')::integer)
+	WITH CHECK (current_setting('app.bypass_rls', true) = 'on' OR org_id = NULLIF(current_setting('app.org_id', true), '')::integer);
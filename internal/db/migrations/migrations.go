@@ -0,0 +1,13 @@
+// Package migrations embeds the versioned SQL schema migrations.
+//
+// Each migration is a pair of files named NNNN_description.up.sql and
+// NNNN_description.down.sql. Versions are applied in ascending order and
+// must never be renumbered once released.
+package migrations
+
+import "embed"
+
+// FS contains every *.sql migration file shipped with the binary
+//
+//go:embed *.sql
+var FS embed.FS
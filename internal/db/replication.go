@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"saas-go-app/internal/consistency"
+)
+
+// replicationLagQuery measures how far a follower's replay is behind the
+// primary. A follower that has replayed everything it received is not
+// lagging, however long ago the primary last wrote; a primary never is.
+const replicationLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() THEN 0
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`
+
+// replayedLSNQuery returns how far a follower has replayed the primary's
+// WAL, or the primary's own position
+const replayedLSNQuery = `
+	SELECT (CASE WHEN pg_is_in_recovery() THEN pg_last_wal_replay_lsn() ELSE pg_current_wal_lsn() END)::text`
+
+// ReplicationLag returns how far the database behind conn lags behind
+// the primary, zero for the primary itself
+func ReplicationLag(ctx context.Context, conn *sql.DB) (time.Duration, error) {
+	var seconds float64
+	if err := conn.QueryRowContext(ctx, replicationLagQuery).Scan(&seconds); err != nil {
+		return 0, fmt.Errorf("failed to measure replication lag: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// ReplayedLSN returns the WAL position the database behind conn has
+// replayed up to, its current position for the primary
+func ReplayedLSN(ctx context.Context, conn *sql.DB) (consistency.LSN, error) {
+	var position sql.NullString
+	if err := conn.QueryRowContext(ctx, replayedLSNQuery).Scan(&position); err != nil {
+		return 0, fmt.Errorf("failed to read replayed WAL position: %w", err)
+	}
+	// A follower that has not replayed anything yet
+	if !position.Valid {
+		return 0, nil
+	}
+	return consistency.ParseLSN(position.String)
+}
@@ -0,0 +1,250 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"saas-go-app/internal/consistency"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// PrimaryPool names the primary in routing decisions and metrics
+const PrimaryPool = "primary"
+
+// Reasons for a routing decision
+const (
+	routeFresh       = "fresh"        // a follower within the staleness budget
+	routeCaughtUp    = "caught_up"    // a follower, once it replayed the client's writes
+	routeBehindWrite = "behind_write" // no follower replayed the client's writes in time
+	routeLagging     = "lagging"      // every reachable follower is too far behind
+	routeDown        = "unavailable"  // no follower is reachable
+	routeNoFollower  = "no_follower"  // no follower is configured
+)
+
+// replayPollInterval is how often a follower is asked whether it has
+// replayed a client's writes while a read waits for it
+const replayPollInterval = 10 * time.Millisecond
+
+var (
+	readsRouted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_reads_routed_total",
+		Help: "Reads routed between the followers and the primary, by the pool and the reason it was chosen.",
+	}, []string{"pool", "reason"})
+	followerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "db_follower_replication_lag_seconds",
+		Help: "How far each follower's replay was behind the primary when last measured.",
+	}, []string{"pool"})
+	followerUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "db_follower_up",
+		Help: "Whether each follower answered the last lag measurement.",
+	}, []string{"pool"})
+)
+
+// Pool is a named database pool
+type Pool struct {
+	Name string
+	DB   *sql.DB
+}
+
+// RoutingOptions configure a ReadRouter
+type RoutingOptions struct {
+	// MaxStaleness is how far behind the primary a follower may be
+	MaxStaleness time.Duration
+	// ConsistencyWait is how long a read carrying a consistency token
+	// waits for a follower to replay up to it before using the primary
+	ConsistencyWait time.Duration
+}
+
+// follower is a follower pool and its last measured state
+type follower struct {
+	Pool
+	up       bool
+	lag      time.Duration
+	replayed consistency.LSN
+}
+
+// ReadRouter routes reads that tolerate some staleness, such as analytics,
+// to the freshest follower whose replication lag is within a budget, and
+// to the primary when every follower is lagging or down. Lag is measured
+// by Refresh, not on each read. Reads whose context carries a consistency
+// token only use a follower that has replayed up to it, waiting briefly
+// for one. It is safe for concurrent use.
+type ReadRouter struct {
+	primary *sql.DB
+	opts    RoutingOptions
+	// measure and replayedLSN query followers; tests replace them
+	measure     func(ctx context.Context, conn *sql.DB) (time.Duration, error)
+	replayedLSN func(ctx context.Context, conn *sql.DB) (consistency.LSN, error)
+
+	mu        sync.RWMutex
+	followers []*follower
+}
+
+// NewReadRouter returns a router over primary and followers. Followers
+// count as down until the first Refresh.
+func NewReadRouter(primary *sql.DB, followers []Pool, opts RoutingOptions) *ReadRouter {
+	r := &ReadRouter{primary: primary, opts: opts, measure: ReplicationLag, replayedLSN: ReplayedLSN}
+	for _, pool := range followers {
+		r.followers = append(r.followers, &follower{Pool: pool})
+	}
+	return r
+}
+
+// Followers returns the follower pools
+func (r *ReadRouter) Followers() []Pool {
+	pools := make([]Pool, len(r.followers))
+	for i, f := range r.followers {
+		pools[i] = f.Pool
+	}
+	return pools
+}
+
+// Pick returns the pool a read from ctx should run on
+func (r *ReadRouter) Pick(ctx context.Context) *sql.DB {
+	name, conn, reason := r.route(ctx)
+	readsRouted.WithLabelValues(name, reason).Inc()
+	return conn
+}
+
+// route chooses the freshest follower within the budget that has
+// replayed the writes ctx must see, or the primary
+func (r *ReadRouter) route(ctx context.Context) (name string, conn *sql.DB, reason string) {
+	candidates, reason := r.candidates()
+	if len(candidates) == 0 {
+		return PrimaryPool, r.primary, reason
+	}
+	minLSN, ok := consistency.MinLSN(ctx)
+	if !ok {
+		return candidates[0].Name, candidates[0].DB, routeFresh
+	}
+	for _, f := range candidates {
+		if f.replayed >= minLSN {
+			return f.Name, f.DB, routeFresh
+		}
+	}
+	if r.waitForReplay(ctx, candidates[0], minLSN) {
+		return candidates[0].Name, candidates[0].DB, routeCaughtUp
+	}
+	return PrimaryPool, r.primary, routeBehindWrite
+}
+
+// candidates returns a snapshot of the followers within the staleness
+// budget, freshest first, or why there are none
+func (r *ReadRouter) candidates() ([]follower, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.followers) == 0 {
+		return nil, routeNoFollower
+	}
+	var candidates []follower
+	reason := routeDown
+	for _, f := range r.followers {
+		if !f.up {
+			continue
+		}
+		if f.lag > r.opts.MaxStaleness {
+			reason = routeLagging
+			continue
+		}
+		candidates = append(candidates, *f)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].lag < candidates[j].lag })
+	return candidates, reason
+}
+
+// waitForReplay polls f until it has replayed up to lsn, for at most the
+// consistency wait
+func (r *ReadRouter) waitForReplay(ctx context.Context, f follower, lsn consistency.LSN) bool {
+	ctx, cancel := context.WithTimeout(ctx, r.opts.ConsistencyWait)
+	defer cancel()
+	for {
+		replayed, err := r.replayedLSN(ctx, f.DB)
+		if err == nil && replayed >= lsn {
+			r.mu.Lock()
+			for _, current := range r.followers {
+				if current.DB == f.DB {
+					current.replayed = max(current.replayed, replayed)
+				}
+			}
+			r.mu.Unlock()
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(replayPollInterval):
+		}
+	}
+}
+
+// Refresh measures every follower's replication lag
+func (r *ReadRouter) Refresh(ctx context.Context) {
+	type measurement struct {
+		lag      time.Duration
+		replayed consistency.LSN
+		err      error
+	}
+	measured := make([]measurement, len(r.followers))
+	var wg sync.WaitGroup
+	for i, f := range r.followers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Read the position first, so it is no later than the lag
+			replayed, err := r.replayedLSN(ctx, f.DB)
+			if err != nil {
+				measured[i] = measurement{err: err}
+				return
+			}
+			lag, err := r.measure(ctx, f.DB)
+			measured[i] = measurement{lag, replayed, err}
+		}()
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, f := range r.followers {
+		m := measured[i]
+		if m.err != nil {
+			if f.up {
+				slog.WarnContext(ctx, "Follower unavailable, routing its reads elsewhere", "pool", f.Name, "error", m.err)
+			}
+			f.up = false
+			followerUp.WithLabelValues(f.Name).Set(0)
+			continue
+		}
+		if f.up && f.lag <= r.opts.MaxStaleness && m.lag > r.opts.MaxStaleness {
+			slog.WarnContext(ctx, "Follower lagging, routing its reads elsewhere", "pool", f.Name, "lag", m.lag, "max_staleness", r.opts.MaxStaleness)
+		}
+		f.up, f.lag, f.replayed = true, m.lag, max(f.replayed, m.replayed)
+		followerUp.WithLabelValues(f.Name).Set(1)
+		followerLag.WithLabelValues(f.Name).Set(m.lag.Seconds())
+	}
+}
+
+// Run refreshes the followers' state every interval until ctx is done
+func (r *ReadRouter) Run(ctx context.Context, interval time.Duration) {
+	if len(r.followers) == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			measureCtx, cancel := context.WithTimeout(ctx, interval)
+			r.Refresh(measureCtx)
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"saas-go-app/internal/consistency"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestReadRouterPicksFreshestFollower(t *testing.T) {
+	primary, amber, olive := new(sql.DB), new(sql.DB), new(sql.DB)
+	router := NewReadRouter(primary, []Pool{{"amber", amber}, {"olive", olive}}, RoutingOptions{MaxStaleness: 10 * time.Second})
+
+	lags := map[*sql.DB]time.Duration{amber: 2 * time.Second, olive: time.Second}
+	errs := map[*sql.DB]error{}
+	router.measure = func(ctx context.Context, conn *sql.DB) (time.Duration, error) {
+		return lags[conn], errs[conn]
+	}
+	router.replayedLSN = func(ctx context.Context, conn *sql.DB) (consistency.LSN, error) {
+		return 0, errs[conn]
+	}
+	pick := func() *sql.DB { return router.Pick(context.Background()) }
+
+	// Followers count as down until measured
+	if pick() != primary {
+		t.Error("Expected reads to use the primary before the first refresh")
+	}
+
+	router.Refresh(context.Background())
+	if pick() != olive {
+		t.Error("Expected reads to use the freshest follower")
+	}
+
+	errs[olive] = errors.New("connection refused")
+	router.Refresh(context.Background())
+	if pick() != amber {
+		t.Error("Expected reads to skip the follower that is down")
+	}
+
+	lags[amber] = time.Minute
+	router.Refresh(context.Background())
+	before := testutil.ToFloat64(readsRouted.WithLabelValues(PrimaryPool, routeLagging))
+	if pick() != primary {
+		t.Error("Expected reads to fall back to the primary when followers lag")
+	}
+	if got := testutil.ToFloat64(readsRouted.WithLabelValues(PrimaryPool, routeLagging)) - before; got != 1 {
+		t.Errorf("Expected the fallback to be counted, got %v", got)
+	}
+	if lag := testutil.ToFloat64(followerLag.WithLabelValues("amber")); lag != 60 {
+		t.Errorf("Expected amber's lag to be exported, got %v", lag)
+	}
+
+	if NewReadRouter(primary, nil, RoutingOptions{MaxStaleness: time.Second}).Pick(context.Background()) != primary {
+		t.Error("Expected reads to use the primary without followers")
+	}
+}
+
+func TestReadRouterWaitsForClientsWrites(t *testing.T) {
+	primary, amber := new(sql.DB), new(sql.DB)
+	router := NewReadRouter(primary, []Pool{{"amber", amber}}, RoutingOptions{
+		MaxStaleness:    10 * time.Second,
+		ConsistencyWait: 50 * time.Millisecond,
+	})
+
+	var mu sync.Mutex
+	replayed, polls := consistency.LSN(100), 0
+	router.measure = func(ctx context.Context, conn *sql.DB) (time.Duration, error) {
+		return time.Second, nil
+	}
+	router.replayedLSN = func(ctx context.Context, conn *sql.DB) (consistency.LSN, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		return replayed, nil
+	}
+	router.Refresh(context.Background())
+
+	if router.Pick(consistency.WithMinLSN(context.Background(), 100)) != amber {
+		t.Error("Expected a follower that replayed the client's writes to serve them")
+	}
+
+	before := testutil.ToFloat64(readsRouted.WithLabelValues(PrimaryPool, routeBehindWrite))
+	if router.Pick(consistency.WithMinLSN(context.Background(), 200)) != primary {
+		t.Error("Expected the primary to serve reads the follower has not replayed")
+	}
+	if got := testutil.ToFloat64(readsRouted.WithLabelValues(PrimaryPool, routeBehindWrite)) - before; got != 1 {
+		t.Errorf("Expected the fallback to be counted, got %v", got)
+	}
+
+	mu.Lock()
+	replayed, polls = 200, 0
+	mu.Unlock()
+	if router.Pick(consistency.WithMinLSN(context.Background(), 200)) != amber {
+		t.Error("Expected the read to wait for the follower to catch up")
+	}
+	if router.Pick(consistency.WithMinLSN(context.Background(), 200)) != amber || polls != 1 {
+		t.Errorf("Expected the caught up position to be remembered, polled %d times", polls)
+	}
+}
@@ -1,53 +1,87 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
-	"os"
-	"strconv"
 	"time"
 
 	"saas-go-app/internal/auth"
 )
 
 // SeedData populates the database with sample customers and accounts
-func SeedData() error {
+func SeedData(ctx context.Context) error {
+	return acrossTenants(ctx, func(q *sql.Conn) error {
+		return seedData(ctx, q)
+	})
+}
+
+// acrossTenants runs fn on a connection the row-level security policies
+// do not restrict, since seeding spans organizations
+func acrossTenants(ctx context.Context, fn func(q *sql.Conn) error) error {
+	conn, err := PrimaryDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SET app.bypass_rls = 'on'"); err != nil {
+		return fmt.Errorf("failed to bypass tenant isolation: %w", err)
+	}
+	defer func() {
+		// Requests must never get this connection with the bypass still on
+		if _, err := conn.ExecContext(context.Background(), "RESET app.bypass_rls"); err != nil {
+			slog.Warn("Failed to restore tenant isolation, discarding connection", "error", err)
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	return fn(conn)
+}
+
+func seedData(ctx context.Context, q *sql.Conn) error {
 	// Check if data already exists
 	var count int
-	err := PrimaryDB.QueryRow("SELECT COUNT(*) FROM customers").Scan(&count)
+	err := q.QueryRowContext(ctx, "SELECT COUNT(*) FROM customers").Scan(&count)
 	if err != nil {
 		return err
 	}
 	if count > 0 {
-		log.Println("Database already contains data, skipping seed")
+		slog.Info("Database already contains data, skipping seed")
 		return nil
 	}
 
-	log.Println("Seeding database with sample data...")
+	slog.Info("Seeding database with sample data")
 
 	// Create default test user if users table is empty
 	var userCount int
-	err = PrimaryDB.QueryRow("SELECT COUNT(*) FROM users").Scan(&userCount)
+	err = q.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&userCount)
 	if err == nil && userCount == 0 {
 		// Create default test user: admin / admin123
 		passwordHash, err := auth.HashPassword("admin123")
 		if err == nil {
-			_, err = PrimaryDB.Exec(
-				"INSERT INTO users (username, password_hash) VALUES ($1, $2)",
+			_, err = q.ExecContext(ctx,
+				"INSERT INTO users (username, password_hash, is_admin) VALUES ($1, $2, true)",
 				"admin", passwordHash,
 			)
 			if err == nil {
-				log.Println("Created default test user: username='admin', password='admin123'")
+				slog.Info("Created default test user", "username", "admin", "password", "admin123")
 			} else {
-				log.Printf("Warning: Failed to create default user: %v", err)
+				slog.Warn("Failed to create default user", "error", err)
 			}
 		} else {
-			log.Printf("Warning: Failed to hash password for default user: %v", err)
+			slog.Warn("Failed to hash password for default user", "error", err)
 		}
 	}
 
+	orgID, err := ensureDemoOrganization(ctx, q)
+	if err != nil {
+		return err
+	}
+
 	// Sample customers
 	customers := []struct {
 		name  string
@@ -65,15 +99,15 @@ func SeedData() error {
 	// Insert customers
 	for _, customer := range customers {
 		var id int
-		err := PrimaryDB.QueryRow(
-			"INSERT INTO customers (name, email) VALUES ($1, $2) RETURNING id",
-			customer.name, customer.email,
+		err := q.QueryRowContext(ctx,
+			"INSERT INTO customers (org_id, name, email) VALUES ($1, $2, $3) RETURNING id",
+			orgID, customer.name, customer.email,
 		).Scan(&id)
 		if err != nil {
 			return err
 		}
 		customerIDs = append(customerIDs, id)
-		log.Printf("Created customer: %s (ID: %d)", customer.name, id)
+		slog.Debug("Created customer", "name", customer.name, "id", id)
 	}
 
 	// Sample accounts linked to customers
@@ -104,24 +138,38 @@ func SeedData() error {
 	for _, account := range accounts {
 		customerID := customerIDs[account.customerIndex]
 		var id int
-		err := PrimaryDB.QueryRow(
-			"INSERT INTO accounts (customer_id, name, status) VALUES ($1, $2, $3) RETURNING id",
-			customerID, account.name, account.status,
+		err := q.QueryRowContext(ctx,
+			"INSERT INTO accounts (org_id, customer_id, name, status) VALUES ($1, $2, $3, $4) RETURNING id",
+			orgID, customerID, account.name, account.status,
 		).Scan(&id)
 		if err != nil {
 			return err
 		}
-		log.Printf("Created account: %s (ID: %d) for customer ID: %d", account.name, id, customerID)
+		slog.Debug("Created account", "name", account.name, "id", id, "customer_id", customerID)
 	}
 
-	log.Println("Database seeding completed successfully")
+	slog.Info("Database seeding completed successfully")
 	return nil
 }
 
+// SeedOptions selects the sample data to generate
+type SeedOptions struct {
+	// Performance generates large datasets instead of a few records
+	Performance         bool
+	Customers           int
+	AccountsPerCustomer int
+}
+
 // SeedDataIfEmpty seeds data only if the database is empty
-func SeedDataIfEmpty() error {
+func SeedDataIfEmpty(ctx context.Context, opts SeedOptions) error {
+	return acrossTenants(ctx, func(q *sql.Conn) error {
+		return seedDataIfEmpty(ctx, q, opts)
+	})
+}
+
+func seedDataIfEmpty(ctx context.Context, q *sql.Conn, opts SeedOptions) error {
 	var count int
-	err := PrimaryDB.QueryRow("SELECT COUNT(*) FROM customers").Scan(&count)
+	err := q.QueryRowContext(ctx, "SELECT COUNT(*) FROM customers").Scan(&count)
 	if err != nil && err != sql.ErrNoRows {
 		return err
 	}
@@ -130,38 +178,50 @@ func SeedDataIfEmpty() error {
 	}
 	
 	// Check if we should generate performance demo data
-	if os.Getenv("SEED_PERFORMANCE_DATA") == "true" {
-		return SeedPerformanceData()
+	if opts.Performance {
+		return seedPerformanceData(ctx, q, opts)
 	}
 	
-	return SeedData()
+	return seedData(ctx, q)
 }
 
 // ClearAndReseed clears existing data and reseeds the database
 // This is useful for regenerating demo data
-func ClearAndReseed() error {
-	log.Println("Clearing existing data...")
+func ClearAndReseed(ctx context.Context, opts SeedOptions) error {
+	return acrossTenants(ctx, func(q *sql.Conn) error {
+		return clearAndReseed(ctx, q, opts)
+	})
+}
+
+func clearAndReseed(ctx context.Context, q *sql.Conn, opts SeedOptions) error {
+	slog.Info("Clearing existing data")
 	
 	// Clear accounts first (due to foreign key constraint)
-	_, err := PrimaryDB.Exec("TRUNCATE TABLE accounts CASCADE")
+	_, err := q.ExecContext(ctx, "TRUNCATE TABLE accounts CASCADE")
 	if err != nil {
 		return fmt.Errorf("failed to clear accounts: %w", err)
 	}
 	
 	// Clear customers
-	_, err = PrimaryDB.Exec("TRUNCATE TABLE customers CASCADE")
+	_, err = q.ExecContext(ctx, "TRUNCATE TABLE customers CASCADE")
 	if err != nil {
 		return fmt.Errorf("failed to clear customers: %w", err)
 	}
 	
-	log.Println("Data cleared successfully")
+	// Clear aggregates computed from the old data
+	_, err = q.ExecContext(ctx, "TRUNCATE TABLE daily_metrics")
+	if err != nil {
+		return fmt.Errorf("failed to clear daily metrics: %w", err)
+	}
 	
-	// Reseed based on environment variables
-	if os.Getenv("SEED_PERFORMANCE_DATA") == "true" {
-		return SeedPerformanceData()
+	slog.Info("Data cleared successfully")
+	
+	// Reseed based on the options
+	if opts.Performance {
+		return seedPerformanceData(ctx, q, opts)
 	}
 	
-	return SeedData()
+	return seedData(ctx, q)
 }
 
 // SeedPerformanceData generates large datasets for NGPG performance demonstrations
@@ -169,34 +229,44 @@ func ClearAndReseed() error {
 // - Read scaling with follower pools
 // - Analytics query performance
 // - Automatic query routing
-func SeedPerformanceData() error {
-	log.Println("Generating performance demo data for NGPG showcase...")
+func SeedPerformanceData(ctx context.Context, opts SeedOptions) error {
+	return acrossTenants(ctx, func(q *sql.Conn) error {
+		return seedPerformanceData(ctx, q, opts)
+	})
+}
+
+func seedPerformanceData(ctx context.Context, q *sql.Conn, opts SeedOptions) error {
+	slog.Info("Generating performance demo data for NGPG showcase")
 	
-	// Get configuration from environment or use defaults
-	numCustomers := getEnvInt("SEED_CUSTOMERS", 1000)
-	numAccountsPerCustomer := getEnvInt("SEED_ACCOUNTS_PER_CUSTOMER", 5)
+	numCustomers := opts.Customers
+	numAccountsPerCustomer := opts.AccountsPerCustomer
 	
 	totalAccounts := numCustomers * numAccountsPerCustomer
 	
-	log.Printf("Generating %d customers with ~%d accounts each (~%d total accounts)...", 
-		numCustomers, numAccountsPerCustomer, totalAccounts)
+	slog.Info("Generating performance demo data",
+		"customers", numCustomers, "accounts_per_customer", numAccountsPerCustomer, "total_accounts", totalAccounts)
 	
 	// Create default test user if users table is empty
 	var userCount int
-	err := PrimaryDB.QueryRow("SELECT COUNT(*) FROM users").Scan(&userCount)
+	err := q.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&userCount)
 	if err == nil && userCount == 0 {
 		passwordHash, err := auth.HashPassword("admin123")
 		if err == nil {
-			_, err = PrimaryDB.Exec(
-				"INSERT INTO users (username, password_hash) VALUES ($1, $2)",
+			_, err = q.ExecContext(ctx,
+				"INSERT INTO users (username, password_hash, is_admin) VALUES ($1, $2, true)",
 				"admin", passwordHash,
 			)
 			if err == nil {
-				log.Println("Created default test user: username='admin', password='admin123'")
+				slog.Info("Created default test user", "username", "admin", "password", "admin123")
 			}
 		}
 	}
 	
+	orgID, err := ensureDemoOrganization(ctx, q)
+	if err != nil {
+		return err
+	}
+
 	// Company name templates for realistic data
 	companyTypes := []string{
 		"Corporation", "Inc", "LLC", "Ltd", "Group", "Solutions", "Systems",
@@ -227,7 +297,7 @@ func SeedPerformanceData() error {
 	// Generate customers - insert individually to get IDs
 	customerIDs := make([]int, 0, numCustomers)
 	
-	log.Println("Creating customers...")
+	slog.Info("Creating customers")
 	startTime := time.Now()
 	
 	for i := 0; i < numCustomers; i++ {
@@ -239,9 +309,9 @@ func SeedPerformanceData() error {
 			i)
 		
 		var id int
-		err := PrimaryDB.QueryRow(
-			"INSERT INTO customers (name, email) VALUES ($1, $2) RETURNING id",
-			name, email,
+		err := q.QueryRowContext(ctx,
+			"INSERT INTO customers (org_id, name, email) VALUES ($1, $2, $3) RETURNING id",
+			orgID, name, email,
 		).Scan(&id)
 		if err != nil {
 			return fmt.Errorf("failed to insert customer: %w", err)
@@ -249,15 +319,15 @@ func SeedPerformanceData() error {
 		customerIDs = append(customerIDs, id)
 		
 		if (i+1)%100 == 0 {
-			log.Printf("  Created %d/%d customers...", i+1, numCustomers)
+			slog.Info("Creating customers", "created", i+1, "total", numCustomers)
 		}
 	}
 	
 	customerTime := time.Since(startTime)
-	log.Printf("Created %d customers in %v", len(customerIDs), customerTime)
+	slog.Info("Created customers", "count", len(customerIDs), "duration", customerTime)
 	
 	// Generate accounts in batches for better performance
-	log.Println("Creating accounts...")
+	slog.Info("Creating accounts")
 	accountStartTime := time.Now()
 	
 	accountCount := 0
@@ -274,18 +344,18 @@ func SeedPerformanceData() error {
 		
 		// Build batch insert query
 		placeholders := ""
-		values := make([]interface{}, 0, len(accountBatch)*3)
+		values := make([]interface{}, 0, len(accountBatch)*4)
 		
 		for i, acc := range accountBatch {
 			if i > 0 {
 				placeholders += ", "
 			}
-			placeholders += fmt.Sprintf("($%d, $%d, $%d)", len(values)+1, len(values)+2, len(values)+3)
-			values = append(values, acc.customerID, acc.name, acc.status)
+			placeholders += fmt.Sprintf("($%d, $%d, $%d, $%d)", len(values)+1, len(values)+2, len(values)+3, len(values)+4)
+			values = append(values, orgID, acc.customerID, acc.name, acc.status)
 		}
 		
-		query := fmt.Sprintf("INSERT INTO accounts (customer_id, name, status) VALUES %s", placeholders)
-		_, err := PrimaryDB.Exec(query, values...)
+		query := fmt.Sprintf("INSERT INTO accounts (org_id, customer_id, name, status) VALUES %s", placeholders)
+		_, err := q.ExecContext(ctx, query, values...)
 		if err != nil {
 			return fmt.Errorf("failed to insert account batch: %w", err)
 		}
@@ -326,8 +396,8 @@ func SeedPerformanceData() error {
 		
 		// Log progress
 		if (i+1)%100 == 0 {
-			log.Printf("  Created accounts for %d/%d customers (%d total accounts)...", 
-				i+1, len(customerIDs), accountCount)
+			slog.Info("Creating accounts",
+				"customers", i+1, "total_customers", len(customerIDs), "accounts", accountCount)
 		}
 	}
 	
@@ -339,27 +409,50 @@ func SeedPerformanceData() error {
 	accountTime := time.Since(accountStartTime)
 	totalTime := customerTime + accountTime
 	
-	log.Printf("Created %d accounts in %v", accountCount, accountTime)
-	log.Printf("Performance demo data generation completed in %v", totalTime)
-	log.Printf("Summary: %d customers, %d accounts", len(customerIDs), accountCount)
+	slog.Info("Created accounts", "count", accountCount, "duration", accountTime)
+	slog.Info("Performance demo data generation completed", "duration", totalTime)
+	slog.Info("Performance demo data summary", "customers", len(customerIDs), "accounts", accountCount)
 	
 	return nil
 }
 
-// Helper functions
-func getEnvInt(key string, defaultValue int) int {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// demoOrganizationName is the organization seeded data and the default user belong to
+const demoOrganizationName = "Demo Organization"
+
+// ensureDemoOrganization returns the demo organization, creating it if needed,
+// and makes sure the default admin user is its owner
+func ensureDemoOrganization(ctx context.Context, q *sql.Conn) (int, error) {
+	var orgID int
+	err := q.QueryRowContext(ctx,
+		"SELECT id FROM organizations WHERE name = $1 ORDER BY id LIMIT 1",
+		demoOrganizationName,
+	).Scan(&orgID)
+	if err == sql.ErrNoRows {
+		err = q.QueryRowContext(ctx,
+			"INSERT INTO organizations (name) VALUES ($1) RETURNING id",
+			demoOrganizationName,
+		).Scan(&orgID)
+		if err == nil {
+			slog.Info("Created organization", "name", demoOrganizationName, "id", orgID)
+		}
 	}
-	intValue, err := strconv.Atoi(value)
 	if err != nil {
-		log.Printf("Warning: Invalid value for %s (%s), using default %d", key, value, defaultValue)
-		return defaultValue
+		return 0, fmt.Errorf("failed to find or create demo organization: %w", err)
 	}
-	return intValue
+
+	_, err = q.ExecContext(ctx,
+		"INSERT INTO organization_members (organization_id, user_id, role) SELECT $1, id, 'owner' FROM users WHERE username = 'admin' "+
+			"ON CONFLICT (organization_id, user_id) DO UPDATE SET role = 'owner'",
+		orgID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add default user to demo organization: %w", err)
+	}
+
+	return orgID, nil
 }
 
+// Helper functions
 func weightedRandomStatus(statuses []string, weights []int) string {
 	totalWeight := 0
 	for _, w := range weights {
@@ -0,0 +1,58 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"saas-go-app/internal/db"
+	"saas-go-app/internal/jobs"
+)
+
+// Ping checks that conn reaches its database
+func Ping(conn *sql.DB) Checker {
+	return func(ctx context.Context) error {
+		return conn.PingContext(ctx)
+	}
+}
+
+// Queue checks that the job queue's backend, Redis for asynq, answers
+func Queue(inspector jobs.Inspector) Checker {
+	return func(ctx context.Context) error {
+		_, err := inspector.Queues(ctx)
+		return err
+	}
+}
+
+// Migrations checks that every migration migrator knows has been applied
+func Migrations(migrator *db.Migrator) Checker {
+	return func(ctx context.Context) error {
+		pending, err := migrator.Pending(ctx)
+		if errors.Is(err, db.ErrNotMigrated) {
+			return Errorf("not migrated")
+		}
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return Errorf("%d migrations pending", pending)
+		}
+		return nil
+	}
+}
+
+// ReplicationLag checks that the follower behind conn replays the
+// primary's changes within max
+func ReplicationLag(conn *sql.DB, max time.Duration) Checker {
+	return func(ctx context.Context) error {
+		lag, err := db.ReplicationLag(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if lag > max {
+			return Errorf("replication lag %s exceeds %s", lag.Round(time.Millisecond), max)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,196 @@
+// Package health checks the service's dependencies for the readiness
+// endpoint. Checks are registered with a Registry by name; critical ones
+// make the service unready when they fail, the others only degrade it.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"saas-go-app/internal/repository"
+)
+
+// Status is the state of one check or of the whole service
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// DefaultTimeout bounds each check unless the registry says otherwise
+const DefaultTimeout = 2 * time.Second
+
+// Checker checks one dependency, returning why it is unusable
+type Checker func(ctx context.Context) error
+
+// Result is the outcome of one check. LastError is kept after the check
+// recovers, so intermittent failures show up. Errors are summarized, see
+// Errorf; the checker's own error, which may name hosts and carry driver
+// messages, only goes to the logs.
+type Result struct {
+	Status      Status     `json:"status"`
+	Critical    bool       `json:"critical"`
+	LatencyMS   float64    `json:"latency_ms"`
+	Error       string     `json:"error,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Report is the outcome of every check. Status is down when a critical
+// check failed, degraded when another one did and up otherwise.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Ready reports whether the service can serve traffic
+func (r Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// check is a registered checker and what it last reported
+type check struct {
+	name     string
+	critical bool
+	checker  Checker
+
+	mu          sync.Mutex
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// Registry runs the registered checks. It is safe for concurrent use.
+type Registry struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []*check
+}
+
+// NewRegistry returns an empty registry bounding each check by timeout,
+// or DefaultTimeout when it is not positive
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Registry{timeout: timeout}
+}
+
+// Register adds a check named name, replacing any check of that name
+func (r *Registry) Register(name string, critical bool, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := &check{name: name, critical: critical, checker: checker}
+	for i, existing := range r.checks {
+		if existing.name == name {
+			r.checks[i] = c
+			return
+		}
+	}
+	r.checks = append(r.checks, c)
+	sort.Slice(r.checks, func(i, j int) bool { return r.checks[i].name < r.checks[j].name })
+}
+
+// Run runs every check concurrently and reports their results
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := r.checks
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, r.timeout)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		result := results[i]
+		report.Checks[c.name] = result
+		switch {
+		case result.Status == StatusUp:
+		case c.critical:
+			report.Status = StatusDown
+		case report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// run runs the check once, recording its error
+func (c *check) run(ctx context.Context, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.checker(ctx)
+	result := Result{
+		Status:    StatusUp,
+		Critical:  c.critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = Errorf("timed out after %s", timeout)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		slog.WarnContext(ctx, "Health check failed", "check", c.name, "critical", c.critical, "error", err)
+		result.Status = StatusDown
+		result.Error = summarize(err)
+		c.lastError, c.lastErrorAt = result.Error, time.Now().UTC()
+	}
+	if c.lastError != "" {
+		lastErrorAt := c.lastErrorAt
+		result.LastError, result.LastErrorAt = c.lastError, &lastErrorAt
+	}
+	return result
+}
+
+// publicError is an error whose message is safe to serve
+type publicError struct{ message string }
+
+func (e publicError) Error() string { return e.message }
+
+// Errorf formats an error whose message readiness responses may show as
+// is, for checkers describing what they found rather than passing on an
+// error from a driver
+func Errorf(format string, args ...any) error {
+	return publicError{message: fmt.Sprintf(format, args...)}
+}
+
+// summarize describes err without hosts, addresses or driver messages
+func summarize(err error) string {
+	var public publicError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &public):
+		return public.message
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timed out"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.Is(err, repository.ErrUnavailable):
+		return "unavailable"
+	case errors.As(err, &netErr):
+		return "network error"
+	default:
+		return "check failed"
+	}
+}
@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"saas-go-app/internal/repository"
+)
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{Errorf("%d migrations pending", 3), "3 migrations pending"},
+		{fmt.Errorf("ping: %w", context.DeadlineExceeded), "timed out"},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, "connection refused"},
+		{fmt.Errorf("begin transaction: %w", repository.ErrUnavailable), "unavailable"},
+		{&net.DNSError{Err: "no such host", Name: "db.internal"}, "network error"},
+		{errors.New(`pq: password authentication failed for user "app"`), "check failed"},
+	}
+	for _, tt := range tests {
+		if got := summarize(tt.err); got != tt.want {
+			t.Errorf("summarize(%v): expected %q, got %q", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestRegistryRun(t *testing.T) {
+	var followerErr error
+	checks := NewRegistry(50 * time.Millisecond)
+	checks.Register("primary", true, func(context.Context) error { return nil })
+	checks.Register("follower", false, func(context.Context) error { return followerErr })
+
+	if report := checks.Run(context.Background()); report.Status != StatusUp || !report.Ready() {
+		t.Errorf("Expected up with every check passing, got %+v", report)
+	}
+
+	followerErr = fmt.Errorf("dial tcp 10.0.0.5:5432: %w", syscall.ECONNREFUSED)
+	report := checks.Run(context.Background())
+	if report.Status != StatusDegraded || !report.Ready() {
+		t.Errorf("Expected a failing non-critical check to degrade but not fail readiness, got %+v", report)
+	}
+	if follower := report.Checks["follower"]; follower.Status != StatusDown || follower.Error != "connection refused" {
+		t.Errorf("Expected the follower check to report its error without the address, got %+v", follower)
+	}
+
+	followerErr = nil
+	report = checks.Run(context.Background())
+	follower := report.Checks["follower"]
+	if follower.Status != StatusUp || follower.Error != "" {
+		t.Errorf("Expected the follower check to recover, got %+v", follower)
+	}
+	if follower.LastError != "connection refused" || follower.LastErrorAt == nil {
+		t.Errorf("Expected the follower check to keep its last error, got %+v", follower)
+	}
+
+	checks.Register("primary", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	report = checks.Run(context.Background())
+	if report.Status != StatusDown || report.Ready() {
+		t.Errorf("Expected a failing critical check to fail readiness, got %+v", report)
+	}
+	if primary := report.Checks["primary"]; primary.Error != "timed out after 50ms" {
+		t.Errorf("Expected the primary check to time out, got %+v", primary)
+	}
+	if len(report.Checks) != 2 {
+		t.Errorf("Expected re-registering to replace the check, got %d checks", len(report.Checks))
+	}
+}
@@ -2,12 +2,12 @@ package jobs
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"time"
 
-	"saas-go-app/internal/db"
+	"saas-go-app/internal/repository"
 
 	"github.com/hibiken/asynq"
 )
@@ -18,59 +18,53 @@ const (
 
 // AggregationPayload represents the payload for aggregation jobs
 type AggregationPayload struct {
+	TaskMeta
 	Date time.Time `json:"date"`
 }
 
-// NewAggregationTask creates a new aggregation task
-func NewAggregationTask(date time.Time) (*asynq.Task, error) {
-	payload, err := json.Marshal(AggregationPayload{Date: date})
+// NewAggregationTask creates a new aggregation task enqueued from ctx
+func NewAggregationTask(ctx context.Context, date time.Time) (*asynq.Task, error) {
+	payload, err := json.Marshal(AggregationPayload{TaskMeta: NewTaskMeta(ctx), Date: date})
 	if err != nil {
 		return nil, err
 	}
 	return asynq.NewTask(TypeAggregateData, payload), nil
 }
 
-// HandleAggregationTask processes aggregation tasks
-func HandleAggregationTask(ctx context.Context, t *asynq.Task) error {
+// AggregationHandler processes aggregation tasks, persisting each day's
+// customer and account counts to daily_metrics
+type AggregationHandler struct {
+	metrics repository.DailyMetricsRepository
+}
+
+// NewAggregationHandler creates an aggregation handler writing to the given repository
+func NewAggregationHandler(metrics repository.DailyMetricsRepository) *AggregationHandler {
+	return &AggregationHandler{metrics: metrics}
+}
+
+// ProcessTask aggregates the day in the task payload, defaulting to today.
+// Reruns for the same day overwrite the stored values.
+func (h *AggregationHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
 	var payload AggregationPayload
 	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
-		return err
+		return fmt.Errorf("decode aggregation payload: %v: %w", err, asynq.SkipRetry)
 	}
 
-	log.Printf("Processing aggregation task for date: %s", payload.Date.Format("2006-01-02"))
-
-	// Perform data aggregation
-	// This is a demo, so we'll just log some aggregated statistics
-	analyticsDB := db.AnalyticsDB
-	if analyticsDB == nil {
-		analyticsDB = db.PrimaryDB
+	date := payload.Date
+	if date.IsZero() {
+		date = time.Now()
 	}
 
-	var totalCustomers int
-	var totalAccounts int
-	var activeAccounts int
-
-	err := analyticsDB.QueryRow("SELECT COUNT(*) FROM customers").Scan(&totalCustomers)
-	if err != nil && err != sql.ErrNoRows {
-		log.Printf("Error aggregating customers: %v", err)
-	}
+	day := date.UTC().Format("2006-01-02")
+	slog.InfoContext(ctx, "Processing aggregation task", "date", day)
 
-	err = analyticsDB.QueryRow("SELECT COUNT(*) FROM accounts").Scan(&totalAccounts)
-	if err != nil && err != sql.ErrNoRows {
-		log.Printf("Error aggregating accounts: %v", err)
-	}
-
-	err = analyticsDB.QueryRow("SELECT COUNT(*) FROM accounts WHERE status = 'active'").Scan(&activeAccounts)
-	if err != nil && err != sql.ErrNoRows {
-		log.Printf("Error aggregating active accounts: %v", err)
+	totals, err := h.metrics.AggregateDay(ctx, date)
+	if err != nil {
+		return fmt.Errorf("aggregate %s: %w", day, err)
 	}
+	recordTotals(date.UTC(), totals)
 
-	log.Printf("Aggregation results - Customers: %d, Accounts: %d, Active: %d", 
-		totalCustomers, totalAccounts, activeAccounts)
-
-	// In a real application, you might store these aggregated results in a separate table
-	// For this demo, we're just logging them
-
+	slog.InfoContext(ctx, "Aggregation stored daily metrics", "date", day, "rows", totals.Rows,
+		"customers", totals.Customers, "accounts", totals.AccountsByStatus)
 	return nil
 }
-
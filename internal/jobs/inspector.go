@@ -0,0 +1,395 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// Task states that can be listed
+const (
+	TaskStatePending   = "pending"
+	TaskStateActive    = "active"
+	TaskStateScheduled = "scheduled"
+	TaskStateRetry     = "retry"
+	TaskStateArchived  = "archived"
+	TaskStateCompleted = "completed"
+)
+
+// TaskStates lists the task states in lifecycle order
+var TaskStates = []string{
+	TaskStatePending, TaskStateActive, TaskStateScheduled,
+	TaskStateRetry, TaskStateArchived, TaskStateCompleted,
+}
+
+var (
+	// ErrQueueNotFound is returned for a queue that neither is configured nor has tasks
+	ErrQueueNotFound = errors.New("queue not found")
+
+	// ErrTaskNotFound is returned when the task does not exist in the queue
+	ErrTaskNotFound = errors.New("task not found")
+
+	// ErrInvalidTaskState is returned when listing an unknown task state
+	ErrInvalidTaskState = errors.New("invalid task state")
+
+	// ErrTaskStateConflict is returned when an operation does not apply to
+	// the task's current state, such as running an active task
+	ErrTaskStateConflict = errors.New("operation not allowed in the task's state")
+)
+
+// QueueStats is a snapshot of one queue
+type QueueStats struct {
+	Queue     string `json:"queue"`
+	Priority  int    `json:"priority"`
+	Paused    bool   `json:"paused"`
+	Size      int    `json:"size"`
+	Pending   int    `json:"pending"`
+	Active    int    `json:"active"`
+	Scheduled int    `json:"scheduled"`
+	Retry     int    `json:"retry"`
+	Archived  int    `json:"archived"`
+	Completed int    `json:"completed"`
+	// Processed and Failed count today's tasks (UTC)
+	Processed int `json:"processed"`
+	Failed    int `json:"failed"`
+	// LatencySeconds is how long the oldest pending task has waited
+	LatencySeconds float64 `json:"latency_seconds"`
+}
+
+// TaskDetails describes one task
+type TaskDetails struct {
+	ID    string `json:"id"`
+	Queue string `json:"queue"`
+	Type  string `json:"type"`
+	// Payload is the task payload, as JSON when it is valid JSON and as a
+	// string otherwise
+	Payload       json.RawMessage `json:"payload" swaggertype:"object"`
+	State         string          `json:"state"`
+	MaxRetry      int             `json:"max_retry"`
+	Retried       int             `json:"retried"`
+	LastError     string          `json:"last_error,omitempty"`
+	LastFailedAt  *time.Time      `json:"last_failed_at,omitempty"`
+	NextProcessAt *time.Time      `json:"next_process_at,omitempty"`
+	CompletedAt   *time.Time      `json:"completed_at,omitempty"`
+}
+
+// Inspector reports on and manages queued tasks
+type Inspector interface {
+	// Queues returns the configured queues and any other queue with tasks,
+	// highest priority first
+	Queues(ctx context.Context) ([]QueueStats, error)
+	// ListTasks returns one page (from 1) of the queue's tasks in state
+	ListTasks(ctx context.Context, queue, state string, page, perPage int) ([]TaskDetails, error)
+	GetTask(ctx context.Context, queue, id string) (TaskDetails, error)
+	// RunTask moves a scheduled, retry or archived task to pending
+	RunTask(ctx context.Context, queue, id string) error
+	// DeleteTask deletes a task that is not active
+	DeleteTask(ctx context.Context, queue, id string) error
+	RunArchivedTasks(ctx context.Context, queue string) (int, error)
+	DeleteArchivedTasks(ctx context.Context, queue string) (int, error)
+	// PauseQueue stops workers taking tasks from the queue; pausing a
+	// paused queue, or resuming a running one, does nothing
+	PauseQueue(ctx context.Context, queue string) error
+	ResumeQueue(ctx context.Context, queue string) error
+}
+
+// AsynqInspector implements Inspector on top of asynq's Redis state
+type AsynqInspector struct {
+	inspector *asynq.Inspector
+	// priorities are the queues workers process, reported even before
+	// their first task
+	priorities map[string]int
+}
+
+// NewAsynqInspector creates an inspector for the Redis server at redis,
+// see RedisOptions, whose workers process the queues in priorities
+func NewAsynqInspector(redis asynq.RedisConnOpt, priorities map[string]int) *AsynqInspector {
+	return &AsynqInspector{
+		inspector:  asynq.NewInspector(redis),
+		priorities: priorities,
+	}
+}
+
+// Close closes the Redis connection
+func (i *AsynqInspector) Close() error {
+	return i.inspector.Close()
+}
+
+func (i *AsynqInspector) Queues(ctx context.Context) ([]QueueStats, error) {
+	existing, err := i.inspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("list queues: %w", err)
+	}
+
+	names := existing
+	for queue := range i.priorities {
+		if !slices.Contains(names, queue) {
+			names = append(names, queue)
+		}
+	}
+
+	stats := make([]QueueStats, 0, len(names))
+	for _, queue := range names {
+		// Queues are created in Redis by their first task
+		if !slices.Contains(existing, queue) {
+			stats = append(stats, QueueStats{Queue: queue, Priority: i.priorities[queue]})
+			continue
+		}
+
+		info, err := i.inspector.GetQueueInfo(queue)
+		if err != nil {
+			return nil, fmt.Errorf("get queue %s: %w", queue, err)
+		}
+		stats = append(stats, newQueueStats(info, i.priorities[queue]))
+	}
+
+	sort.Slice(stats, func(a, b int) bool {
+		if stats[a].Priority != stats[b].Priority {
+			return stats[a].Priority > stats[b].Priority
+		}
+		return stats[a].Queue < stats[b].Queue
+	})
+	return stats, nil
+}
+
+func (i *AsynqInspector) ListTasks(ctx context.Context, queue, state string, page, perPage int) ([]TaskDetails, error) {
+	var list func(string, ...asynq.ListOption) ([]*asynq.TaskInfo, error)
+	switch state {
+	case TaskStatePending:
+		list = i.inspector.ListPendingTasks
+	case TaskStateActive:
+		list = i.inspector.ListActiveTasks
+	case TaskStateScheduled:
+		list = i.inspector.ListScheduledTasks
+	case TaskStateRetry:
+		list = i.inspector.ListRetryTasks
+	case TaskStateArchived:
+		list = i.inspector.ListArchivedTasks
+	case TaskStateCompleted:
+		list = i.inspector.ListCompletedTasks
+	default:
+		return nil, ErrInvalidTaskState
+	}
+
+	exists, err := i.queueExists(queue)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return []TaskDetails{}, nil
+	}
+
+	infos, err := list(queue, asynq.Page(page), asynq.PageSize(perPage))
+	if err != nil {
+		return nil, translateInspectorError(err, "list tasks")
+	}
+
+	tasks := make([]TaskDetails, len(infos))
+	for n, info := range infos {
+		tasks[n] = newTaskDetails(info)
+	}
+	return tasks, nil
+}
+
+func (i *AsynqInspector) GetTask(ctx context.Context, queue, id string) (TaskDetails, error) {
+	info, err := i.getTask(queue, id)
+	if err != nil {
+		return TaskDetails{}, err
+	}
+	return newTaskDetails(info), nil
+}
+
+func (i *AsynqInspector) RunTask(ctx context.Context, queue, id string) error {
+	info, err := i.getTask(queue, id)
+	if err != nil {
+		return err
+	}
+	if info.State == asynq.TaskStatePending || info.State == asynq.TaskStateActive || info.State == asynq.TaskStateCompleted {
+		return ErrTaskStateConflict
+	}
+	return translateInspectorError(i.inspector.RunTask(queue, id), "run task")
+}
+
+func (i *AsynqInspector) DeleteTask(ctx context.Context, queue, id string) error {
+	info, err := i.getTask(queue, id)
+	if err != nil {
+		return err
+	}
+	if info.State == asynq.TaskStateActive {
+		return ErrTaskStateConflict
+	}
+	return translateInspectorError(i.inspector.DeleteTask(queue, id), "delete task")
+}
+
+func (i *AsynqInspector) RunArchivedTasks(ctx context.Context, queue string) (int, error) {
+	if err := i.requireQueue(queue); err != nil {
+		return 0, err
+	}
+	n, err := i.inspector.RunAllArchivedTasks(queue)
+	return n, translateInspectorError(err, "run archived tasks")
+}
+
+func (i *AsynqInspector) DeleteArchivedTasks(ctx context.Context, queue string) (int, error) {
+	if err := i.requireQueue(queue); err != nil {
+		return 0, err
+	}
+	n, err := i.inspector.DeleteAllArchivedTasks(queue)
+	return n, translateInspectorError(err, "delete archived tasks")
+}
+
+func (i *AsynqInspector) PauseQueue(ctx context.Context, queue string) error {
+	return i.setPaused(queue, true)
+}
+
+func (i *AsynqInspector) ResumeQueue(ctx context.Context, queue string) error {
+	return i.setPaused(queue, false)
+}
+
+func (i *AsynqInspector) setPaused(queue string, paused bool) error {
+	if err := i.requireQueue(queue); err != nil {
+		return err
+	}
+
+	// asynq rejects pausing a paused queue; make both operations idempotent
+	info, err := i.inspector.GetQueueInfo(queue)
+	if err != nil {
+		return fmt.Errorf("get queue %s: %w", queue, err)
+	}
+	if info.Paused == paused {
+		return nil
+	}
+	if paused {
+		return translateInspectorError(i.inspector.PauseQueue(queue), "pause queue")
+	}
+	return translateInspectorError(i.inspector.UnpauseQueue(queue), "resume queue")
+}
+
+func (i *AsynqInspector) getTask(queue, id string) (*asynq.TaskInfo, error) {
+	info, err := i.inspector.GetTaskInfo(queue, id)
+	if errors.Is(err, asynq.ErrQueueNotFound) {
+		// A configured queue without tasks yet holds no task either
+		if _, ok := i.priorities[queue]; ok {
+			return nil, ErrTaskNotFound
+		}
+		return nil, ErrQueueNotFound
+	}
+	if err != nil {
+		return nil, translateInspectorError(err, "get task")
+	}
+	return info, nil
+}
+
+// queueExists reports whether Redis knows the queue, that is, whether it
+// has ever had a task
+func (i *AsynqInspector) queueExists(queue string) (bool, error) {
+	existing, err := i.inspector.Queues()
+	if err != nil {
+		return false, fmt.Errorf("list queues: %w", err)
+	}
+	if slices.Contains(existing, queue) {
+		return true, nil
+	}
+	if _, ok := i.priorities[queue]; ok {
+		return false, nil
+	}
+	return false, ErrQueueNotFound
+}
+
+// requireQueue returns ErrQueueNotFound unless Redis knows the queue
+func (i *AsynqInspector) requireQueue(queue string) error {
+	exists, err := i.queueExists(queue)
+	if err == nil && !exists {
+		return ErrQueueNotFound
+	}
+	return err
+}
+
+// translateInspectorError maps asynq errors onto the package's errors
+func translateInspectorError(err error, action string) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, asynq.ErrQueueNotFound):
+		return ErrQueueNotFound
+	case errors.Is(err, asynq.ErrTaskNotFound):
+		return ErrTaskNotFound
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
+
+func newQueueStats(info *asynq.QueueInfo, priority int) QueueStats {
+	return QueueStats{
+		Queue:          info.Queue,
+		Priority:       priority,
+		Paused:         info.Paused,
+		Size:           info.Size,
+		Pending:        info.Pending,
+		Active:         info.Active,
+		Scheduled:      info.Scheduled,
+		Retry:          info.Retry,
+		Archived:       info.Archived,
+		Completed:      info.Completed,
+		Processed:      info.Processed,
+		Failed:         info.Failed,
+		LatencySeconds: info.Latency.Seconds(),
+	}
+}
+
+func newTaskDetails(info *asynq.TaskInfo) TaskDetails {
+	return TaskDetails{
+		ID:            info.ID,
+		Queue:         info.Queue,
+		Type:          info.Type,
+		Payload:       payloadJSON(info.Payload),
+		State:         info.State.String(),
+		MaxRetry:      info.MaxRetry,
+		Retried:       info.Retried,
+		LastError:     info.LastErr,
+		LastFailedAt:  timeOrNil(info.LastFailedAt),
+		NextProcessAt: timeOrNil(info.NextProcessAt),
+		CompletedAt:   timeOrNil(info.CompletedAt),
+	}
+}
+
+// payloadJSON returns payload if it is JSON, or payload as a JSON string
+func payloadJSON(payload []byte) json.RawMessage {
+	if len(payload) == 0 {
+		return json.RawMessage("null")
+	}
+	if json.Valid(payload) {
+		return payload
+	}
+	encoded, _ := json.Marshal(string(payload))
+	return encoded
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// ReportTaskError logs a failed task attempt. Tasks that have used up their
+// retries are archived, where the admin API can retry or delete them.
+func ReportTaskError(ctx context.Context, task *asynq.Task, err error) {
+	id, _ := asynq.GetTaskID(ctx)
+	queue, _ := asynq.GetQueueName(ctx)
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+
+	ctx = taskContext(ctx, task)
+	attrs := []any{"task_id", id, "type", task.Type(), "queue", queue, "error", err}
+	if retried >= maxRetry || errors.Is(err, asynq.SkipRetry) {
+		slog.ErrorContext(ctx, "Task failed and was archived", append(attrs, "retried", retried)...)
+		return
+	}
+	slog.WarnContext(ctx, "Task failed and will be retried", append(attrs, "retry", retried+1, "max_retry", maxRetry)...)
+}
@@ -0,0 +1,127 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+func TestPayloadJSON(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    string
+	}{
+		{`{"date":"2024-01-01T00:00:00Z"}`, `{"date":"2024-01-01T00:00:00Z"}`},
+		{"", "null"},
+		{"not json", `"not json"`},
+	}
+
+	for _, tt := range tests {
+		if got := string(payloadJSON([]byte(tt.payload))); got != tt.want {
+			t.Errorf("payloadJSON(%q) = %s, want %s", tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateInspectorError(t *testing.T) {
+	failure := errors.New("connection reset")
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"nil", nil, nil},
+		{"queue not found", asynq.ErrQueueNotFound, ErrQueueNotFound},
+		{"wrapped queue not found", fmt.Errorf("inspect: %w", asynq.ErrQueueNotFound), ErrQueueNotFound},
+		{"task not found", asynq.ErrTaskNotFound, ErrTaskNotFound},
+		{"wrapped task not found", fmt.Errorf("inspect: %w", asynq.ErrTaskNotFound), ErrTaskNotFound},
+		{"other", failure, failure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translateInspectorError(tt.err, "run task")
+			if !errors.Is(got, tt.want) || (tt.want == nil) != (got == nil) {
+				t.Errorf("translateInspectorError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	if got := translateInspectorError(failure, "run task").Error(); got != "run task: connection reset" {
+		t.Errorf("Expected the action in other errors, got %q", got)
+	}
+}
+
+func TestNewTaskDetails(t *testing.T) {
+	failedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		info asynq.TaskInfo
+		want TaskDetails
+	}{
+		{
+			name: "pending",
+			info: asynq.TaskInfo{
+				ID: "a", Queue: "default", Type: TypeAggregateData, Payload: []byte(`{"date":"2024-01-01T00:00:00Z"}`),
+				State: asynq.TaskStatePending, MaxRetry: 5,
+			},
+			want: TaskDetails{
+				ID: "a", Queue: "default", Type: TypeAggregateData, Payload: json.RawMessage(`{"date":"2024-01-01T00:00:00Z"}`),
+				State: TaskStatePending, MaxRetry: 5,
+			},
+		},
+		{
+			name: "retry",
+			info: asynq.TaskInfo{
+				ID: "b", Queue: "low", Type: TypeAggregateData, State: asynq.TaskStateRetry,
+				MaxRetry: 5, Retried: 2, LastErr: "timeout", LastFailedAt: failedAt, NextProcessAt: failedAt.Add(time.Minute),
+			},
+			want: TaskDetails{
+				ID: "b", Queue: "low", Type: TypeAggregateData, Payload: json.RawMessage("null"), State: TaskStateRetry,
+				MaxRetry: 5, Retried: 2, LastError: "timeout", LastFailedAt: &failedAt, NextProcessAt: timeOrNil(failedAt.Add(time.Minute)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newTaskDetails(&tt.info); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newTaskDetails() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewQueueStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     asynq.QueueInfo
+		priority int
+		want     QueueStats
+	}{
+		{
+			name:     "busy",
+			info:     asynq.QueueInfo{Queue: "critical", Size: 7, Pending: 3, Active: 1, Scheduled: 1, Retry: 1, Archived: 1, Completed: 2, Processed: 10, Failed: 2, Latency: 1500 * time.Millisecond},
+			priority: 6,
+			want:     QueueStats{Queue: "critical", Priority: 6, Size: 7, Pending: 3, Active: 1, Scheduled: 1, Retry: 1, Archived: 1, Completed: 2, Processed: 10, Failed: 2, LatencySeconds: 1.5},
+		},
+		{
+			name: "paused and unconfigured",
+			info: asynq.QueueInfo{Queue: "legacy", Paused: true},
+			want: QueueStats{Queue: "legacy", Paused: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newQueueStats(&tt.info, tt.priority); got != tt.want {
+				t.Errorf("newQueueStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
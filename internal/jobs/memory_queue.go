@@ -0,0 +1,530 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// asynq's defaults, applied by MemoryQueue too
+const (
+	defaultMaxRetry = 25
+	defaultTimeout  = 30 * time.Minute
+)
+
+// MemoryQueue is an in-process Queue for local development and tests. Tasks
+// follow asynq's lifecycle (scheduled, pending, active, retry, archived,
+// completed) and its retry backoff, but live in memory: they are lost on
+// restart and each process has its own queue, so task IDs only deduplicate
+// within a process. The Unique and Group options are ignored.
+type MemoryQueue struct {
+	concurrency int
+	priorities  map[string]int
+	retryDelay  asynq.RetryDelayFunc
+
+	mu     sync.Mutex
+	tasks  map[string]*memoryTask
+	paused map[string]bool
+	seq    int64
+
+	wake     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	workers  sync.WaitGroup
+	// abort cancels running tasks when Shutdown gives up waiting
+	running context.Context
+	abort   context.CancelFunc
+}
+
+type memoryTask struct {
+	task          *asynq.Task
+	id            string
+	queue         string
+	seq           int64
+	state         string
+	maxRetry      int
+	retried       int
+	timeout       time.Duration
+	deadline      time.Time
+	retention     time.Duration
+	lastErr       string
+	lastFailedAt  time.Time
+	nextProcessAt time.Time
+	completedAt   time.Time
+}
+
+// NewMemoryQueue creates an in-process queue that, once started, processes
+// tasks as opts say
+func NewMemoryQueue(opts Options) *MemoryQueue {
+	opts = opts.withDefaults()
+	running, abort := context.WithCancel(context.Background())
+	return &MemoryQueue{
+		concurrency: opts.Concurrency,
+		priorities:  opts.Priorities,
+		retryDelay:  asynq.DefaultRetryDelayFunc,
+		tasks:       make(map[string]*memoryTask),
+		paused:      make(map[string]bool),
+		wake:        make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		running:     running,
+		abort:       abort,
+	}
+}
+
+func (q *MemoryQueue) EnqueueContext(ctx context.Context, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	t := &memoryTask{
+		task:     task,
+		queue:    "default",
+		state:    TaskStatePending,
+		maxRetry: defaultMaxRetry,
+		timeout:  defaultTimeout,
+	}
+	for _, opt := range withTaskOptions(task, opts) {
+		switch opt.Type() {
+		case asynq.TaskIDOpt:
+			t.id = opt.Value().(string)
+		case asynq.QueueOpt:
+			t.queue = opt.Value().(string)
+		case asynq.MaxRetryOpt:
+			t.maxRetry = opt.Value().(int)
+		case asynq.TimeoutOpt:
+			t.timeout = opt.Value().(time.Duration)
+		case asynq.DeadlineOpt:
+			t.deadline = opt.Value().(time.Time)
+		case asynq.RetentionOpt:
+			t.retention = opt.Value().(time.Duration)
+		case asynq.ProcessAtOpt:
+			t.nextProcessAt = opt.Value().(time.Time)
+		case asynq.ProcessInOpt:
+			t.nextProcessAt = time.Now().Add(opt.Value().(time.Duration))
+		}
+	}
+	if t.nextProcessAt.After(time.Now()) {
+		t.state = TaskStateScheduled
+	}
+	if t.id == "" {
+		var id [16]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			return nil, err
+		}
+		t.id = hex.EncodeToString(id[:])
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.tasks[t.id]; ok {
+		return nil, asynq.ErrTaskIDConflict
+	}
+	q.seq++
+	t.seq = q.seq
+	q.tasks[t.id] = t
+	q.signal()
+
+	info := t.info()
+	return &asynq.TaskInfo{
+		ID:       info.ID,
+		Queue:    info.Queue,
+		Type:     info.Type,
+		Payload:  task.Payload(),
+		State:    asynq.TaskStatePending,
+		MaxRetry: t.maxRetry,
+	}, nil
+}
+
+func (q *MemoryQueue) Start(handler asynq.Handler) error {
+	slog.Info("Starting in-process job processor", "concurrency", q.concurrency, "queues", q.priorities)
+	for i := 0; i < q.concurrency; i++ {
+		q.workers.Add(1)
+		go q.work(handler)
+	}
+	return nil
+}
+
+// Shutdown waits for running tasks until ctx is done, then cancels them.
+// Pending tasks are dropped with the process.
+func (q *MemoryQueue) Shutdown(ctx context.Context) error {
+	q.stopOnce.Do(func() { close(q.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		q.abort()
+		return ctx.Err()
+	}
+}
+
+func (q *MemoryQueue) Inspector() Inspector {
+	return q
+}
+
+func (q *MemoryQueue) Close() error {
+	q.abort()
+	return nil
+}
+
+// signal wakes one idle worker. Callers hold q.mu.
+func (q *MemoryQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *MemoryQueue) work(handler asynq.Handler) {
+	defer q.workers.Done()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		t, wait := q.next()
+		if t == nil {
+			select {
+			case <-q.stop:
+				return
+			case <-q.wake:
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		// Let another worker look for more work
+		q.mu.Lock()
+		q.signal()
+		q.mu.Unlock()
+
+		q.finish(t, q.run(handler, t))
+	}
+}
+
+// next claims the next pending task, highest priority queue first, or
+// returns how long to wait before a scheduled task falls due
+func (q *MemoryQueue) next() (*memoryTask, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	wait := time.Second
+	var best *memoryTask
+	for id, t := range q.tasks {
+		switch t.state {
+		case TaskStateScheduled, TaskStateRetry:
+			if !t.nextProcessAt.After(now) {
+				t.state = TaskStatePending
+			} else if until := t.nextProcessAt.Sub(now); until < wait {
+				wait = until
+			}
+		case TaskStateCompleted:
+			if now.Sub(t.completedAt) >= t.retention {
+				delete(q.tasks, id)
+			}
+		}
+
+		// Like asynq's workers, leave queues that are not configured alone
+		priority, ok := q.priorities[t.queue]
+		if t.state != TaskStatePending || q.paused[t.queue] || !ok {
+			continue
+		}
+		if best == nil || priority > q.priorities[best.queue] ||
+			(priority == q.priorities[best.queue] && t.seq < best.seq) {
+			best = t
+		}
+	}
+
+	if best != nil {
+		best.state = TaskStateActive
+	}
+	return best, wait
+}
+
+// run processes t with handler, turning panics into errors
+func (q *MemoryQueue) run(handler asynq.Handler, t *memoryTask) (err error) {
+	ctx, cancel := context.WithTimeout(q.running, t.timeout)
+	defer cancel()
+	if !t.deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(ctx, t.deadline)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler.ProcessTask(ctx, t.task)
+}
+
+// finish records the outcome of running t
+func (q *MemoryQueue) finish(t *memoryTask, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	if err == nil {
+		if t.retention > 0 {
+			t.state = TaskStateCompleted
+			t.completedAt = now
+		} else {
+			delete(q.tasks, t.id)
+		}
+		return
+	}
+
+	t.lastErr = err.Error()
+	t.lastFailedAt = now
+	ctx := taskContext(context.Background(), t.task)
+	attrs := []any{"task_id", t.id, "type", t.task.Type(), "queue", t.queue, "error", err}
+	if t.retried >= t.maxRetry || errors.Is(err, asynq.SkipRetry) {
+		t.state = TaskStateArchived
+		slog.ErrorContext(ctx, "Task failed and was archived", append(attrs, "retried", t.retried)...)
+		return
+	}
+
+	t.retried++
+	t.state = TaskStateRetry
+	t.nextProcessAt = now.Add(q.retryDelay(t.retried, err, t.task))
+	slog.WarnContext(ctx, "Task failed and will be retried", append(attrs, "retry", t.retried, "max_retry", t.maxRetry)...)
+}
+
+func (t *memoryTask) info() TaskDetails {
+	return TaskDetails{
+		ID:            t.id,
+		Queue:         t.queue,
+		Type:          t.task.Type(),
+		Payload:       payloadJSON(t.task.Payload()),
+		State:         t.state,
+		MaxRetry:      t.maxRetry,
+		Retried:       t.retried,
+		LastError:     t.lastErr,
+		LastFailedAt:  timeOrNil(t.lastFailedAt),
+		NextProcessAt: timeOrNil(t.nextProcessAt),
+		CompletedAt:   timeOrNil(t.completedAt),
+	}
+}
+
+// knownQueue reports whether queue is configured or has tasks. Callers hold q.mu.
+func (q *MemoryQueue) knownQueue(queue string) bool {
+	if _, ok := q.priorities[queue]; ok {
+		return true
+	}
+	for _, t := range q.tasks {
+		if t.queue == queue {
+			return true
+		}
+	}
+	return false
+}
+
+// task returns the task id in queue. Callers hold q.mu.
+func (q *MemoryQueue) task(queue, id string) (*memoryTask, error) {
+	if !q.knownQueue(queue) {
+		return nil, ErrQueueNotFound
+	}
+	t, ok := q.tasks[id]
+	if !ok || t.queue != queue {
+		return nil, ErrTaskNotFound
+	}
+	return t, nil
+}
+
+func (q *MemoryQueue) Queues(ctx context.Context) ([]QueueStats, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	byName := make(map[string]*QueueStats)
+	stat := func(queue string) *QueueStats {
+		if byName[queue] == nil {
+			byName[queue] = &QueueStats{Queue: queue, Priority: q.priorities[queue], Paused: q.paused[queue]}
+		}
+		return byName[queue]
+	}
+	for queue := range q.priorities {
+		stat(queue)
+	}
+
+	now := time.Now()
+	for _, t := range q.tasks {
+		s := stat(t.queue)
+		s.Size++
+		switch t.state {
+		case TaskStatePending:
+			s.Pending++
+			// Pending tasks wait from their enqueue or due time, which the
+			// queue does not track separately; report due time latency
+			if !t.nextProcessAt.IsZero() {
+				s.LatencySeconds = max(s.LatencySeconds, now.Sub(t.nextProcessAt).Seconds())
+			}
+		case TaskStateActive:
+			s.Active++
+		case TaskStateScheduled:
+			s.Scheduled++
+		case TaskStateRetry:
+			s.Retry++
+		case TaskStateArchived:
+			s.Archived++
+		case TaskStateCompleted:
+			s.Completed++
+		}
+	}
+
+	stats := make([]QueueStats, 0, len(byName))
+	for _, s := range byName {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(a, b int) bool {
+		if stats[a].Priority != stats[b].Priority {
+			return stats[a].Priority > stats[b].Priority
+		}
+		return stats[a].Queue < stats[b].Queue
+	})
+	return stats, nil
+}
+
+func (q *MemoryQueue) ListTasks(ctx context.Context, queue, state string, page, perPage int) ([]TaskDetails, error) {
+	if !isTaskState(state) {
+		return nil, ErrInvalidTaskState
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.knownQueue(queue) {
+		return nil, ErrQueueNotFound
+	}
+
+	var matching []*memoryTask
+	for _, t := range q.tasks {
+		if t.queue == queue && t.state == state {
+			matching = append(matching, t)
+		}
+	}
+	sort.Slice(matching, func(a, b int) bool { return matching[a].seq < matching[b].seq })
+
+	tasks := []TaskDetails{}
+	for i := (page - 1) * perPage; i >= 0 && i < len(matching) && len(tasks) < perPage; i++ {
+		tasks = append(tasks, matching[i].info())
+	}
+	return tasks, nil
+}
+
+func (q *MemoryQueue) GetTask(ctx context.Context, queue, id string) (TaskDetails, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, err := q.task(queue, id)
+	if err != nil {
+		return TaskDetails{}, err
+	}
+	return t.info(), nil
+}
+
+func (q *MemoryQueue) RunTask(ctx context.Context, queue, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, err := q.task(queue, id)
+	if err != nil {
+		return err
+	}
+	switch t.state {
+	case TaskStateScheduled, TaskStateRetry, TaskStateArchived:
+		t.state = TaskStatePending
+		t.nextProcessAt = time.Time{}
+		q.signal()
+		return nil
+	}
+	return ErrTaskStateConflict
+}
+
+func (q *MemoryQueue) DeleteTask(ctx context.Context, queue, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	t, err := q.task(queue, id)
+	if err != nil {
+		return err
+	}
+	if t.state == TaskStateActive {
+		return ErrTaskStateConflict
+	}
+	delete(q.tasks, id)
+	return nil
+}
+
+func (q *MemoryQueue) RunArchivedTasks(ctx context.Context, queue string) (int, error) {
+	return q.eachArchived(queue, func(t *memoryTask) {
+		t.state = TaskStatePending
+		q.signal()
+	})
+}
+
+func (q *MemoryQueue) DeleteArchivedTasks(ctx context.Context, queue string) (int, error) {
+	return q.eachArchived(queue, func(t *memoryTask) {
+		delete(q.tasks, t.id)
+	})
+}
+
+// eachArchived applies fn to every archived task in queue, holding q.mu
+func (q *MemoryQueue) eachArchived(queue string, fn func(*memoryTask)) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.knownQueue(queue) {
+		return 0, ErrQueueNotFound
+	}
+	count := 0
+	for _, t := range q.tasks {
+		if t.queue == queue && t.state == TaskStateArchived {
+			fn(t)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *MemoryQueue) PauseQueue(ctx context.Context, queue string) error {
+	return q.setPaused(queue, true)
+}
+
+func (q *MemoryQueue) ResumeQueue(ctx context.Context, queue string) error {
+	return q.setPaused(queue, false)
+}
+
+func (q *MemoryQueue) setPaused(queue string, paused bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.knownQueue(queue) {
+		return ErrQueueNotFound
+	}
+	q.paused[queue] = paused
+	q.signal()
+	return nil
+}
+
+func isTaskState(state string) bool {
+	for _, s := range TaskStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
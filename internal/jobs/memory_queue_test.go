@@ -0,0 +1,226 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newTestMemoryQueue(t *testing.T, handler asynq.HandlerFunc) *MemoryQueue {
+	t.Helper()
+	q := NewMemoryQueue(Options{Concurrency: 2})
+	q.retryDelay = func(int, error, *asynq.Task) time.Duration { return 0 }
+	if err := q.Start(handler); err != nil {
+		t.Fatalf("Failed to start queue: %v", err)
+	}
+	t.Cleanup(func() { q.Shutdown(context.Background()) })
+	return q
+}
+
+func TestMemoryQueueRunsTasks(t *testing.T) {
+	done := make(chan string, 1)
+	q := newTestMemoryQueue(t, func(ctx context.Context, task *asynq.Task) error {
+		done <- string(task.Payload())
+		return nil
+	})
+
+	ctx := context.Background()
+	if _, err := q.EnqueueContext(ctx, asynq.NewTask("test", []byte("hello")), asynq.TaskID("once"), asynq.Retention(time.Hour)); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	select {
+	case payload := <-done:
+		if payload != "hello" {
+			t.Errorf("Expected payload hello, got %q", payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Task was not processed")
+	}
+
+	// The retained task keeps its ID, so the same run cannot be enqueued twice
+	waitFor(t, "completion", func() bool {
+		task, err := q.GetTask(ctx, "default", "once")
+		return err == nil && task.State == TaskStateCompleted
+	})
+	if _, err := q.EnqueueContext(ctx, asynq.NewTask("test", nil), asynq.TaskID("once")); !errors.Is(err, asynq.ErrTaskIDConflict) {
+		t.Errorf("Expected ErrTaskIDConflict, got %v", err)
+	}
+}
+
+func TestMemoryQueueRetriesThenArchives(t *testing.T) {
+	attempts := make(chan struct{}, 10)
+	q := newTestMemoryQueue(t, func(ctx context.Context, task *asynq.Task) error {
+		attempts <- struct{}{}
+		return fmt.Errorf("boom")
+	})
+
+	ctx := context.Background()
+	info, err := q.EnqueueContext(ctx, asynq.NewTask("test", nil), asynq.MaxRetry(2))
+	if err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	waitFor(t, "archival", func() bool {
+		task, err := q.GetTask(ctx, "default", info.ID)
+		return err == nil && task.State == TaskStateArchived
+	})
+
+	task, _ := q.GetTask(ctx, "default", info.ID)
+	if len(attempts) != 3 || task.Retried != 2 || task.LastError != "boom" {
+		t.Errorf("Expected 3 attempts and 2 retries, got %d attempts and %+v", len(attempts), task)
+	}
+
+	// Running archived tasks puts them back on the queue
+	if n, err := q.RunArchivedTasks(ctx, "default"); err != nil || n != 1 {
+		t.Fatalf("Expected 1 task run, got %d: %v", n, err)
+	}
+	waitFor(t, "another attempt", func() bool { return len(attempts) == 4 })
+
+	waitFor(t, "archival", func() bool {
+		task, err := q.GetTask(ctx, "default", info.ID)
+		return err == nil && task.State == TaskStateArchived
+	})
+	if n, err := q.DeleteArchivedTasks(ctx, "default"); err != nil || n != 1 {
+		t.Fatalf("Expected 1 task deleted, got %d: %v", n, err)
+	}
+	if _, err := q.GetTask(ctx, "default", info.ID); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func TestMemoryQueueSkipRetry(t *testing.T) {
+	q := newTestMemoryQueue(t, func(ctx context.Context, task *asynq.Task) error {
+		return fmt.Errorf("bad payload: %w", asynq.SkipRetry)
+	})
+
+	ctx := context.Background()
+	info, err := q.EnqueueContext(ctx, asynq.NewTask(TypeAggregateData, nil))
+	if err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	if info.MaxRetry != 5 {
+		t.Errorf("Expected the task type's MaxRetry of 5, got %d", info.MaxRetry)
+	}
+	waitFor(t, "archival", func() bool {
+		task, err := q.GetTask(ctx, "default", info.ID)
+		return err == nil && task.State == TaskStateArchived && task.Retried == 0
+	})
+}
+
+func TestMemoryQueuePause(t *testing.T) {
+	done := make(chan struct{}, 1)
+	q := newTestMemoryQueue(t, func(ctx context.Context, task *asynq.Task) error {
+		done <- struct{}{}
+		return nil
+	})
+
+	ctx := context.Background()
+	if err := q.PauseQueue(ctx, "low"); err != nil {
+		t.Fatalf("Failed to pause queue: %v", err)
+	}
+	if _, err := q.EnqueueContext(ctx, asynq.NewTask("test", nil), asynq.Queue("low")); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+
+	select {
+	case <-done:
+		t.Fatal("Task ran while its queue was paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	tasks, err := q.ListTasks(ctx, "low", TaskStatePending, 1, 10)
+	if err != nil || len(tasks) != 1 {
+		t.Fatalf("Expected 1 pending task, got %v: %v", tasks, err)
+	}
+
+	if err := q.ResumeQueue(ctx, "low"); err != nil {
+		t.Fatalf("Failed to resume queue: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Task was not processed after resuming")
+	}
+
+	if err := q.PauseQueue(ctx, "missing"); !errors.Is(err, ErrQueueNotFound) {
+		t.Errorf("Expected ErrQueueNotFound, got %v", err)
+	}
+}
+
+func TestMemoryQueueRunsConfiguredQueues(t *testing.T) {
+	done := make(chan string, 2)
+	q := NewMemoryQueue(Options{Concurrency: 1, Priorities: map[string]int{"default": 1}})
+	if err := q.Start(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		done <- task.Type()
+		return nil
+	})); err != nil {
+		t.Fatalf("Failed to start queue: %v", err)
+	}
+	t.Cleanup(func() { q.Shutdown(context.Background()) })
+
+	ctx := context.Background()
+	if _, err := q.EnqueueContext(ctx, asynq.NewTask("unconfigured", nil), asynq.Queue("critical")); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	if _, err := q.EnqueueContext(ctx, asynq.NewTask("configured", nil)); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+
+	if got := <-done; got != "configured" {
+		t.Fatalf("Expected the default queue's task, got %s", got)
+	}
+	select {
+	case <-done:
+		t.Fatal("Task ran in a queue that is not configured")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	queues, err := q.Queues(ctx)
+	if err != nil || len(queues) != 2 || queues[0].Queue != "default" || queues[0].Priority != 1 {
+		t.Errorf("Expected the default queue first, then critical, got %+v: %v", queues, err)
+	}
+}
+
+func TestMemoryQueueShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	q := newTestMemoryQueue(t, func(ctx context.Context, task *asynq.Task) error {
+		close(started)
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	if _, err := q.EnqueueContext(context.Background(), asynq.NewTask("test", nil)); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	<-started
+
+	// A task that outlives the drain timeout is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected DeadlineExceeded, got %v", err)
+	}
+	close(release)
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected workers to stop after cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"saas-go-app/internal/models"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	tasksProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "jobs_tasks_processed_total",
+		Help: "Task attempts processed by this process, by task type and result.",
+	}, []string{"type", "result"})
+	tasksInProgress = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "jobs_tasks_in_progress",
+		Help: "Tasks this process is running, by task type.",
+	}, []string{"type"})
+	taskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "jobs_task_duration_seconds",
+		Help:    "How long task attempts took, by task type.",
+		Buckets: []float64{0.1, 0.5, 1, 5, 15, 60, 300, 900},
+	}, []string{"type"})
+
+	// Business gauges, set by the aggregation job
+	businessCustomers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "business_customers",
+		Help: "Customers of every organization at the end of the latest aggregated day.",
+	})
+	businessAccounts = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "business_accounts",
+		Help: "Accounts of every organization at the end of the latest aggregated day, by status.",
+	}, []string{"status"})
+	businessDay = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "business_metrics_day_timestamp_seconds",
+		Help: "Start of the latest aggregated day (UTC) the business gauges describe.",
+	})
+
+	// latestDay is the day the business gauges describe
+	latestDay   time.Time
+	latestDayMu sync.Mutex
+)
+
+// recordTotals sets the business gauges to totals, the aggregate of day,
+// unless they already describe a later day, as after a backfill
+func recordTotals(day time.Time, totals models.DailyTotals) {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	latestDayMu.Lock()
+	defer latestDayMu.Unlock()
+	if day.Before(latestDay) {
+		return
+	}
+	latestDay = day
+
+	businessDay.Set(float64(day.Unix()))
+	businessCustomers.Set(float64(totals.Customers))
+	businessAccounts.Reset()
+	for status, count := range totals.AccountsByStatus {
+		businessAccounts.WithLabelValues(status).Set(float64(count))
+	}
+}
+
+// instrument records metrics for every task attempt next handles
+func instrument(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		inProgress := tasksInProgress.WithLabelValues(task.Type())
+		inProgress.Inc()
+		defer inProgress.Dec()
+
+		start := time.Now()
+		err := next.ProcessTask(ctx, task)
+		taskDuration.WithLabelValues(task.Type()).Observe(time.Since(start).Seconds())
+
+		result := "success"
+		if err != nil {
+			result = "failure"
+		}
+		tasksProcessed.WithLabelValues(task.Type(), result).Inc()
+		return err
+	})
+}
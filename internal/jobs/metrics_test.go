@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"saas-go-app/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordTotalsKeepsLatestDay(t *testing.T) {
+	today := time.Now().UTC()
+	recordTotals(today, models.DailyTotals{Customers: 10, AccountsByStatus: map[string]int64{"active": 7, "closed": 1}})
+	// A backfill of an earlier day leaves the gauges alone
+	recordTotals(today.AddDate(0, 0, -3), models.DailyTotals{Customers: 4})
+
+	if v := testutil.ToFloat64(businessCustomers); v != 10 {
+		t.Errorf("Expected 10 customers, got %v", v)
+	}
+	if v := testutil.ToFloat64(businessAccounts.WithLabelValues("active")); v != 7 {
+		t.Errorf("Expected 7 active accounts, got %v", v)
+	}
+
+	// Statuses without accounts disappear
+	recordTotals(today, models.DailyTotals{Customers: 11, AccountsByStatus: map[string]int64{"active": 8}})
+	if n := testutil.CollectAndCount(businessAccounts); n != 1 {
+		t.Errorf("Expected 1 account status, got %d", n)
+	}
+}
@@ -0,0 +1,216 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"saas-go-app/internal/logging"
+	"saas-go-app/internal/repository"
+
+	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// DefaultConcurrency is how many tasks a process runs at once
+const DefaultConcurrency = 10
+
+// DefaultQueuePriorities are the queues workers process, with their
+// relative weights, unless Options say otherwise
+var DefaultQueuePriorities = map[string]int{
+	"critical": 6,
+	"default":  3,
+	"low":      1,
+}
+
+// Options configure how a queue processes tasks
+type Options struct {
+	// Concurrency is how many tasks a process runs at once
+	Concurrency int
+	// Priorities are the queues to process, with their relative weights.
+	// Tasks in other queues are kept but not run.
+	Priorities map[string]int
+}
+
+// withDefaults fills in unset options
+func (o Options) withDefaults() Options {
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultConcurrency
+	}
+	if len(o.Priorities) == 0 {
+		o.Priorities = DefaultQueuePriorities
+	}
+	return o
+}
+
+// TaskOptions are the default options of each task type, applied by every
+// Queue before the options passed to EnqueueContext
+var TaskOptions = map[string][]asynq.Option{
+	// Retried with exponential backoff, then archived for inspection
+	// through the admin API
+	TypeAggregateData: {asynq.MaxRetry(5), asynq.Timeout(10 * time.Minute)},
+}
+
+// TaskMeta is embedded in every task payload. RequestID is the ID of the
+// request that enqueued the task; the task's log lines carry it, so a
+// request can be traced into the worker. TraceContext carries the
+// enqueuing span, as W3C traceparent and tracestate, and the task's span
+// links to it.
+type TaskMeta struct {
+	RequestID    string            `json:"request_id,omitempty"`
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+}
+
+// NewTaskMeta returns the metadata of a task enqueued from ctx
+func NewTaskMeta(ctx context.Context) TaskMeta {
+	meta := TaskMeta{RequestID: logging.RequestID(ctx)}
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) > 0 {
+		meta.TraceContext = carrier
+	}
+	return meta
+}
+
+// taskMeta returns the metadata in task's payload
+func taskMeta(task *asynq.Task) TaskMeta {
+	var meta TaskMeta
+	_ = json.Unmarshal(task.Payload(), &meta)
+	return meta
+}
+
+// taskContext returns ctx carrying the request ID in task's payload
+func taskContext(ctx context.Context, task *asynq.Task) context.Context {
+	if meta := taskMeta(task); meta.RequestID != "" {
+		return logging.WithRequestID(ctx, meta.RequestID)
+	}
+	return ctx
+}
+
+// Queue enqueues tasks and runs them with a handler. AsynqQueue keeps tasks
+// in Redis and shares them between processes; MemoryQueue runs them inside
+// the process when Redis is not configured.
+type Queue interface {
+	// EnqueueContext enqueues task. It returns asynq.ErrTaskIDConflict when
+	// a task with the same asynq.TaskID option already exists.
+	EnqueueContext(ctx context.Context, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error)
+	// Start runs enqueued tasks with handler in the background
+	Start(handler asynq.Handler) error
+	// Shutdown stops taking new tasks and waits for running ones to finish.
+	// Tasks still running when ctx is done are cancelled and retried later.
+	Shutdown(ctx context.Context) error
+	// Inspector reports on and manages the queue's tasks
+	Inspector() Inspector
+	Close() error
+}
+
+// NewQueue returns a Redis-backed queue when redisURL is set and an
+// in-process queue otherwise
+func NewQueue(redisURL string, opts Options) (Queue, error) {
+	if redisURL == "" {
+		slog.Warn("REDIS_URL not set, background jobs will run in process and are lost on restart")
+		return NewMemoryQueue(opts), nil
+	}
+	return NewAsynqQueue(redisURL, opts)
+}
+
+// RedisOptions converts a Redis URL such as redis://:password@host:6379/0,
+// the form Heroku sets REDIS_URL in, to asynq's connection options. The
+// error leaves out the URL, which carries the password.
+func RedisOptions(redisURL string) (asynq.RedisConnOpt, error) {
+	redis, err := asynq.ParseRedisURI(redisURL)
+	if err != nil {
+		return nil, errors.New("invalid Redis URL, expected redis://[:password@]host:port[/db] or rediss://")
+	}
+	return redis, nil
+}
+
+// NewServeMux returns the handler for every task type, tracing and
+// recording Prometheus metrics for each attempt and passing handlers the
+// enqueuing request's ID in their context
+func NewServeMux(metrics repository.DailyMetricsRepository) *asynq.ServeMux {
+	mux := asynq.NewServeMux()
+	mux.Use(withTaskContext, instrument)
+	mux.Handle(TypeAggregateData, NewAggregationHandler(metrics))
+	return mux
+}
+
+// withTaskOptions prepends the default options of task's type to opts
+func withTaskOptions(task *asynq.Task, opts []asynq.Option) []asynq.Option {
+	defaults := TaskOptions[task.Type()]
+	return append(append(make([]asynq.Option, 0, len(defaults)+len(opts)), defaults...), opts...)
+}
+
+// AsynqQueue is a Queue backed by Redis through asynq
+type AsynqQueue struct {
+	redis     asynq.RedisConnOpt
+	opts      Options
+	client    *asynq.Client
+	inspector *AsynqInspector
+	server    *asynq.Server
+}
+
+// NewAsynqQueue creates a queue on the Redis server at redisURL that, once
+// started, processes tasks as opts say
+func NewAsynqQueue(redisURL string, opts Options) (*AsynqQueue, error) {
+	redis, err := RedisOptions(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
+	return &AsynqQueue{
+		redis:     redis,
+		opts:      opts,
+		client:    asynq.NewClient(redis),
+		inspector: NewAsynqInspector(redis, opts.Priorities),
+	}, nil
+}
+
+func (q *AsynqQueue) EnqueueContext(ctx context.Context, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	return q.client.EnqueueContext(ctx, task, withTaskOptions(task, opts)...)
+}
+
+func (q *AsynqQueue) Start(handler asynq.Handler) error {
+	q.server = asynq.NewServer(q.redis, asynq.Config{
+		Concurrency:  q.opts.Concurrency,
+		Queues:       q.opts.Priorities,
+		ErrorHandler: asynq.ErrorHandlerFunc(ReportTaskError),
+		// Let running tasks finish; Shutdown's context bounds the wait
+		ShutdownTimeout: defaultTimeout,
+	})
+	slog.Info("Starting background job processor", "concurrency", q.opts.Concurrency, "queues", q.opts.Priorities)
+	return q.server.Start(handler)
+}
+
+// Shutdown waits for running tasks until ctx is done. Tasks still running
+// then are abandoned with the process; asynq retries them once their lease
+// expires.
+func (q *AsynqQueue) Shutdown(ctx context.Context) error {
+	if q.server == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		q.server.Shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *AsynqQueue) Inspector() Inspector {
+	return q.inspector
+}
+
+func (q *AsynqQueue) Close() error {
+	q.inspector.Close()
+	return q.client.Close()
+}
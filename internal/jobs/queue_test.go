@@ -0,0 +1,125 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"saas-go-app/internal/logging"
+	"saas-go-app/internal/tracing"
+
+	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRedisOptions(t *testing.T) {
+	redis, err := RedisOptions("redis://:hunter2@redis.example.com:6379/2")
+	if err != nil {
+		t.Fatalf("Failed to parse Redis URL: %v", err)
+	}
+	opt, ok := redis.(asynq.RedisClientOpt)
+	if !ok || opt.Addr != "redis.example.com:6379" || opt.Password != "hunter2" || opt.DB != 2 || opt.TLSConfig != nil {
+		t.Errorf("Unexpected options for redis://: %+v", redis)
+	}
+
+	redis, err = RedisOptions("rediss://:hunter2@redis.example.com:6380")
+	if err != nil {
+		t.Fatalf("Failed to parse Redis URL: %v", err)
+	}
+	if opt, ok := redis.(asynq.RedisClientOpt); !ok || opt.Addr != "redis.example.com:6380" || opt.TLSConfig == nil {
+		t.Errorf("Expected TLS for rediss://, got %+v", redis)
+	}
+
+	for _, invalid := range []string{"localhost:6379", "http://localhost:6379", "redis://localhost:6379/cache", "redis://:hunter2@%zz"} {
+		_, err := RedisOptions(invalid)
+		if err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		} else if strings.Contains(err.Error(), "hunter2") {
+			t.Errorf("Expected the error to leave out the password, got %v", err)
+		}
+	}
+}
+
+func TestTaskCarriesRequestID(t *testing.T) {
+	ctx := logging.WithRequestID(context.Background(), "req-42")
+	task, err := NewAggregationTask(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	var got string
+	handler := withTaskContext(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		got = logging.RequestID(ctx)
+		return nil
+	}))
+	if err := handler.ProcessTask(context.Background(), task); err != nil {
+		t.Fatalf("Failed to process task: %v", err)
+	}
+	if got != "req-42" {
+		t.Errorf("Expected the handler to see request ID req-42, got %q", got)
+	}
+}
+
+func TestTaskSpanLinksToEnqueuingSpan(t *testing.T) {
+	var out bytes.Buffer
+	exporter, err := tracing.NewStdoutExporter(&out)
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+	shutdown, err := tracing.Install(context.Background(), exporter, "jobs-test")
+	if err != nil {
+		t.Fatalf("Failed to install tracing: %v", err)
+	}
+
+	ctx, request := otel.Tracer("test").Start(context.Background(), "request")
+	task, err := NewAggregationTask(ctx, time.Now())
+	request.End()
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	handler := withTaskContext(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			t.Error("Expected the handler to run in a span")
+		}
+		return nil
+	}))
+	if err := handler.ProcessTask(context.Background(), task); err != nil {
+		t.Fatalf("Failed to process task: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to flush spans: %v", err)
+	}
+
+	type spanContext struct{ TraceID, SpanID string }
+	type exportedSpan struct {
+		Name        string
+		SpanContext spanContext
+		Links       []struct{ SpanContext spanContext }
+	}
+	var processed *exportedSpan
+	for decoder := json.NewDecoder(&out); decoder.More(); {
+		var span exportedSpan
+		if err := decoder.Decode(&span); err != nil {
+			t.Fatalf("Failed to decode span: %v", err)
+		}
+		if span.Name == "process "+TypeAggregateData {
+			processed = &span
+		}
+	}
+	if processed == nil {
+		t.Fatalf("Expected a span for the task, got %s", out.String())
+	}
+
+	want := request.SpanContext()
+	if len(processed.Links) != 1 || processed.Links[0].SpanContext.SpanID != want.SpanID().String() {
+		t.Errorf("Expected the task span to link to span %s, got %+v", want.SpanID(), processed.Links)
+	}
+	if processed.SpanContext.TraceID == want.TraceID().String() {
+		t.Error("Expected the task to start a new trace")
+	}
+}
@@ -0,0 +1,270 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/robfig/cron/v3"
+)
+
+// scheduleRetention keeps processed periodic tasks, and with them their task
+// IDs, long enough that a late instance cannot enqueue the same run again
+const scheduleRetention = 24 * time.Hour
+
+// Enqueuer is the part of a Queue the scheduler needs
+type Enqueuer interface {
+	EnqueueContext(ctx context.Context, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error)
+}
+
+// PeriodicJob describes a task enqueued on a cron schedule
+type PeriodicJob struct {
+	// Name identifies the job; it is part of every run's task ID
+	Name string
+	// Spec is a standard 5-field cron expression or descriptor such as
+	// "@hourly", evaluated in UTC unless prefixed with CRON_TZ=
+	Spec  string
+	Queue string
+	// NewTask builds the task for the run scheduled at the given time
+	NewTask func(ctx context.Context, scheduledAt time.Time) (*asynq.Task, error)
+}
+
+// DefaultPeriodicJobs returns the application's periodic jobs
+func DefaultPeriodicJobs() []PeriodicJob {
+	return []PeriodicJob{
+		{
+			// Shortly after midnight UTC, once the previous day is complete
+			Name:  "daily_metrics",
+			Spec:  "15 0 * * *",
+			Queue: "default",
+			NewTask: func(ctx context.Context, scheduledAt time.Time) (*asynq.Task, error) {
+				return NewAggregationTask(ctx, scheduledAt.AddDate(0, 0, -1))
+			},
+		},
+	}
+}
+
+// ScheduledJob is the state of a registered periodic job
+type ScheduledJob struct {
+	Name     string    `json:"name"`
+	Spec     string    `json:"spec"`
+	TaskType string    `json:"task_type"`
+	Queue    string    `json:"queue"`
+	NextRun  time.Time `json:"next_run"`
+	// LastRun is the scheduled time of the last run this instance handled
+	LastRun *time.Time `json:"last_run,omitempty"`
+	// LastTaskID is the asynq task ID of that run
+	LastTaskID string `json:"last_task_id,omitempty"`
+	// LastEnqueuedBy is "this instance", or "another instance" when its
+	// enqueue lost to an identical one elsewhere
+	LastEnqueuedBy string `json:"last_enqueued_by,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+}
+
+// Scheduler enqueues periodic jobs. Every instance of the application may
+// run one: each run gets a task ID derived from the job name and scheduled
+// time, so asynq accepts the first enqueue and rejects the rest with
+// ErrTaskIDConflict, and every run is enqueued exactly once.
+type Scheduler struct {
+	client Enqueuer
+
+	mu      sync.Mutex
+	entries []*scheduleEntry
+	running bool
+}
+
+type scheduleEntry struct {
+	job      PeriodicJob
+	schedule cron.Schedule
+	state    ScheduledJob
+}
+
+// NewScheduler creates a scheduler enqueueing through client. A nil client
+// keeps the registry but never enqueues.
+func NewScheduler(client Enqueuer) *Scheduler {
+	return &Scheduler{client: client}
+}
+
+// Register adds job to the schedule. A spec of "off" disables it.
+func (s *Scheduler) Register(job PeriodicJob) error {
+	spec := job.Spec
+	if spec == "off" {
+		slog.Info("Periodic job disabled", "job", job.Name)
+		return nil
+	}
+
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for job %s: %w", spec, job.Name, err)
+	}
+
+	// Build a sample task to learn its type for the registry
+	sample, err := job.NewTask(context.Background(), time.Now())
+	if err != nil {
+		return fmt.Errorf("build task for job %s: %w", job.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.entries {
+		if entry.job.Name == job.Name {
+			return fmt.Errorf("job %s is already scheduled", job.Name)
+		}
+	}
+	if job.Queue == "" {
+		job.Queue = "default"
+	}
+	s.entries = append(s.entries, &scheduleEntry{
+		job:      job,
+		schedule: schedule,
+		state: ScheduledJob{
+			Name:     job.Name,
+			Spec:     spec,
+			TaskType: sample.Type(),
+			Queue:    job.Queue,
+			NextRun:  schedule.Next(time.Now()),
+		},
+	})
+	return nil
+}
+
+// Run enqueues jobs as they fall due until ctx is cancelled. Runs missed
+// while no instance was running are skipped, not caught up.
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.client == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.running = true
+	now := time.Now()
+	for _, entry := range s.entries {
+		entry.state.NextRun = entry.schedule.Next(now)
+	}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	for {
+		next, ok := s.nextRun()
+		if !ok {
+			<-ctx.Done()
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.enqueueDue(ctx, time.Now())
+	}
+}
+
+// Running reports whether Run is active on this instance
+func (s *Scheduler) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// Jobs returns the registered jobs, ordered by name
+func (s *Scheduler) Jobs() []ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]ScheduledJob, len(s.entries))
+	for i, entry := range s.entries {
+		jobs[i] = entry.state
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
+
+// nextRun returns the earliest next run of any job
+func (s *Scheduler) nextRun() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, entry := range s.entries {
+		if next.IsZero() || entry.state.NextRun.Before(next) {
+			next = entry.state.NextRun
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// enqueueDue enqueues every job whose next run is at or before now
+func (s *Scheduler) enqueueDue(ctx context.Context, now time.Time) {
+	type run struct {
+		entry       *scheduleEntry
+		scheduledAt time.Time
+	}
+
+	s.mu.Lock()
+	var due []run
+	for _, entry := range s.entries {
+		if !entry.state.NextRun.After(now) {
+			due = append(due, run{entry, entry.state.NextRun})
+			entry.state.NextRun = entry.schedule.Next(now)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, r := range due {
+		taskID, enqueuedBy, err := s.enqueue(ctx, r.entry.job, r.scheduledAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to enqueue periodic job", "job", r.entry.job.Name, "scheduled_at", r.scheduledAt, "error", err)
+		}
+
+		s.mu.Lock()
+		scheduledAt := r.scheduledAt
+		r.entry.state.LastRun = &scheduledAt
+		r.entry.state.LastTaskID = taskID
+		r.entry.state.LastEnqueuedBy = enqueuedBy
+		r.entry.state.LastError = ""
+		if err != nil {
+			r.entry.state.LastError = err.Error()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// enqueue enqueues one run of job, reporting which instance enqueued it
+func (s *Scheduler) enqueue(ctx context.Context, job PeriodicJob, scheduledAt time.Time) (string, string, error) {
+	taskID := fmt.Sprintf("%s:%d", job.Name, scheduledAt.Unix())
+
+	task, err := job.NewTask(ctx, scheduledAt)
+	if err != nil {
+		return taskID, "", err
+	}
+
+	_, err = s.client.EnqueueContext(ctx, task,
+		asynq.TaskID(taskID),
+		asynq.Queue(job.Queue),
+		asynq.Retention(scheduleRetention),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return taskID, "another instance", nil
+	}
+	if err != nil {
+		return taskID, "", err
+	}
+
+	slog.InfoContext(ctx, "Enqueued periodic job", "job", job.Name, "task_id", taskID)
+	return taskID, "this instance", nil
+}
@@ -0,0 +1,109 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// fakeQueue rejects duplicate task IDs like asynq does
+type fakeQueue struct {
+	mu    sync.Mutex
+	tasks map[string]*asynq.Task
+}
+
+func (q *fakeQueue) EnqueueContext(ctx context.Context, task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	var id string
+	for _, opt := range opts {
+		if opt.Type() == asynq.TaskIDOpt {
+			id = opt.Value().(string)
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.tasks[id]; ok {
+		return nil, asynq.ErrTaskIDConflict
+	}
+	q.tasks[id] = task
+	return &asynq.TaskInfo{ID: id}, nil
+}
+
+func TestSchedulerEnqueuesEachRunOnce(t *testing.T) {
+	queue := &fakeQueue{tasks: make(map[string]*asynq.Task)}
+	job := DefaultPeriodicJobs()[0]
+
+	// Two instances sharing one queue
+	first, second := NewScheduler(queue), NewScheduler(queue)
+	for _, s := range []*Scheduler{first, second} {
+		if err := s.Register(job); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+		s.entries[0].state.NextRun = time.Date(2024, 3, 2, 0, 15, 0, 0, time.UTC)
+	}
+
+	now := time.Date(2024, 3, 2, 0, 15, 1, 0, time.UTC)
+	first.enqueueDue(context.Background(), now)
+	second.enqueueDue(context.Background(), now)
+
+	if len(queue.tasks) != 1 {
+		t.Fatalf("Expected 1 enqueued task, got %d", len(queue.tasks))
+	}
+	if got := first.Jobs()[0].LastEnqueuedBy; got != "this instance" {
+		t.Errorf("Expected first instance to enqueue, got %q", got)
+	}
+	status := second.Jobs()[0]
+	if status.LastEnqueuedBy != "another instance" || status.LastError != "" {
+		t.Errorf("Expected second instance to lose without error, got %+v", status)
+	}
+	if want := time.Date(2024, 3, 3, 0, 15, 0, 0, time.UTC); !status.NextRun.Equal(want) {
+		t.Errorf("Expected next run %s, got %s", want, status.NextRun)
+	}
+
+	// The nightly run aggregates the day that just ended
+	task := queue.tasks[status.LastTaskID]
+	if task == nil || task.Type() != TypeAggregateData {
+		t.Fatalf("Expected an %s task under %q", TypeAggregateData, status.LastTaskID)
+	}
+	if string(task.Payload()) != `{"date":"2024-03-01T00:15:00Z"}` {
+		t.Errorf("Unexpected payload %s", task.Payload())
+	}
+}
+
+func TestSchedulerRegister(t *testing.T) {
+	s := NewScheduler(nil)
+	job := DefaultPeriodicJobs()[0]
+
+	if err := s.Register(job); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := s.Register(job); err == nil {
+		t.Error("Expected registering a job twice to fail")
+	}
+
+	job.Name = "broken"
+	job.Spec = "every day"
+	if err := s.Register(job); err == nil {
+		t.Error("Expected an invalid spec to fail")
+	}
+
+	job.Name = "overridden"
+	job.Spec = "@hourly"
+	if err := s.Register(job); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	job.Name = "disabled"
+	job.Spec = "off"
+	if err := s.Register(job); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	jobs := s.Jobs()
+	if len(jobs) != 2 || jobs[0].Name != "daily_metrics" || jobs[1].Spec != "@hourly" {
+		t.Errorf("Unexpected registry: %+v", jobs)
+	}
+}
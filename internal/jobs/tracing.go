@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("saas-go-app/internal/jobs")
+
+// withTaskContext runs each task in a span of its own, linked to the span
+// that enqueued it, with the enqueuing request's ID in its context. Tasks
+// start new traces, as they may run long after the request and be retried.
+func withTaskContext(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		meta := taskMeta(task)
+		ctx = taskContext(ctx, task)
+
+		attrs := []attribute.KeyValue{
+			semconv.MessagingSystemKey.String("asynq"),
+			semconv.MessagingOperationTypeProcess,
+			attribute.String("asynq.task.type", task.Type()),
+		}
+		if id, ok := asynq.GetTaskID(ctx); ok {
+			attrs = append(attrs, semconv.MessagingMessageID(id))
+		}
+		if queue, ok := asynq.GetQueueName(ctx); ok {
+			attrs = append(attrs, semconv.MessagingDestinationName(queue))
+		}
+		if retries, ok := asynq.GetRetryCount(ctx); ok {
+			attrs = append(attrs, attribute.Int("asynq.task.retry_count", retries))
+		}
+		if meta.RequestID != "" {
+			attrs = append(attrs, attribute.String("request_id", meta.RequestID))
+		}
+		opts := []trace.SpanStartOption{
+			trace.WithNewRoot(),
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(attrs...),
+		}
+		enqueued := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(meta.TraceContext))
+		if link := trace.LinkFromContext(enqueued); link.SpanContext.IsValid() {
+			opts = append(opts, trace.WithLinks(link))
+		}
+
+		ctx, span := tracer.Start(ctx, "process "+task.Type(), opts...)
+		defer span.End()
+
+		err := next.ProcessTask(ctx, task)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	})
+}
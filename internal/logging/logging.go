@@ -0,0 +1,87 @@
+// Package logging sets up structured logging with log/slog and carries the
+// ID of the request being served in contexts, so every log line, error
+// response and enqueued job can be traced back to it
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formats accepted by Setup
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Setup makes slog's default logger, which the log package also writes
+// through, log to standard error in format at level and above
+func Setup(format, level string) error {
+	handler, err := NewHandler(os.Stderr, format, level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// NewHandler returns a handler writing to w in format at level and above,
+// adding the request ID and trace of each record's context
+func NewHandler(w io.Writer, format, level string) (slog.Handler, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return contextHandler{slog.NewJSONHandler(w, opts)}, nil
+	case FormatText:
+		return contextHandler{slog.NewTextHandler(w, opts)}, nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, use %s or %s", format, FormatJSON, FormatText)
+}
+
+// contextHandler adds the request ID and the span carried by the context
+// to records, so log lines can be found from a trace and vice versa
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHandlerAddsRequestID(t *testing.T) {
+	var out bytes.Buffer
+	handler, err := NewHandler(&out, FormatJSON, "info")
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	logger := slog.New(handler)
+
+	logger.InfoContext(WithRequestID(context.Background(), "abc-123"), "hello", "n", 1)
+	logger.DebugContext(context.Background(), "hidden")
+
+	var line map[string]any
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("Expected one JSON line, got %q: %v", out.String(), err)
+	}
+	if line["msg"] != "hello" || line["request_id"] != "abc-123" || line["n"] != 1.0 {
+		t.Errorf("Unexpected log line: %v", line)
+	}
+
+	out.Reset()
+	span := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	logger.InfoContext(trace.ContextWithSpanContext(context.Background(), span), "traced")
+	line = nil
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("Expected one JSON line, got %q: %v", out.String(), err)
+	}
+	if line["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || line["span_id"] != "00f067aa0ba902b7" {
+		t.Errorf("Expected the line to carry the span, got %v", line)
+	}
+
+	if _, err := NewHandler(&out, "xml", "info"); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+	if _, err := NewHandler(&out, FormatText, "loud"); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
+
+func TestMiddlewareRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware(), Recovery())
+	router.GET("/fail", func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, ErrorBody(c, "bad"))
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		name, path, header string
+		want               int
+		keep               bool
+	}{
+		{"propagates the router's ID", "/fail", "9f0c2a1e-3b7d-4c55-8e2a-1d6f0b9c7e41", http.StatusBadRequest, true},
+		{"assigns an ID", "/fail", "", http.StatusBadRequest, false},
+		{"replaces an unsafe ID", "/fail", "evil\" injected=1", http.StatusBadRequest, false},
+		{"recovers panics", "/panic", "req-1", http.StatusInternalServerError, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+			}
+			id := w.Header().Get(RequestIDHeader)
+			if tt.keep && id != tt.header {
+				t.Errorf("Expected request ID %q, got %q", tt.header, id)
+			}
+			if !tt.keep && (id == "" || id == tt.header) {
+				t.Errorf("Expected a new request ID, got %q", id)
+			}
+
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["request_id"] != id || body["error"] == "" {
+				t.Errorf("Expected the error body to carry request ID %q, got %s", id, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID. Heroku's router sets it on every
+// request, so its logs and ours share IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// Middleware assigns each request an ID, taken from X-Request-ID when the
+// client or router sent a usable one, echoes it in the response, and logs
+// the request once it has been served
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		ctx := WithRequestID(c.Request.Context(), id)
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(RequestIDHeader, id)
+
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if errs := c.Errors.String(); errs != "" {
+			attrs = append(attrs, slog.String("errors", errs))
+		}
+		slog.LogAttrs(ctx, level, "request", attrs...)
+	}
+}
+
+// Recovery turns panics in handlers into logged 500 responses
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if err := recover(); err != nil {
+				slog.ErrorContext(c.Request.Context(), "panic serving request",
+					"panic", err, "stack", string(debug.Stack()))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorBody(c, "Internal server error"))
+			}
+		}()
+		c.Next()
+	}
+}
+
+// ErrorBody returns the JSON body of an error response, with the request
+// ID for clients to quote when reporting the error
+func ErrorBody(c *gin.Context, message string) gin.H {
+	body := gin.H{"error": message}
+	if id := RequestID(c.Request.Context()); id != "" {
+		body["request_id"] = id
+	}
+	return body
+}
+
+// validRequestID accepts IDs of up to 128 letters, digits and -_.: so
+// clients cannot inject arbitrary text into logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
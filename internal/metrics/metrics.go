@@ -0,0 +1,91 @@
+// Package metrics exports the Prometheus metrics of the HTTP server and the
+// database pools. Metrics owned by one package, such as the job and login
+// metrics, are defined in that package.
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// unmatchedRoute labels requests that matched no route, such as frontend
+// paths and 404s, so arbitrary paths cannot create new series
+const unmatchedRoute = "unmatched"
+
+// sizeBuckets span 100 bytes to 10 MB
+var sizeBuckets = prometheus.ExponentialBuckets(100, 10, 6)
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "How long requests took to serve, by method, route template and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	requestSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_size_bytes",
+		Help:    "Size of request bodies, by method, route template and status.",
+		Buckets: sizeBuckets,
+	}, []string{"method", "route", "status"})
+	responseSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_response_size_bytes",
+		Help:    "Size of response bodies, by method, route template and status.",
+		Buckets: sizeBuckets,
+	}, []string{"method", "route", "status"})
+	requestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Requests being served.",
+	})
+)
+
+// Middleware records the duration and sizes of every request, labeled by
+// route template, such as /api/customers/:id, rather than by path
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestsInFlight.Inc()
+		defer requestsInFlight.Dec()
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		labels := prometheus.Labels{
+			"method": c.Request.Method,
+			"route":  route,
+			"status": strconv.Itoa(c.Writer.Status()),
+		}
+		requestDuration.With(labels).Observe(time.Since(start).Seconds())
+		requestSize.With(labels).Observe(float64(max(c.Request.ContentLength, 0)))
+		responseSize.With(labels).Observe(float64(max(c.Writer.Size(), 0)))
+	}
+}
+
+var (
+	pools   = make(map[string]prometheus.Collector)
+	poolsMu sync.Mutex
+)
+
+// RegisterDBPool exports the connection stats of db labeled db_name=name:
+// go_sql_open_connections, go_sql_in_use_connections,
+// go_sql_wait_count_total and the other database/sql pool statistics.
+// Registering a pool under a name already used replaces the old pool.
+func RegisterDBPool(name string, db *sql.DB) {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+
+	if old, ok := pools[name]; ok {
+		prometheus.Unregister(old)
+	}
+	collector := collectors.NewDBStatsCollector(db, name)
+	prometheus.MustRegister(collector)
+	pools[name] = collector
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareLabelsByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/items/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "item")
+	})
+
+	for _, path := range []string{"/items/1", "/items/2", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Both items share one series; unmatched paths share another
+	if n := testutil.CollectAndCount(requestDuration); n != 2 {
+		t.Errorf("Expected 2 duration series, got %d", n)
+	}
+	if !responseSize.DeleteLabelValues(http.MethodGet, "/items/:id", "200") {
+		t.Error("Expected a response size series for the route template")
+	}
+	if !requestSize.DeleteLabelValues(http.MethodGet, unmatchedRoute, "404") {
+		t.Error("Expected a request size series for unmatched paths")
+	}
+	if v := testutil.ToFloat64(requestsInFlight); v != 0 {
+		t.Errorf("Expected no requests in flight, got %v", v)
+	}
+}
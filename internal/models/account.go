@@ -5,6 +5,7 @@ import "time"
 // Account represents an account in the system
 type Account struct {
 	ID         int       `json:"id" db:"id"`
+	OrgID      int       `json:"org_id" db:"org_id"`
 	CustomerID int       `json:"customer_id" db:"customer_id"`
 	Name       string    `json:"name" db:"name"`
 	Status     string    `json:"status" db:"status"`
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// AnalyticsOverview represents overall customer and account statistics
+type AnalyticsOverview struct {
+	TotalCustomers         int     `json:"total_customers"`
+	TotalAccounts          int     `json:"total_accounts"`
+	ActiveAccounts         int     `json:"active_accounts"`
+	InactiveAccounts       int     `json:"inactive_accounts"`
+	AvgAccountsPerCustomer float64 `json:"avg_accounts_per_customer"`
+}
+
+// CustomerAnalytics represents account statistics for a single customer
+type CustomerAnalytics struct {
+	CustomerID       int `json:"customer_id"`
+	TotalAccounts    int `json:"total_accounts"`
+	ActiveAccounts   int `json:"active_accounts"`
+	InactiveAccounts int `json:"inactive_accounts"`
+}
+
+// Daily metric names, as stored in daily_metrics
+const (
+	MetricCustomers    = "customers"
+	MetricNewCustomers = "new_customers"
+	MetricAccounts     = "accounts"
+	MetricNewAccounts  = "new_accounts"
+)
+
+// MetricDimensionAll is the dimension of a metric's overall value;
+// accounts are also broken down by status
+const MetricDimensionAll = "all"
+
+// DailyTotals sums one day's aggregated metrics over every organization
+type DailyTotals struct {
+	// Rows is the number of daily_metrics rows written
+	Rows      int64
+	Customers int64
+	// AccountsByStatus counts accounts by their status
+	AccountsByStatus map[string]int64
+}
+
+// MetricPoint is one day's value of a metric for one dimension
+type MetricPoint struct {
+	Date      time.Time `json:"date" db:"metric_date"`
+	Dimension string    `json:"dimension" db:"dimension"`
+	Value     int64     `json:"value" db:"value"`
+}
+
+// TimeseriesPoint is one day of a timeseries
+type TimeseriesPoint struct {
+	Date  string `json:"date" example:"2024-01-31"`
+	Value int64  `json:"value"`
+}
+
+// TimeseriesSeries is the daily values of one dimension of a metric
+type TimeseriesSeries struct {
+	Dimension string            `json:"dimension" example:"all"`
+	Points    []TimeseriesPoint `json:"points"`
+}
+
+// Timeseries is a metric's daily values between two dates, one series per dimension
+type Timeseries struct {
+	Metric string             `json:"metric" example:"accounts"`
+	From   string             `json:"from" example:"2024-01-01"`
+	To     string             `json:"to" example:"2024-01-31"`
+	Series []TimeseriesSeries `json:"series"`
+}
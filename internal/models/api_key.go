@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// APIKey is an organization's credential for machine-to-machine access.
+// Only a hash of the secret is stored; Prefix identifies the key.
+type APIKey struct {
+	ID         int        `json:"id" db:"id"`
+	OrgID      int        `json:"org_id" db:"org_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	CreatedBy  *int       `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// CreateAPIKeyRequest represents the request payload for minting an API key
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+	// Scopes are the permissions the key grants, e.g. "customers:read"
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
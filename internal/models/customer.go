@@ -5,6 +5,7 @@ import "time"
 // Customer represents a customer in the system
 type Customer struct {
 	ID        int       `json:"id" db:"id"`
+	OrgID     int       `json:"org_id" db:"org_id"`
 	Name      string    `json:"name" db:"name"`
 	Email     string    `json:"email" db:"email"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Organization is a tenant; customers and accounts belong to exactly one
+type Organization struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// Role is the listing user's role in the organization, when known
+	Role string `json:"role,omitempty" db:"role"`
+}
+
+// CreateOrganizationRequest represents the request payload for creating an organization
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// Member is a user's membership of an organization
+type Member struct {
+	UserID   int    `json:"user_id" db:"user_id"`
+	Username string `json:"username" db:"username"`
+	Role     string `json:"role" db:"role"`
+}
+
+// AddMemberRequest represents the request payload for adding a user to an organization
+type AddMemberRequest struct {
+	Username string `json:"username" binding:"required"`
+	// Role defaults to member
+	Role string `json:"role"`
+}
+
+// UpdateMemberRequest represents the request payload for changing a member's role
+type UpdateMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
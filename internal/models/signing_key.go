@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// SigningKey is a stored JWT signing key
+type SigningKey struct {
+	KID       string `json:"kid" db:"kid"`
+	Algorithm string `json:"alg" db:"algorithm"`
+	// PrivateKey is the PKCS #8 PEM encoding of the private key
+	PrivateKey string    `json:"-" db:"private_key"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
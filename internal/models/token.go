@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RefreshToken is a stored refresh token; only a hash of the token is kept
+type RefreshToken struct {
+	ID        int64      `json:"id" db:"id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	FamilyID  string     `json:"family_id" db:"family_id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	OrgID     int        `json:"org_id" db:"org_id"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// User represents an application user who can log in
+type User struct {
+	ID           int       `json:"id" db:"id"`
+	Username     string    `json:"username" db:"username"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	IsAdmin      bool      `json:"is_admin" db:"is_admin"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"saas-go-app/internal/models"
+)
+
+const accountColumns = "id, org_id, customer_id, name, status, created_at, updated_at"
+
+type postgresAccounts struct {
+	db *sql.DB
+	// reads serves List and Get, which may use a follower
+	reads ReadPool
+}
+
+func scanAccount(row rowScanner) (models.Account, error) {
+	var account models.Account
+	err := row.Scan(&account.ID, &account.OrgID, &account.CustomerID, &account.Name, &account.Status, &account.CreatedAt, &account.UpdatedAt)
+	return account, err
+}
+
+func (r *postgresAccounts) List(ctx context.Context, filter AccountFilter, page PageRequest) (Page[models.Account], error) {
+	var result Page[models.Account]
+	err := inTenant(ctx, r.reads.Pick(ctx), true, func(q querier, orgID int) error {
+		b := &queryBuilder{}
+		b.where("org_id = " + b.arg(orgID))
+		if filter.Status != "" {
+			b.where("status = " + b.arg(filter.Status))
+		}
+		if filter.CustomerID != 0 {
+			b.where("customer_id = " + b.arg(filter.CustomerID))
+		}
+		if filter.CreatedAfter != nil {
+			b.where("created_at >= " + b.arg(*filter.CreatedAfter))
+		}
+		if filter.CreatedBefore != nil {
+			b.where("created_at < " + b.arg(*filter.CreatedBefore))
+		}
+
+		var err error
+		result, err = listRows(ctx, q, b, "accounts", accountColumns, page, accountSortColumns, scanAccount)
+		return err
+	})
+	return result, err
+}
+
+func (r *postgresAccounts) Get(ctx context.Context, id int) (models.Account, error) {
+	var account models.Account
+	err := inTenant(ctx, r.reads.Pick(ctx), true, func(q querier, orgID int) error {
+		var err error
+		account, err = scanAccount(q.QueryRowContext(ctx,
+			"SELECT "+accountColumns+" FROM accounts WHERE id = $1 AND org_id = $2",
+			id, orgID,
+		))
+		return translateError(err, "get account")
+	})
+	return account, err
+}
+
+func (r *postgresAccounts) Create(ctx context.Context, req models.CreateAccountRequest) (models.Account, error) {
+	var account models.Account
+	err := inTenant(ctx, r.db, false, func(q querier, orgID int) error {
+		// Selecting the customer within the organization doubles as the
+		// reference check: customers of other organizations yield no row
+		var err error
+		account, err = scanAccount(q.QueryRowContext(ctx,
+			"INSERT INTO accounts (org_id, customer_id, name, status) "+
+				"SELECT org_id, id, $2, $3 FROM customers WHERE id = $1 AND org_id = $4 "+
+				"RETURNING "+accountColumns,
+			req.CustomerID, req.Name, req.Status, orgID,
+		))
+		if err == sql.ErrNoRows {
+			return ErrInvalidReference
+		}
+		return translateError(err, "create account")
+	})
+	return account, err
+}
+
+func (r *postgresAccounts) Update(ctx context.Context, id int, req models.UpdateAccountRequest) (models.Account, error) {
+	var account models.Account
+	err := inTenant(ctx, r.db, false, func(q querier, orgID int) error {
+		var err error
+		account, err = scanAccount(q.QueryRowContext(ctx,
+			"UPDATE accounts SET name = $1, status = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3 AND org_id = $4 RETURNING "+accountColumns,
+			req.Name, req.Status, id, orgID,
+		))
+		return translateError(err, "update account")
+	})
+	return account, err
+}
+
+func (r *postgresAccounts) Delete(ctx context.Context, id int) error {
+	return inTenant(ctx, r.db, false, func(q querier, orgID int) error {
+		result, err := q.ExecContext(ctx, "DELETE FROM accounts WHERE id = $1 AND org_id = $2", id, orgID)
+		if err != nil {
+			return translateError(err, "delete account")
+		}
+
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
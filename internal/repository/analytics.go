@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"saas-go-app/internal/models"
+)
+
+type postgresAnalytics struct {
+	pool ReadPool
+}
+
+func (r *postgresAnalytics) Overview(ctx context.Context) (models.AnalyticsOverview, error) {
+	var overview models.AnalyticsOverview
+	err := inTenant(ctx, r.pool.Pick(ctx), true, func(q querier, orgID int) error {
+		err := q.QueryRowContext(ctx, "SELECT COUNT(*) FROM customers WHERE org_id = $1", orgID).Scan(&overview.TotalCustomers)
+		if err != nil {
+			return translateError(err, "count customers")
+		}
+
+		err = q.QueryRowContext(ctx, "SELECT COUNT(*) FROM accounts WHERE org_id = $1", orgID).Scan(&overview.TotalAccounts)
+		if err != nil {
+			return translateError(err, "count accounts")
+		}
+
+		err = q.QueryRowContext(ctx, "SELECT COUNT(*) FROM accounts WHERE org_id = $1 AND status = 'active'", orgID).Scan(&overview.ActiveAccounts)
+		if err != nil {
+			return translateError(err, "count active accounts")
+		}
+
+		err = q.QueryRowContext(ctx, "SELECT COUNT(*) FROM accounts WHERE org_id = $1 AND status = 'inactive'", orgID).Scan(&overview.InactiveAccounts)
+		if err != nil {
+			return translateError(err, "count inactive accounts")
+		}
+
+		if overview.TotalCustomers > 0 {
+			err = q.QueryRowContext(ctx,
+				"SELECT COALESCE(AVG(account_count), 0) FROM (SELECT customer_id, COUNT(*) as account_count FROM accounts WHERE org_id = $1 GROUP BY customer_id) AS subquery",
+				orgID,
+			).Scan(&overview.AvgAccountsPerCustomer)
+			if err != nil {
+				return translateError(err, "average accounts per customer")
+			}
+		}
+		return nil
+	})
+	return overview, err
+}
+
+func (r *postgresAnalytics) CustomerSummary(ctx context.Context, customerID int) (models.CustomerAnalytics, error) {
+	summary := models.CustomerAnalytics{CustomerID: customerID}
+	err := inTenant(ctx, r.pool.Pick(ctx), true, func(q querier, orgID int) error {
+		// Grouping by the customer yields no row, and ErrNotFound, for
+		// customers of other organizations
+		err := q.QueryRowContext(ctx,
+			"SELECT COUNT(a.id), COUNT(CASE WHEN a.status = 'active' THEN 1 END) FROM customers c "+
+				"LEFT JOIN accounts a ON a.customer_id = c.id AND a.org_id = c.org_id "+
+				"WHERE c.id = $1 AND c.org_id = $2 GROUP BY c.id",
+			customerID, orgID,
+		).Scan(&summary.TotalAccounts, &summary.ActiveAccounts)
+		return translateError(err, "summarize customer accounts")
+	})
+
+	summary.InactiveAccounts = summary.TotalAccounts - summary.ActiveAccounts
+	return summary, err
+}
+
+func (r *postgresAnalytics) Timeseries(ctx context.Context, metric string, from, to time.Time) ([]models.MetricPoint, error) {
+	points := []models.MetricPoint{}
+	err := inTenant(ctx, r.pool.Pick(ctx), true, func(q querier, orgID int) error {
+		rows, err := q.QueryContext(ctx,
+			"SELECT metric_date, dimension, value FROM daily_metrics "+
+				"WHERE org_id = $1 AND metric = $2 AND metric_date BETWEEN $3::date AND $4::date "+
+				"ORDER BY dimension, metric_date",
+			orgID, metric, from.Format(time.DateOnly), to.Format(time.DateOnly),
+		)
+		if err != nil {
+			return translateError(err, "list daily metrics")
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var point models.MetricPoint
+			if err := rows.Scan(&point.Date, &point.Dimension, &point.Value); err != nil {
+				return translateError(err, "scan daily metrics")
+			}
+			points = append(points, point)
+		}
+		return translateError(rows.Err(), "list daily metrics")
+	})
+	return points, err
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"saas-go-app/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = "id, org_id, name, prefix, key_hash, scopes, created_by, created_at, last_used_at, expires_at, revoked_at"
+
+// apiKeyTouchInterval limits last_used_at writes to one per key per minute
+const apiKeyTouchInterval = "1 minute"
+
+type postgresAPIKeys struct {
+	db *sql.DB
+}
+
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	var createdBy sql.NullInt64
+	err := row.Scan(
+		&key.ID, &key.OrgID, &key.Name, &key.Prefix, &key.KeyHash, pq.Array(&key.Scopes),
+		&createdBy, &key.CreatedAt, &key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt,
+	)
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		key.CreatedBy = &id
+	}
+	return key, err
+}
+
+func (r *postgresAPIKeys) List(ctx context.Context) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	err := inTenant(ctx, r.db, true, func(q querier, orgID int) error {
+		rows, err := q.QueryContext(ctx,
+			"SELECT "+apiKeyColumns+" FROM api_keys WHERE org_id = $1 ORDER BY created_at DESC, id DESC",
+			orgID,
+		)
+		if err != nil {
+			return translateError(err, "list api keys")
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			key, err := scanAPIKey(rows)
+			if err != nil {
+				return translateError(err, "scan api key")
+			}
+			keys = append(keys, key)
+		}
+		return translateError(rows.Err(), "list api keys")
+	})
+	return keys, err
+}
+
+func (r *postgresAPIKeys) Get(ctx context.Context, id int) (models.APIKey, error) {
+	var key models.APIKey
+	err := inTenant(ctx, r.db, true, func(q querier, orgID int) error {
+		var err error
+		key, err = scanAPIKey(q.QueryRowContext(ctx,
+			"SELECT "+apiKeyColumns+" FROM api_keys WHERE id = $1 AND org_id = $2",
+			id, orgID,
+		))
+		return translateError(err, "get api key")
+	})
+	return key, err
+}
+
+func (r *postgresAPIKeys) Create(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	var created models.APIKey
+	err := inTenant(ctx, r.db, false, func(q querier, orgID int) error {
+		var err error
+		created, err = scanAPIKey(q.QueryRowContext(ctx,
+			"INSERT INTO api_keys (org_id, name, prefix, key_hash, scopes, created_by, expires_at) "+
+				"VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING "+apiKeyColumns,
+			orgID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.CreatedBy, key.ExpiresAt,
+		))
+		return translateError(err, "create api key")
+	})
+	return created, err
+}
+
+func (r *postgresAPIKeys) Revoke(ctx context.Context, id int) error {
+	return inTenant(ctx, r.db, false, func(q querier, orgID int) error {
+		result, err := q.ExecContext(ctx,
+			"UPDATE api_keys SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP) WHERE id = $1 AND org_id = $2",
+			id, orgID,
+		)
+		if err != nil {
+			return translateError(err, "revoke api key")
+		}
+
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *postgresAPIKeys) GetByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = $1",
+		prefix,
+	))
+	return key, translateError(err, "get api key")
+}
+
+func (r *postgresAPIKeys) Touch(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP "+
+			"WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '"+apiKeyTouchInterval+"')",
+		id,
+	)
+	return translateError(err, "touch api key")
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"saas-go-app/internal/models"
+)
+
+const customerColumns = "id, org_id, name, email, created_at, updated_at"
+
+type postgresCustomers struct {
+	db *sql.DB
+	// reads serves List and Get, which may use a follower
+	reads ReadPool
+}
+
+func scanCustomer(row rowScanner) (models.Customer, error) {
+	var customer models.Customer
+	err := row.Scan(&customer.ID, &customer.OrgID, &customer.Name, &customer.Email, &customer.CreatedAt, &customer.UpdatedAt)
+	return customer, err
+}
+
+func (r *postgresCustomers) List(ctx context.Context, filter CustomerFilter, page PageRequest) (Page[models.Customer], error) {
+	var result Page[models.Customer]
+	err := inTenant(ctx, r.reads.Pick(ctx), true, func(q querier, orgID int) error {
+		b := &queryBuilder{}
+		b.where("org_id = " + b.arg(orgID))
+		if filter.Search != "" {
+			pattern := b.arg("%" + escapeLike(filter.Search) + "%")
+			term := b.arg(filter.Search)
+			// ILIKE catches substrings, the trigram % operator catches typos;
+			// both are served by the gin_trgm_ops indexes
+			b.where("(name ILIKE " + pattern + " OR email ILIKE " + pattern + " OR name % " + term + ")")
+		}
+		if filter.CreatedAfter != nil {
+			b.where("created_at >= " + b.arg(*filter.CreatedAfter))
+		}
+		if filter.CreatedBefore != nil {
+			b.where("created_at < " + b.arg(*filter.CreatedBefore))
+		}
+
+		var err error
+		result, err = listRows(ctx, q, b, "customers", customerColumns, page, customerSortColumns, scanCustomer)
+		return err
+	})
+	return result, err
+}
+
+func (r *postgresCustomers) Get(ctx context.Context, id int) (models.Customer, error) {
+	var customer models.Customer
+	err := inTenant(ctx, r.reads.Pick(ctx), true, func(q querier, orgID int) error {
+		var err error
+		customer, err = scanCustomer(q.QueryRowContext(ctx,
+			"SELECT "+customerColumns+" FROM customers WHERE id = $1 AND org_id = $2",
+			id, orgID,
+		))
+		return translateError(err, "get customer")
+	})
+	return customer, err
+}
+
+func (r *postgresCustomers) Create(ctx context.Context, req models.CreateCustomerRequest) (models.Customer, error) {
+	var customer models.Customer
+	err := inTenant(ctx, r.db, false, func(q querier, orgID int) error {
+		var err error
+		customer, err = scanCustomer(q.QueryRowContext(ctx,
+			"INSERT INTO customers (org_id, name, email) VALUES ($1, $2, $3) RETURNING "+customerColumns,
+			orgID, req.Name, req.Email,
+		))
+		return translateError(err, "create customer")
+	})
+	return customer, err
+}
+
+func (r *postgresCustomers) Update(ctx context.Context, id int, req models.UpdateCustomerRequest) (models.Customer, error) {
+	var customer models.Customer
+	err := inTenant(ctx, r.db, false, func(q querier, orgID int) error {
+		var err error
+		customer, err = scanCustomer(q.QueryRowContext(ctx,
+			"UPDATE customers SET name = $1, email = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3 AND org_id = $4 RETURNING "+customerColumns,
+			req.Name, req.Email, id, orgID,
+		))
+		return translateError(err, "update customer")
+	})
+	return customer, err
+}
+
+func (r *postgresCustomers) Delete(ctx context.Context, id int) error {
+	return inTenant(ctx, r.db, false, func(q querier, orgID int) error {
+		result, err := q.ExecContext(ctx, "DELETE FROM customers WHERE id = $1 AND org_id = $2", id, orgID)
+		if err != nil {
+			return translateError(err, "delete customer")
+		}
+
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"saas-go-app/internal/models"
+)
+
+// upsertDailyMetric makes daily_metrics inserts idempotent: a rerun for the
+// same day overwrites the stored value
+const upsertDailyMetric = " ON CONFLICT (org_id, metric, dimension, metric_date) " +
+	"DO UPDATE SET value = EXCLUDED.value, computed_at = CURRENT_TIMESTAMP"
+
+// dailyTotalsSQL stores, for every organization, how many rows of a table
+// existed at the end of day $1 ($2) and how many were created that day ($3).
+// Organizations without rows get zeros rather than gaps.
+const dailyTotalsSQL = "INSERT INTO daily_metrics (metric_date, org_id, metric, dimension, value) " +
+	"SELECT $1::date, counts.org_id, m.metric, 'all', m.value FROM (" +
+	"SELECT o.id AS org_id, COUNT(t.id) AS total, COUNT(t.id) FILTER (WHERE t.created_at >= $1::date) AS created " +
+	"FROM organizations o LEFT JOIN %s t ON t.org_id = o.id AND t.created_at < $1::date + 1 " +
+	"GROUP BY o.id" +
+	") counts CROSS JOIN LATERAL (VALUES ($2::text, counts.total), ($3::text, counts.created)) AS m(metric, value)" +
+	upsertDailyMetric
+
+type postgresDailyMetrics struct {
+	db *sql.DB
+}
+
+func (r *postgresDailyMetrics) AggregateDay(ctx context.Context, day time.Time) (models.DailyTotals, error) {
+	date := day.UTC().Format(time.DateOnly)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.DailyTotals{}, translateError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	if err := acrossTenants(ctx, tx); err != nil {
+		return models.DailyTotals{}, err
+	}
+
+	var written int64
+	exec := func(action, query string, args ...any) error {
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return translateError(err, action)
+		}
+		rowsAffected, _ := result.RowsAffected()
+		written += rowsAffected
+		return nil
+	}
+
+	if err := exec("aggregate customers", fmt.Sprintf(dailyTotalsSQL, "customers"),
+		date, models.MetricCustomers, models.MetricNewCustomers); err != nil {
+		return models.DailyTotals{}, err
+	}
+	if err := exec("aggregate accounts", fmt.Sprintf(dailyTotalsSQL, "accounts"),
+		date, models.MetricAccounts, models.MetricNewAccounts); err != nil {
+		return models.DailyTotals{}, err
+	}
+
+	// A status with no accounts left has no group below; zero it first so
+	// a rerun does not leave its old count behind
+	_, err = tx.ExecContext(ctx,
+		"UPDATE daily_metrics SET value = 0, computed_at = CURRENT_TIMESTAMP "+
+			"WHERE metric_date = $1::date AND metric = $2 AND dimension <> 'all'",
+		date, models.MetricAccounts,
+	)
+	if err != nil {
+		return models.DailyTotals{}, translateError(err, "reset account status metrics")
+	}
+	if err := exec("aggregate account statuses",
+		"INSERT INTO daily_metrics (metric_date, org_id, metric, dimension, value) "+
+			"SELECT $1::date, org_id, $2::text, status, COUNT(*) FROM accounts "+
+			"WHERE created_at < $1::date + 1 GROUP BY org_id, status"+upsertDailyMetric,
+		date, models.MetricAccounts); err != nil {
+		return models.DailyTotals{}, err
+	}
+
+	totals := models.DailyTotals{Rows: written, AccountsByStatus: make(map[string]int64)}
+	rows, err := tx.QueryContext(ctx,
+		"SELECT metric, dimension, SUM(value) FROM daily_metrics "+
+			"WHERE metric_date = $1::date AND ((metric = $2 AND dimension = 'all') OR (metric = $3 AND dimension <> 'all')) "+
+			"GROUP BY metric, dimension",
+		date, models.MetricCustomers, models.MetricAccounts,
+	)
+	if err != nil {
+		return models.DailyTotals{}, translateError(err, "sum daily metrics")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var metric, dimension string
+		var value int64
+		if err := rows.Scan(&metric, &dimension, &value); err != nil {
+			return models.DailyTotals{}, translateError(err, "sum daily metrics")
+		}
+		if metric == models.MetricCustomers {
+			totals.Customers = value
+		} else if value > 0 {
+			totals.AccountsByStatus[dimension] = value
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return models.DailyTotals{}, translateError(err, "sum daily metrics")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.DailyTotals{}, translateError(err, "commit daily metrics")
+	}
+	return totals, nil
+}
@@ -0,0 +1,905 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"saas-go-app/internal/models"
+	"saas-go-app/internal/tenant"
+)
+
+// MemoryStore is an in-memory Store for tests and local development.
+// It mirrors the constraints enforced by the Postgres schema: customer
+// emails unique per organization, unique usernames, accounts cascading
+// with their customer, and tenant isolation.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	customers map[int]models.Customer
+	accounts  map[int]models.Account
+	users     map[string]models.User
+	orgs      map[int]models.Organization
+	members   map[int]map[int]string // org ID -> user ID -> role
+	refresh   map[int64]models.RefreshToken
+	revoked   map[string]time.Time // access token jti -> expiry
+	keys      []models.SigningKey
+	apiKeys   map[int]models.APIKey
+	metrics   map[dailyMetricKey]int64
+
+	nextCustomerID int
+	nextAccountID  int
+	nextUserID     int
+	nextOrgID      int
+	nextRefreshID  int64
+	nextAPIKeyID   int
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		customers: make(map[int]models.Customer),
+		accounts:  make(map[int]models.Account),
+		users:     make(map[string]models.User),
+		orgs:      make(map[int]models.Organization),
+		members:   make(map[int]map[int]string),
+		refresh:   make(map[int64]models.RefreshToken),
+		revoked:   make(map[string]time.Time),
+		apiKeys:   make(map[int]models.APIKey),
+		metrics:   make(map[dailyMetricKey]int64),
+	}
+}
+
+// Customers returns the customer repository
+func (s *MemoryStore) Customers() CustomerRepository {
+	return memoryCustomers{s}
+}
+
+// Accounts returns the account repository
+func (s *MemoryStore) Accounts() AccountRepository {
+	return memoryAccounts{s}
+}
+
+// Users returns the user repository
+func (s *MemoryStore) Users() UserRepository {
+	return memoryUsers{s}
+}
+
+// Organizations returns the organization repository
+func (s *MemoryStore) Organizations() OrganizationRepository {
+	return memoryOrganizations{s}
+}
+
+// Tokens returns the token repository
+func (s *MemoryStore) Tokens() TokenRepository {
+	return memoryTokens{s}
+}
+
+// APIKeys returns the API key repository
+func (s *MemoryStore) APIKeys() APIKeyRepository {
+	return memoryAPIKeys{s}
+}
+
+// SigningKeys returns the signing key repository
+func (s *MemoryStore) SigningKeys() SigningKeyRepository {
+	return memorySigningKeys{s}
+}
+
+// Analytics returns the analytics repository
+func (s *MemoryStore) Analytics() AnalyticsRepository {
+	return memoryAnalytics{s}
+}
+
+// DailyMetrics returns the daily metrics repository
+func (s *MemoryStore) DailyMetrics() DailyMetricsRepository {
+	return memoryDailyMetrics{s}
+}
+
+type memoryCustomers struct{ s *MemoryStore }
+
+func (r memoryCustomers) List(ctx context.Context, filter CustomerFilter, page PageRequest) (Page[models.Customer], error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return Page[models.Customer]{}, ErrNoTenant
+	}
+	page, column, err := resolveSort(page, customerSortColumns)
+	if err != nil {
+		return Page[models.Customer]{}, err
+	}
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	search := strings.ToLower(filter.Search)
+	customers := make([]models.Customer, 0, len(r.s.customers))
+	for _, customer := range r.s.customers {
+		if customer.OrgID != orgID {
+			continue
+		}
+		if search != "" &&
+			!strings.Contains(strings.ToLower(customer.Name), search) &&
+			!strings.Contains(strings.ToLower(customer.Email), search) {
+			continue
+		}
+		if !createdWithin(customer.CreatedAt, filter.CreatedAfter, filter.CreatedBefore) {
+			continue
+		}
+		customers = append(customers, customer)
+	}
+
+	sortItems(customers, column, page.Sort.Desc)
+	return paginateSlice(customers, page, column), nil
+}
+
+func (r memoryCustomers) Get(ctx context.Context, id int) (models.Customer, error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return models.Customer{}, ErrNoTenant
+	}
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	customer, ok := r.s.customers[id]
+	if !ok || customer.OrgID != orgID {
+		return models.Customer{}, ErrNotFound
+	}
+	return customer, nil
+}
+
+func (r memoryCustomers) Create(ctx context.Context, req models.CreateCustomerRequest) (models.Customer, error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return models.Customer{}, ErrNoTenant
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if r.s.emailTaken(orgID, req.Email, 0) {
+		return models.Customer{}, ErrConflict
+	}
+
+	r.s.nextCustomerID++
+	now := time.Now()
+	customer := models.Customer{
+		ID:        r.s.nextCustomerID,
+		OrgID:     orgID,
+		Name:      req.Name,
+		Email:     req.Email,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.s.customers[customer.ID] = customer
+	return customer, nil
+}
+
+func (r memoryCustomers) Update(ctx context.Context, id int, req models.UpdateCustomerRequest) (models.Customer, error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return models.Customer{}, ErrNoTenant
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	customer, ok := r.s.customers[id]
+	if !ok || customer.OrgID != orgID {
+		return models.Customer{}, ErrNotFound
+	}
+	if r.s.emailTaken(orgID, req.Email, id) {
+		return models.Customer{}, ErrConflict
+	}
+
+	customer.Name = req.Name
+	customer.Email = req.Email
+	customer.UpdatedAt = time.Now()
+	r.s.customers[id] = customer
+	return customer, nil
+}
+
+func (r memoryCustomers) Delete(ctx context.Context, id int) error {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if customer, ok := r.s.customers[id]; !ok || customer.OrgID != orgID {
+		return ErrNotFound
+	}
+	delete(r.s.customers, id)
+
+	// ON DELETE CASCADE
+	for accountID, account := range r.s.accounts {
+		if account.CustomerID == id {
+			delete(r.s.accounts, accountID)
+		}
+	}
+	return nil
+}
+
+// emailTaken reports whether another customer in the organization already
+// uses email; callers hold mu
+func (s *MemoryStore) emailTaken(orgID int, email string, exceptID int) bool {
+	for _, customer := range s.customers {
+		if customer.OrgID == orgID && customer.Email == email && customer.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+type memoryAccounts struct{ s *MemoryStore }
+
+func (r memoryAccounts) List(ctx context.Context, filter AccountFilter, page PageRequest) (Page[models.Account], error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return Page[models.Account]{}, ErrNoTenant
+	}
+	page, column, err := resolveSort(page, accountSortColumns)
+	if err != nil {
+		return Page[models.Account]{}, err
+	}
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	accounts := make([]models.Account, 0, len(r.s.accounts))
+	for _, account := range r.s.accounts {
+		if account.OrgID != orgID {
+			continue
+		}
+		if filter.Status != "" && account.Status != filter.Status {
+			continue
+		}
+		if filter.CustomerID != 0 && account.CustomerID != filter.CustomerID {
+			continue
+		}
+		if !createdWithin(account.CreatedAt, filter.CreatedAfter, filter.CreatedBefore) {
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+
+	sortItems(accounts, column, page.Sort.Desc)
+	return paginateSlice(accounts, page, column), nil
+}
+
+func (r memoryAccounts) Get(ctx context.Context, id int) (models.Account, error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return models.Account{}, ErrNoTenant
+	}
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	account, ok := r.s.accounts[id]
+	if !ok || account.OrgID != orgID {
+		return models.Account{}, ErrNotFound
+	}
+	return account, nil
+}
+
+func (r memoryAccounts) Create(ctx context.Context, req models.CreateAccountRequest) (models.Account, error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return models.Account{}, ErrNoTenant
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if customer, ok := r.s.customers[req.CustomerID]; !ok || customer.OrgID != orgID {
+		return models.Account{}, ErrInvalidReference
+	}
+
+	r.s.nextAccountID++
+	now := time.Now()
+	account := models.Account{
+		ID:         r.s.nextAccountID,
+		OrgID:      orgID,
+		CustomerID: req.CustomerID,
+		Name:       req.Name,
+		Status:     req.Status,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	r.s.accounts[account.ID] = account
+	return account, nil
+}
+
+func (r memoryAccounts) Update(ctx context.Context, id int, req models.UpdateAccountRequest) (models.Account, error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return models.Account{}, ErrNoTenant
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	account, ok := r.s.accounts[id]
+	if !ok || account.OrgID != orgID {
+		return models.Account{}, ErrNotFound
+	}
+
+	account.Name = req.Name
+	account.Status = req.Status
+	account.UpdatedAt = time.Now()
+	r.s.accounts[id] = account
+	return account, nil
+}
+
+func (r memoryAccounts) Delete(ctx context.Context, id int) error {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if account, ok := r.s.accounts[id]; !ok || account.OrgID != orgID {
+		return ErrNotFound
+	}
+	delete(r.s.accounts, id)
+	return nil
+}
+
+type memoryUsers struct{ s *MemoryStore }
+
+func (r memoryUsers) GetByID(ctx context.Context, id int) (models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, user := range r.s.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (r memoryUsers) GetByUsername(ctx context.Context, username string) (models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	user, ok := r.s.users[username]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (r memoryUsers) Register(ctx context.Context, username, passwordHash, orgName string) (models.User, models.Organization, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.users[username]; ok {
+		return models.User{}, models.Organization{}, ErrConflict
+	}
+
+	r.s.nextUserID++
+	user := models.User{
+		ID:           r.s.nextUserID,
+		Username:     username,
+		PasswordHash: passwordHash,
+		CreatedAt:    time.Now(),
+	}
+	r.s.users[username] = user
+
+	return user, r.s.createOrganization(orgName, user.ID), nil
+}
+
+func (r memoryUsers) SetAdmin(ctx context.Context, id int, admin bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for username, user := range r.s.users {
+		if user.ID == id {
+			user.IsAdmin = admin
+			r.s.users[username] = user
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+type memoryOrganizations struct{ s *MemoryStore }
+
+func (r memoryOrganizations) Create(ctx context.Context, name string, ownerID int) (models.Organization, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return r.s.createOrganization(name, ownerID), nil
+}
+
+func (r memoryOrganizations) ListForUser(ctx context.Context, userID int) ([]models.Organization, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	orgs := []models.Organization{}
+	for orgID, members := range r.s.members {
+		if role, ok := members[userID]; ok {
+			org := r.s.orgs[orgID]
+			org.Role = role
+			orgs = append(orgs, org)
+		}
+	}
+	sort.Slice(orgs, func(i, j int) bool { return orgs[i].ID < orgs[j].ID })
+	return orgs, nil
+}
+
+func (r memoryOrganizations) MemberRole(ctx context.Context, orgID, userID int) (string, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	role, ok := r.s.members[orgID][userID]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return role, nil
+}
+
+func (r memoryOrganizations) SetMemberRole(ctx context.Context, orgID, userID int, role string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.members[orgID][userID]; !ok {
+		return ErrNotFound
+	}
+	r.s.members[orgID][userID] = role
+	return nil
+}
+
+func (r memoryOrganizations) ListMembers(ctx context.Context, orgID int) ([]models.Member, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	members := []models.Member{}
+	for _, user := range r.s.users {
+		if role, ok := r.s.members[orgID][user.ID]; ok {
+			members = append(members, models.Member{UserID: user.ID, Username: user.Username, Role: role})
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].UserID < members[j].UserID })
+	return members, nil
+}
+
+func (r memoryOrganizations) AddMember(ctx context.Context, orgID, userID int, role string) (models.Member, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.orgs[orgID]; !ok {
+		return models.Member{}, ErrInvalidReference
+	}
+	for _, user := range r.s.users {
+		if user.ID != userID {
+			continue
+		}
+		if _, ok := r.s.members[orgID][userID]; ok {
+			return models.Member{}, ErrConflict
+		}
+		r.s.members[orgID][userID] = role
+		return models.Member{UserID: user.ID, Username: user.Username, Role: role}, nil
+	}
+	return models.Member{}, ErrInvalidReference
+}
+
+// createOrganization adds an organization owned by ownerID; callers hold mu
+func (s *MemoryStore) createOrganization(name string, ownerID int) models.Organization {
+	s.nextOrgID++
+	org := models.Organization{ID: s.nextOrgID, Name: name, CreatedAt: time.Now()}
+	s.orgs[org.ID] = org
+	s.members[org.ID] = map[int]string{ownerID: "owner"}
+	org.Role = "owner"
+	return org
+}
+
+type memoryTokens struct{ s *MemoryStore }
+
+func (r memoryTokens) CreateRefreshToken(ctx context.Context, token models.RefreshToken) (models.RefreshToken, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	return r.s.insertRefreshToken(token)
+}
+
+func (r memoryTokens) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, token := range r.s.refresh {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return models.RefreshToken{}, ErrNotFound
+}
+
+func (r memoryTokens) RotateRefreshToken(ctx context.Context, usedID int64, next models.RefreshToken) (models.RefreshToken, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	used, ok := r.s.refresh[usedID]
+	if !ok || used.UsedAt != nil || used.RevokedAt != nil {
+		return models.RefreshToken{}, ErrConflict
+	}
+	now := time.Now()
+	used.UsedAt = &now
+	r.s.refresh[usedID] = used
+
+	return r.s.insertRefreshToken(next)
+}
+
+func (r memoryTokens) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	for id, token := range r.s.refresh {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			r.s.refresh[id] = token
+		}
+	}
+	return nil
+}
+
+func (r memoryTokens) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+	for revoked, expiry := range r.s.revoked {
+		if !expiry.After(now) {
+			delete(r.s.revoked, revoked)
+		}
+	}
+	r.s.revoked[jti] = expiresAt
+	return nil
+}
+
+func (r memoryTokens) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	_, ok := r.s.revoked[jti]
+	return ok, nil
+}
+
+// insertRefreshToken stores a refresh token; callers hold mu
+func (s *MemoryStore) insertRefreshToken(token models.RefreshToken) (models.RefreshToken, error) {
+	for _, existing := range s.refresh {
+		if existing.TokenHash == token.TokenHash {
+			return models.RefreshToken{}, ErrConflict
+		}
+	}
+
+	s.nextRefreshID++
+	token.ID = s.nextRefreshID
+	token.CreatedAt = time.Now()
+	s.refresh[token.ID] = token
+	return token, nil
+}
+
+type memoryAPIKeys struct{ s *MemoryStore }
+
+func (r memoryAPIKeys) List(ctx context.Context) ([]models.APIKey, error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	keys := []models.APIKey{}
+	for _, key := range r.s.apiKeys {
+		if key.OrgID == orgID {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID > keys[j].ID })
+	return keys, nil
+}
+
+func (r memoryAPIKeys) Get(ctx context.Context, id int) (models.APIKey, error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return models.APIKey{}, ErrNoTenant
+	}
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	key, ok := r.s.apiKeys[id]
+	if !ok || key.OrgID != orgID {
+		return models.APIKey{}, ErrNotFound
+	}
+	return key, nil
+}
+
+func (r memoryAPIKeys) Create(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return models.APIKey{}, ErrNoTenant
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.apiKeys {
+		if existing.Prefix == key.Prefix {
+			return models.APIKey{}, ErrConflict
+		}
+	}
+
+	r.s.nextAPIKeyID++
+	key.ID = r.s.nextAPIKeyID
+	key.OrgID = orgID
+	key.CreatedAt = time.Now()
+	r.s.apiKeys[key.ID] = key
+	return key, nil
+}
+
+func (r memoryAPIKeys) Revoke(ctx context.Context, id int) error {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	key, ok := r.s.apiKeys[id]
+	if !ok || key.OrgID != orgID {
+		return ErrNotFound
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+		r.s.apiKeys[id] = key
+	}
+	return nil
+}
+
+func (r memoryAPIKeys) GetByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, key := range r.s.apiKeys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return models.APIKey{}, ErrNotFound
+}
+
+func (r memoryAPIKeys) Touch(ctx context.Context, id int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	key, ok := r.s.apiKeys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	now := time.Now()
+	key.LastUsedAt = &now
+	r.s.apiKeys[id] = key
+	return nil
+}
+
+type memorySigningKeys struct{ s *MemoryStore }
+
+func (r memorySigningKeys) ListSigningKeys(ctx context.Context) ([]models.SigningKey, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	keys := append([]models.SigningKey{}, r.s.keys...)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (r memorySigningKeys) CreateSigningKey(ctx context.Context, key models.SigningKey) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.keys {
+		if existing.KID == key.KID {
+			return ErrConflict
+		}
+	}
+	r.s.keys = append(r.s.keys, key)
+	return nil
+}
+
+func (r memorySigningKeys) DeleteSigningKeysBefore(ctx context.Context, before time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	kept := r.s.keys[:0]
+	for _, key := range r.s.keys {
+		if !key.CreatedAt.Before(before) {
+			kept = append(kept, key)
+		}
+	}
+	r.s.keys = kept
+	return nil
+}
+
+type memoryAnalytics struct{ s *MemoryStore }
+
+func (r memoryAnalytics) Overview(ctx context.Context) (models.AnalyticsOverview, error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return models.AnalyticsOverview{}, ErrNoTenant
+	}
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var overview models.AnalyticsOverview
+	for _, customer := range r.s.customers {
+		if customer.OrgID == orgID {
+			overview.TotalCustomers++
+		}
+	}
+
+	customersWithAccounts := make(map[int]bool)
+	for _, account := range r.s.accounts {
+		if account.OrgID != orgID {
+			continue
+		}
+		overview.TotalAccounts++
+		customersWithAccounts[account.CustomerID] = true
+		switch account.Status {
+		case "active":
+			overview.ActiveAccounts++
+		case "inactive":
+			overview.InactiveAccounts++
+		}
+	}
+
+	// Matches the SQL average, which only counts customers that have accounts
+	if overview.TotalCustomers > 0 && len(customersWithAccounts) > 0 {
+		overview.AvgAccountsPerCustomer = float64(overview.TotalAccounts) / float64(len(customersWithAccounts))
+	}
+	return overview, nil
+}
+
+func (r memoryAnalytics) CustomerSummary(ctx context.Context, customerID int) (models.CustomerAnalytics, error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return models.CustomerAnalytics{}, ErrNoTenant
+	}
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	if customer, ok := r.s.customers[customerID]; !ok || customer.OrgID != orgID {
+		return models.CustomerAnalytics{}, ErrNotFound
+	}
+
+	summary := models.CustomerAnalytics{CustomerID: customerID}
+	for _, account := range r.s.accounts {
+		if account.OrgID != orgID || account.CustomerID != customerID {
+			continue
+		}
+		summary.TotalAccounts++
+		if account.Status == "active" {
+			summary.ActiveAccounts++
+		}
+	}
+	summary.InactiveAccounts = summary.TotalAccounts - summary.ActiveAccounts
+	return summary, nil
+}
+
+func (r memoryAnalytics) Timeseries(ctx context.Context, metric string, from, to time.Time) ([]models.MetricPoint, error) {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	first, last := utcDay(from), utcDay(to)
+
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	points := []models.MetricPoint{}
+	for key, value := range r.s.metrics {
+		if key.orgID != orgID || key.metric != metric || key.date.Before(first) || key.date.After(last) {
+			continue
+		}
+		points = append(points, models.MetricPoint{Date: key.date, Dimension: key.dimension, Value: value})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Dimension != points[j].Dimension {
+			return points[i].Dimension < points[j].Dimension
+		}
+		return points[i].Date.Before(points[j].Date)
+	})
+	return points, nil
+}
+
+// dailyMetricKey mirrors the daily_metrics primary key
+type dailyMetricKey struct {
+	orgID     int
+	metric    string
+	dimension string
+	date      time.Time
+}
+
+type memoryDailyMetrics struct{ s *MemoryStore }
+
+func (r memoryDailyMetrics) AggregateDay(ctx context.Context, day time.Time) (models.DailyTotals, error) {
+	date := utcDay(day)
+	end := date.AddDate(0, 0, 1)
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	values := make(map[dailyMetricKey]int64)
+	for orgID := range r.s.orgs {
+		for _, metric := range []string{models.MetricCustomers, models.MetricNewCustomers, models.MetricAccounts, models.MetricNewAccounts} {
+			values[dailyMetricKey{orgID, metric, models.MetricDimensionAll, date}] = 0
+		}
+	}
+	// Statuses that no longer have accounts are zeroed, as in Postgres
+	for key := range r.s.metrics {
+		if key.date.Equal(date) && key.metric == models.MetricAccounts && key.dimension != models.MetricDimensionAll {
+			values[key] = 0
+		}
+	}
+
+	count := func(orgID int, createdAt time.Time, total, created string) {
+		if !createdAt.Before(end) {
+			return
+		}
+		values[dailyMetricKey{orgID, total, models.MetricDimensionAll, date}]++
+		if !createdAt.Before(date) {
+			values[dailyMetricKey{orgID, created, models.MetricDimensionAll, date}]++
+		}
+	}
+	for _, customer := range r.s.customers {
+		count(customer.OrgID, customer.CreatedAt, models.MetricCustomers, models.MetricNewCustomers)
+	}
+	for _, account := range r.s.accounts {
+		count(account.OrgID, account.CreatedAt, models.MetricAccounts, models.MetricNewAccounts)
+		if account.CreatedAt.Before(end) {
+			values[dailyMetricKey{account.OrgID, models.MetricAccounts, account.Status, date}]++
+		}
+	}
+
+	totals := models.DailyTotals{Rows: int64(len(values)), AccountsByStatus: make(map[string]int64)}
+	for key, value := range values {
+		r.s.metrics[key] = value
+		switch {
+		case key.metric == models.MetricCustomers && key.dimension == models.MetricDimensionAll:
+			totals.Customers += value
+		case key.metric == models.MetricAccounts && key.dimension != models.MetricDimensionAll && value > 0:
+			totals.AccountsByStatus[key.dimension] += value
+		}
+	}
+	return totals, nil
+}
+
+// utcDay truncates t to the start of its UTC day, matching a DATE column
+func utcDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// createdWithin applies the created_after (inclusive) and created_before (exclusive) filters
+func createdWithin(createdAt time.Time, after, before *time.Time) bool {
+	if after != nil && createdAt.Before(*after) {
+		return false
+	}
+	if before != nil && !createdAt.Before(*before) {
+		return false
+	}
+	return true
+}
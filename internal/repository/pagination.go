@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+// or was issued for a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest selects one page of a sorted list. When Page is set the list is
+// read with LIMIT/OFFSET; otherwise it uses keyset pagination on the sort
+// column and id, starting after Cursor if one is given.
+type PageRequest struct {
+	Limit  int
+	Cursor *Cursor
+	Page   int
+	Sort   Sort
+}
+
+// Offset returns the number of rows to skip in page/per_page mode
+func (p PageRequest) Offset() int {
+	if p.Page <= 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.Limit
+}
+
+// Page is one page of results. NextCursor is empty on the last page and in
+// page/per_page mode; Total counts every row matching the filter.
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+	Total      int
+}
+
+// Cursor is the keyset position of the last row on a page. Value is the
+// row's sort key in the order-preserving form produced by sortColumn.key.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// Encode returns the opaque string form of the cursor
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor produced by Cursor.Encode
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.Sort == "" || cursor.ID <= 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// finishPage trims the look-ahead row and sets the next cursor
+func finishPage[T any](items []T, total int, req PageRequest, column sortColumn[T]) Page[T] {
+	page := Page[T]{Items: items, Total: total}
+	if req.Page == 0 && len(items) > req.Limit {
+		page.Items = items[:req.Limit]
+		last := page.Items[req.Limit-1]
+		page.NextCursor = Cursor{Sort: req.Sort.String(), Value: column.key(last), ID: column.id(last)}.Encode()
+	}
+	return page
+}
+
+// paginateSlice applies a page request to items already filtered and sorted
+// by req.Sort
+func paginateSlice[T any](items []T, req PageRequest, column sortColumn[T]) Page[T] {
+	total := len(items)
+
+	if req.Page > 0 {
+		start := min(req.Offset(), total)
+		end := min(start+req.Limit, total)
+		return Page[T]{Items: items[start:end], Total: total}
+	}
+
+	start := 0
+	if req.Cursor != nil {
+		for start < len(items) && !column.after(items[start], *req.Cursor, req.Sort.Desc) {
+			start++
+		}
+	}
+	end := min(start+req.Limit+1, total)
+	return finishPage(items[start:end], total, req, column)
+}
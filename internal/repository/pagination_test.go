@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"saas-go-app/internal/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{Sort: "-created_at", Value: timeKey(time.Now()), ID: 42}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("Failed to decode cursor: %v", err)
+	}
+	if decoded != cursor {
+		t.Errorf("Expected %+v, got %+v", cursor, decoded)
+	}
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	for _, input := range []string{"not base64!", "Zm9v", "e30"} {
+		if _, err := DecodeCursor(input); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q): expected ErrInvalidCursor, got %v", input, err)
+		}
+	}
+}
+
+func TestPaginateSliceWithTiedSortKeys(t *testing.T) {
+	now := time.Now()
+	items := []models.Customer{
+		{ID: 4, CreatedAt: now}, {ID: 3, CreatedAt: now}, {ID: 2, CreatedAt: now}, {ID: 1, CreatedAt: now.Add(-time.Second)},
+	}
+	column := customerSortColumns["created_at"]
+	req := PageRequest{Limit: 2, Sort: DefaultSort}
+
+	first := paginateSlice(items, req, column)
+	if len(first.Items) != 2 || first.NextCursor == "" || first.Total != 4 {
+		t.Fatalf("Unexpected first page: %+v", first)
+	}
+
+	next, _ := DecodeCursor(first.NextCursor)
+	req.Cursor = &next
+	second := paginateSlice(items, req, column)
+	if len(second.Items) != 2 || second.Items[0].ID != 2 || second.NextCursor != "" {
+		t.Errorf("Unexpected second page: %+v", second)
+	}
+}
+
+func TestParseSortWhitelist(t *testing.T) {
+	sort, err := ParseAccountSort("-status")
+	if err != nil || sort.Field != "status" || !sort.Desc {
+		t.Errorf("Expected descending status sort, got %+v (%v)", sort, err)
+	}
+
+	sort, err = ParseCustomerSort("")
+	if err != nil || sort != DefaultSort {
+		t.Errorf("Expected default sort, got %+v (%v)", sort, err)
+	}
+
+	for _, input := range []string{"password_hash", "name; DROP TABLE customers", "-"} {
+		if _, err := ParseCustomerSort(input); !errors.Is(err, ErrInvalidSort) {
+			t.Errorf("ParseCustomerSort(%q): expected ErrInvalidSort, got %v", input, err)
+		}
+	}
+}
+
+func TestListQueryUsesPlaceholders(t *testing.T) {
+	b := &queryBuilder{}
+	b.where("status = " + b.arg("active'; --"))
+
+	req := PageRequest{
+		Limit:  10,
+		Sort:   Sort{Field: "name"},
+		Cursor: &Cursor{Sort: "name", Value: "Acme", ID: 7},
+	}
+	countSQL, countArgs, pageSQL := listQuery(b, accountColumns, "accounts", accountSortColumns["name"], req)
+
+	if countSQL != "SELECT COUNT(*) FROM accounts WHERE status = $1" || len(countArgs) != 1 {
+		t.Errorf("Unexpected count query %q with %d args", countSQL, len(countArgs))
+	}
+
+	want := "WHERE status = $1 AND (name, id) > ($2::text, $3) ORDER BY name ASC, id ASC LIMIT $4"
+	if !strings.HasSuffix(pageSQL, want) {
+		t.Errorf("Expected page query ending in %q, got %q", want, pageSQL)
+	}
+	if strings.Contains(pageSQL, "active") || len(b.args) != 4 {
+		t.Errorf("User input must only appear in args: %q %v", pageSQL, b.args)
+	}
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"saas-go-app/internal/consistency"
+	"saas-go-app/internal/tenant"
+
+	"github.com/lib/pq"
+)
+
+// ReadPool picks the pool each read that tolerates replication lag runs
+// on, such as the freshest follower
+type ReadPool interface {
+	Pick(ctx context.Context) *sql.DB
+}
+
+// singlePool is a ReadPool always picking one pool
+type singlePool struct {
+	db *sql.DB
+}
+
+func (p singlePool) Pick(context.Context) *sql.DB {
+	return p.db
+}
+
+// PostgresStore implements Store on top of the primary and analytics pools
+type PostgresStore struct {
+	primary   *sql.DB
+	analytics ReadPool
+}
+
+// NewPostgresStore creates a Postgres-backed store. Writes and other reads
+// use primary; reporting queries and customer and account reads use the
+// pool analytics picks, or primary when analytics is nil. analytics must
+// honour the consistency token of the request, so clients read their own
+// writes.
+func NewPostgresStore(primary *sql.DB, analytics ReadPool) *PostgresStore {
+	if analytics == nil {
+		analytics = singlePool{primary}
+	}
+	return &PostgresStore{primary: primary, analytics: analytics}
+}
+
+// Customers returns the customer repository
+func (s *PostgresStore) Customers() CustomerRepository {
+	return &postgresCustomers{db: s.primary, reads: s.analytics}
+}
+
+// Accounts returns the account repository
+func (s *PostgresStore) Accounts() AccountRepository {
+	return &postgresAccounts{db: s.primary, reads: s.analytics}
+}
+
+// Users returns the user repository
+func (s *PostgresStore) Users() UserRepository {
+	return &postgresUsers{db: s.primary}
+}
+
+// Organizations returns the organization repository
+func (s *PostgresStore) Organizations() OrganizationRepository {
+	return &postgresOrganizations{db: s.primary}
+}
+
+// Tokens returns the token repository
+func (s *PostgresStore) Tokens() TokenRepository {
+	return &postgresTokens{db: s.primary}
+}
+
+// APIKeys returns the API key repository
+func (s *PostgresStore) APIKeys() APIKeyRepository {
+	return &postgresAPIKeys{db: s.primary}
+}
+
+// SigningKeys returns the signing key repository
+func (s *PostgresStore) SigningKeys() SigningKeyRepository {
+	return &postgresSigningKeys{db: s.primary}
+}
+
+// Analytics returns the analytics repository
+func (s *PostgresStore) Analytics() AnalyticsRepository {
+	return &postgresAnalytics{pool: s.analytics}
+}
+
+// DailyMetrics returns the daily metrics repository
+func (s *PostgresStore) DailyMetrics() DailyMetricsRepository {
+	return &postgresDailyMetrics{db: s.primary}
+}
+
+// Postgres error codes mapped to repository errors
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+	pqQueryCanceled       = "57014"
+)
+
+// translateError maps driver errors onto the repository sentinel errors
+func translateError(err error, action string) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pqUniqueViolation:
+			return fmt.Errorf("%s: %w", action, ErrConflict)
+		case pqForeignKeyViolation:
+			return fmt.Errorf("%s: %w", action, ErrInvalidReference)
+		case pqQueryCanceled:
+			return fmt.Errorf("%s: %w", action, ErrQueryCanceled)
+		}
+	}
+
+	return fmt.Errorf("%s: %w", action, err)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// querier is satisfied by *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// inTenant runs fn in a transaction scoped to the organization in ctx.
+// app.org_id is set with transaction scope so the row-level security
+// policies apply, and fn receives the org ID to filter on explicitly too.
+func inTenant(ctx context.Context, conn *sql.DB, readOnly bool, fn func(q querier, orgID int) error) error {
+	orgID, ok := tenant.OrgID(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: readOnly})
+	if err != nil {
+		return translateError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('app.org_id', $1, true)", strconv.Itoa(orgID)); err != nil {
+		return translateError(err, "set organization")
+	}
+
+	if err := fn(tx, orgID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return translateError(err, "commit transaction")
+	}
+	if !readOnly {
+		recordCommit(ctx, conn)
+	}
+	return nil
+}
+
+// acrossTenants lifts the row-level security policies for the rest of the
+// transaction q, for work that spans every organization. Without it or
+// inTenant, the policies hide every row.
+func acrossTenants(ctx context.Context, q querier) error {
+	_, err := q.ExecContext(ctx, "SELECT set_config('app.bypass_rls', 'on', true)")
+	return translateError(err, "bypass tenant isolation")
+}
+
+// recordCommit records the primary's WAL position after a commit, when
+// the request wants a consistency token. The write stands if it fails;
+// the client's reads may only be served by a follower that is behind.
+func recordCommit(ctx context.Context, conn *sql.DB) {
+	if !consistency.Tracking(ctx) {
+		return
+	}
+	var position string
+	if err := conn.QueryRowContext(ctx, "SELECT pg_current_wal_lsn()::text").Scan(&position); err != nil {
+		slog.WarnContext(ctx, "Failed to read the WAL position for a consistency token", "error", err)
+		return
+	}
+	lsn, err := consistency.ParseLSN(position)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read the WAL position for a consistency token", "error", err)
+		return
+	}
+	consistency.RecordWrite(ctx, lsn)
+}
+
+// listRows runs the count and page queries built from b and scans one page
+func listRows[T any](ctx context.Context, conn querier, b *queryBuilder, table, columns string, req PageRequest, sorts map[string]sortColumn[T], scan func(rowScanner) (T, error)) (Page[T], error) {
+	req, column, err := resolveSort(req, sorts)
+	if err != nil {
+		return Page[T]{}, err
+	}
+
+	countSQL, countArgs, pageSQL := listQuery(b, columns, table, column, req)
+
+	var total int
+	if err := conn.QueryRowContext(ctx, countSQL, countArgs...).Scan(&total); err != nil {
+		return Page[T]{}, translateError(err, "count "+table)
+	}
+
+	rows, err := conn.QueryContext(ctx, pageSQL, b.args...)
+	if err != nil {
+		return Page[T]{}, translateError(err, "list "+table)
+	}
+	defer rows.Close()
+
+	items := []T{}
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return Page[T]{}, translateError(err, "scan "+table)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return Page[T]{}, translateError(err, "list "+table)
+	}
+
+	return finishPage(items, total, req, column), nil
+}
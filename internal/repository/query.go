@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"saas-go-app/internal/models"
+)
+
+// ErrInvalidSort is returned when a sort field is not in the whitelist
+var ErrInvalidSort = errors.New("invalid sort field")
+
+// Sort orders a list by a single whitelisted field, with id as tie-breaker
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// String returns the sort in query-string form, e.g. "-created_at"
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// DefaultSort lists the newest records first
+var DefaultSort = Sort{Field: "created_at", Desc: true}
+
+// sortColumn describes a sortable field. key renders the field so that
+// string comparison matches SQL ordering and Postgres can cast it back
+// with cast; this keeps cursors opaque but type-correct.
+type sortColumn[T any] struct {
+	column string
+	cast   string
+	key    func(T) string
+	id     func(T) int
+}
+
+// after reports whether item sorts strictly after the cursor position
+func (s sortColumn[T]) after(item T, cursor Cursor, desc bool) bool {
+	key, id := s.key(item), s.id(item)
+	if key != cursor.Value {
+		return (key < cursor.Value) == desc
+	}
+	return (id < cursor.ID) == desc
+}
+
+// less orders two items ascending by key then id
+func (s sortColumn[T]) less(a, b T) bool {
+	ka, kb := s.key(a), s.key(b)
+	if ka != kb {
+		return ka < kb
+	}
+	return s.id(a) < s.id(b)
+}
+
+// sortItems sorts items in place according to sort
+func sortItems[T any](items []T, column sortColumn[T], desc bool) {
+	sort.Slice(items, func(i, j int) bool {
+		if desc {
+			return column.less(items[j], items[i])
+		}
+		return column.less(items[i], items[j])
+	})
+}
+
+// timeKey renders timestamps at fixed width so they compare lexically
+func timeKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000000")
+}
+
+// intKey renders non-negative integers at fixed width so they compare lexically
+func intKey(n int) string {
+	return fmt.Sprintf("%020d", n)
+}
+
+var customerSortColumns = map[string]sortColumn[models.Customer]{
+	"created_at": {"created_at", "timestamp", func(c models.Customer) string { return timeKey(c.CreatedAt) }, customerID},
+	"updated_at": {"updated_at", "timestamp", func(c models.Customer) string { return timeKey(c.UpdatedAt) }, customerID},
+	"name":       {"name", "text", func(c models.Customer) string { return c.Name }, customerID},
+	"email":      {"email", "text", func(c models.Customer) string { return c.Email }, customerID},
+	"id":         {"id", "integer", func(c models.Customer) string { return intKey(c.ID) }, customerID},
+}
+
+var accountSortColumns = map[string]sortColumn[models.Account]{
+	"created_at":  {"created_at", "timestamp", func(a models.Account) string { return timeKey(a.CreatedAt) }, accountID},
+	"updated_at":  {"updated_at", "timestamp", func(a models.Account) string { return timeKey(a.UpdatedAt) }, accountID},
+	"name":        {"name", "text", func(a models.Account) string { return a.Name }, accountID},
+	"status":      {"status", "text", func(a models.Account) string { return a.Status }, accountID},
+	"customer_id": {"customer_id", "integer", func(a models.Account) string { return intKey(a.CustomerID) }, accountID},
+	"id":          {"id", "integer", func(a models.Account) string { return intKey(a.ID) }, accountID},
+}
+
+func customerID(c models.Customer) int { return c.ID }
+func accountID(a models.Account) int   { return a.ID }
+
+// ParseCustomerSort parses a sort parameter such as "-name" for customer lists
+func ParseCustomerSort(s string) (Sort, error) {
+	return parseSort(s, customerSortColumns)
+}
+
+// ParseAccountSort parses a sort parameter such as "status" for account lists
+func ParseAccountSort(s string) (Sort, error) {
+	return parseSort(s, accountSortColumns)
+}
+
+func parseSort[T any](s string, columns map[string]sortColumn[T]) (Sort, error) {
+	if s == "" {
+		return DefaultSort, nil
+	}
+
+	result := Sort{Field: strings.TrimPrefix(s, "-"), Desc: strings.HasPrefix(s, "-")}
+	if _, ok := columns[result.Field]; !ok {
+		allowed := make([]string, 0, len(columns))
+		for field := range columns {
+			allowed = append(allowed, field)
+		}
+		sort.Strings(allowed)
+		return Sort{}, fmt.Errorf("%w %q (allowed: %s)", ErrInvalidSort, result.Field, strings.Join(allowed, ", "))
+	}
+	return result, nil
+}
+
+// queryBuilder assembles a WHERE clause with positional parameters. Only
+// column names from the sort whitelists are ever interpolated into SQL;
+// every user-supplied value goes through a placeholder.
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg binds a value and returns its placeholder
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// where adds a condition; use arg to bind its values
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+// whereClause returns " WHERE ..." or an empty string
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// listQuery builds the count and page queries for a table. The count query
+// ignores the cursor so Total always reflects the whole filtered list.
+func listQuery[T any](b *queryBuilder, columns, table string, column sortColumn[T], req PageRequest) (countSQL string, countArgs []interface{}, pageSQL string) {
+	countSQL = "SELECT COUNT(*) FROM " + table + b.whereClause()
+	countArgs = append([]interface{}(nil), b.args...)
+
+	direction, comparison := "ASC", ">"
+	if req.Sort.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if req.Page == 0 && req.Cursor != nil {
+		b.where(fmt.Sprintf("(%s, id) %s (%s::%s, %s)",
+			column.column, comparison, b.arg(req.Cursor.Value), column.cast, b.arg(req.Cursor.ID)))
+	}
+
+	pageSQL = "SELECT " + columns + " FROM " + table + b.whereClause() +
+		fmt.Sprintf(" ORDER BY %s %s, id %s", column.column, direction, direction)
+
+	if req.Page > 0 {
+		pageSQL += fmt.Sprintf(" LIMIT %s OFFSET %s", b.arg(req.Limit), b.arg(req.Offset()))
+	} else {
+		// Fetch one extra row to learn whether another page follows
+		pageSQL += " LIMIT " + b.arg(req.Limit+1)
+	}
+
+	return countSQL, countArgs, pageSQL
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// resolveSort validates the sort and cursor of a page request against a
+// whitelist, applying DefaultSort when none was given
+func resolveSort[T any](req PageRequest, columns map[string]sortColumn[T]) (PageRequest, sortColumn[T], error) {
+	if req.Sort.Field == "" {
+		req.Sort = DefaultSort
+	}
+
+	column, ok := columns[req.Sort.Field]
+	if !ok {
+		return req, column, fmt.Errorf("%w %q", ErrInvalidSort, req.Sort.Field)
+	}
+	if req.Cursor != nil && req.Cursor.Sort != req.Sort.String() {
+		return req, column, ErrInvalidCursor
+	}
+	return req, column, nil
+}
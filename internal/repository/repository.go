@@ -0,0 +1,170 @@
+// Package repository provides data access for the API handlers.
+//
+// Each aggregate has a repository interface with a Postgres implementation
+// (PostgresStore) used in production and an in-memory implementation
+// (MemoryStore) used in tests and local experiments.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"saas-go-app/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("record not found")
+
+	// ErrConflict is returned when a write violates a uniqueness constraint
+	ErrConflict = errors.New("record already exists")
+
+	// ErrInvalidReference is returned when a write references a record that does not exist
+	ErrInvalidReference = errors.New("referenced record does not exist")
+
+	// ErrNoTenant is returned when a tenant-scoped repository is called
+	// without an organization in the context
+	ErrNoTenant = errors.New("no organization in context")
+
+	// ErrUnavailable is returned when the database cannot be reached, so
+	// callers can fail fast instead of treating it as an internal error
+	ErrUnavailable = errors.New("database unavailable")
+
+	// ErrQueryCanceled is returned when Postgres cancels a statement,
+	// because it ran past the statement timeout or its context was done
+	ErrQueryCanceled = errors.New("query canceled")
+)
+
+// CustomerFilter narrows a customer list. Zero values are ignored.
+type CustomerFilter struct {
+	// Search fuzzy-matches customer name or email
+	Search        string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// AccountFilter narrows an account list. Zero values are ignored.
+type AccountFilter struct {
+	Status        string
+	CustomerID    int
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// CustomerRepository manages customer records
+type CustomerRepository interface {
+	List(ctx context.Context, filter CustomerFilter, page PageRequest) (Page[models.Customer], error)
+	Get(ctx context.Context, id int) (models.Customer, error)
+	Create(ctx context.Context, req models.CreateCustomerRequest) (models.Customer, error)
+	Update(ctx context.Context, id int, req models.UpdateCustomerRequest) (models.Customer, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// AccountRepository manages account records
+type AccountRepository interface {
+	List(ctx context.Context, filter AccountFilter, page PageRequest) (Page[models.Account], error)
+	Get(ctx context.Context, id int) (models.Account, error)
+	Create(ctx context.Context, req models.CreateAccountRequest) (models.Account, error)
+	Update(ctx context.Context, id int, req models.UpdateAccountRequest) (models.Account, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// UserRepository manages application users
+type UserRepository interface {
+	GetByID(ctx context.Context, id int) (models.User, error)
+	GetByUsername(ctx context.Context, username string) (models.User, error)
+	// Register creates a user together with a new organization they belong to
+	Register(ctx context.Context, username, passwordHash, orgName string) (models.User, models.Organization, error)
+	// SetAdmin grants or removes system administrator access
+	SetAdmin(ctx context.Context, id int, admin bool) error
+}
+
+// OrganizationRepository manages organizations and their members
+type OrganizationRepository interface {
+	Create(ctx context.Context, name string, ownerID int) (models.Organization, error)
+	ListForUser(ctx context.Context, userID int) ([]models.Organization, error)
+	// MemberRole returns the user's role in the organization, or
+	// ErrNotFound if they are not a member
+	MemberRole(ctx context.Context, orgID, userID int) (string, error)
+	SetMemberRole(ctx context.Context, orgID, userID int, role string) error
+	ListMembers(ctx context.Context, orgID int) ([]models.Member, error)
+	// AddMember makes the user a member of the organization with role. It
+	// returns ErrConflict if they already are one and ErrInvalidReference
+	// if the user does not exist.
+	AddMember(ctx context.Context, orgID, userID int, role string) (models.Member, error)
+}
+
+// TokenRepository stores refresh tokens and revoked access tokens
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token models.RefreshToken) (models.RefreshToken, error)
+	// GetRefreshToken looks a refresh token up by hash, including used and
+	// revoked ones so callers can detect reuse
+	GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error)
+	// RotateRefreshToken marks the token usedID as used and stores next. It
+	// returns ErrConflict if usedID was already used or revoked.
+	RotateRefreshToken(ctx context.Context, usedID int64, next models.RefreshToken) (models.RefreshToken, error)
+	RevokeTokenFamily(ctx context.Context, familyID string) error
+	// RevokeAccessToken denylists jti until expiresAt, and forgets tokens
+	// that have expired since, which no longer need denying
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// APIKeyRepository manages organization API keys. List, Get, Create and
+// Revoke are tenant-scoped; GetByPrefix and Touch serve authentication,
+// before the organization is known.
+type APIKeyRepository interface {
+	List(ctx context.Context) ([]models.APIKey, error)
+	Get(ctx context.Context, id int) (models.APIKey, error)
+	Create(ctx context.Context, key models.APIKey) (models.APIKey, error)
+	Revoke(ctx context.Context, id int) error
+	GetByPrefix(ctx context.Context, prefix string) (models.APIKey, error)
+	// Touch records that the key was used, at most about once a minute
+	Touch(ctx context.Context, id int) error
+}
+
+// SigningKeyRepository stores the keys used to sign access tokens
+type SigningKeyRepository interface {
+	// ListSigningKeys returns all keys, newest first
+	ListSigningKeys(ctx context.Context) ([]models.SigningKey, error)
+	CreateSigningKey(ctx context.Context, key models.SigningKey) error
+	DeleteSigningKeysBefore(ctx context.Context, before time.Time) error
+}
+
+// AnalyticsRepository runs read-only reporting queries
+type AnalyticsRepository interface {
+	Overview(ctx context.Context) (models.AnalyticsOverview, error)
+	// CustomerSummary returns ErrNotFound unless the customer belongs to
+	// the organization in ctx
+	CustomerSummary(ctx context.Context, customerID int) (models.CustomerAnalytics, error)
+	// Timeseries returns the stored daily values of metric between from and
+	// to (inclusive days), ordered by dimension and date
+	Timeseries(ctx context.Context, metric string, from, to time.Time) ([]models.MetricPoint, error)
+}
+
+// DailyMetricsRepository writes the daily_metrics aggregates. It is not
+// tenant-scoped: the aggregation job computes every organization at once.
+type DailyMetricsRepository interface {
+	// AggregateDay computes the metrics of every organization as of the end
+	// of day (UTC) and upserts them, returning their totals over every
+	// organization. Running it again for the same day overwrites the
+	// previous values.
+	AggregateDay(ctx context.Context, day time.Time) (models.DailyTotals, error)
+}
+
+// Store groups the repositories backed by a single data source.
+// Customer, account and analytics repositories are tenant-scoped: they only
+// see rows of the organization carried in the context (see package tenant)
+// and return ErrNoTenant when there is none.
+type Store interface {
+	Customers() CustomerRepository
+	Accounts() AccountRepository
+	Users() UserRepository
+	Organizations() OrganizationRepository
+	Tokens() TokenRepository
+	APIKeys() APIKeyRepository
+	SigningKeys() SigningKeyRepository
+	Analytics() AnalyticsRepository
+	DailyMetrics() DailyMetricsRepository
+}
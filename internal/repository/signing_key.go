@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"saas-go-app/internal/models"
+)
+
+type postgresSigningKeys struct {
+	db *sql.DB
+}
+
+func (r *postgresSigningKeys) ListSigningKeys(ctx context.Context) ([]models.SigningKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT kid, algorithm, private_key, created_at FROM signing_keys ORDER BY created_at DESC, kid",
+	)
+	if err != nil {
+		return nil, translateError(err, "list signing keys")
+	}
+	defer rows.Close()
+
+	keys := []models.SigningKey{}
+	for rows.Next() {
+		var key models.SigningKey
+		if err := rows.Scan(&key.KID, &key.Algorithm, &key.PrivateKey, &key.CreatedAt); err != nil {
+			return nil, translateError(err, "scan signing key")
+		}
+		keys = append(keys, key)
+	}
+	return keys, translateError(rows.Err(), "list signing keys")
+}
+
+func (r *postgresSigningKeys) CreateSigningKey(ctx context.Context, key models.SigningKey) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO signing_keys (kid, algorithm, private_key, created_at) VALUES ($1, $2, $3, $4)",
+		key.KID, key.Algorithm, key.PrivateKey, key.CreatedAt,
+	)
+	return translateError(err, "create signing key")
+}
+
+func (r *postgresSigningKeys) DeleteSigningKeysBefore(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM signing_keys WHERE created_at < $1", before)
+	return translateError(err, "delete signing keys")
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"saas-go-app/internal/models"
+)
+
+type postgresTokens struct {
+	db *sql.DB
+}
+
+const refreshTokenColumns = "id, token_hash, family_id, user_id, org_id, expires_at, used_at, revoked_at, created_at"
+
+func scanRefreshToken(row rowScanner) (models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := row.Scan(
+		&token.ID, &token.TokenHash, &token.FamilyID, &token.UserID, &token.OrgID,
+		&token.ExpiresAt, &token.UsedAt, &token.RevokedAt, &token.CreatedAt,
+	)
+	return token, err
+}
+
+func (r *postgresTokens) CreateRefreshToken(ctx context.Context, token models.RefreshToken) (models.RefreshToken, error) {
+	return insertRefreshToken(ctx, r.db, token)
+}
+
+func insertRefreshToken(ctx context.Context, q querier, token models.RefreshToken) (models.RefreshToken, error) {
+	created, err := scanRefreshToken(q.QueryRowContext(ctx,
+		"INSERT INTO refresh_tokens (token_hash, family_id, user_id, org_id, expires_at) "+
+			"VALUES ($1, $2, $3, $4, $5) RETURNING "+refreshTokenColumns,
+		token.TokenHash, token.FamilyID, token.UserID, token.OrgID, token.ExpiresAt,
+	))
+	return created, translateError(err, "create refresh token")
+}
+
+func (r *postgresTokens) GetRefreshToken(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	token, err := scanRefreshToken(r.db.QueryRowContext(ctx,
+		"SELECT "+refreshTokenColumns+" FROM refresh_tokens WHERE token_hash = $1",
+		tokenHash,
+	))
+	return token, translateError(err, "get refresh token")
+}
+
+func (r *postgresTokens) RotateRefreshToken(ctx context.Context, usedID int64, next models.RefreshToken) (models.RefreshToken, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.RefreshToken{}, translateError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	// The guard on used_at makes concurrent rotations of one token race for
+	// a single winner; the loser sees reuse
+	result, err := tx.ExecContext(ctx,
+		"UPDATE refresh_tokens SET used_at = CURRENT_TIMESTAMP WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL",
+		usedID,
+	)
+	if err != nil {
+		return models.RefreshToken{}, translateError(err, "use refresh token")
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.RefreshToken{}, ErrConflict
+	}
+
+	created, err := insertRefreshToken(ctx, tx, next)
+	if err != nil {
+		return created, err
+	}
+	return created, translateError(tx.Commit(), "commit refresh token rotation")
+}
+
+func (r *postgresTokens) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE family_id = $1 AND revoked_at IS NULL",
+		familyID,
+	)
+	return translateError(err, "revoke refresh token family")
+}
+
+func (r *postgresTokens) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	// Pruning on each revocation keeps the table to tokens still live
+	_, err := r.db.ExecContext(ctx,
+		"WITH expired AS (DELETE FROM revoked_tokens WHERE expires_at <= $3) "+
+			"INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING",
+		jti, expiresAt, time.Now(),
+	)
+	return translateError(err, "revoke access token")
+}
+
+func (r *postgresTokens) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)",
+		jti,
+	).Scan(&revoked)
+	return revoked, translateError(err, "check revoked token")
+}
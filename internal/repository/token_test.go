@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRevokeAccessTokenPrunesExpired(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	tokens := store.Tokens()
+
+	if err := tokens.RevokeAccessToken(ctx, "expired", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+	if err := tokens.RevokeAccessToken(ctx, "live", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+
+	if revoked, _ := tokens.IsAccessTokenRevoked(ctx, "live"); !revoked {
+		t.Error("Expected the live token to stay revoked")
+	}
+	if _, ok := store.revoked["expired"]; ok {
+		t.Error("Expected the expired token to be forgotten")
+	}
+}
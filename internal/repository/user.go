@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"saas-go-app/internal/models"
+)
+
+type postgresUsers struct {
+	db *sql.DB
+}
+
+func (r *postgresUsers) GetByID(ctx context.Context, id int) (models.User, error) {
+	var user models.User
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, username, password_hash, is_admin, created_at FROM users WHERE id = $1",
+		id,
+	).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.IsAdmin, &user.CreatedAt)
+	return user, translateError(err, "get user")
+}
+
+func (r *postgresUsers) GetByUsername(ctx context.Context, username string) (models.User, error) {
+	var user models.User
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, username, password_hash, is_admin, created_at FROM users WHERE username = $1",
+		username,
+	).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.IsAdmin, &user.CreatedAt)
+	return user, translateError(err, "get user")
+}
+
+func (r *postgresUsers) Register(ctx context.Context, username, passwordHash, orgName string) (models.User, models.Organization, error) {
+	var user models.User
+	var org models.Organization
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return user, org, translateError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO users (username, password_hash) VALUES ($1, $2) RETURNING id, username, password_hash, is_admin, created_at",
+		username, passwordHash,
+	).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.IsAdmin, &user.CreatedAt)
+	if err != nil {
+		return user, org, translateError(err, "create user")
+	}
+
+	org, err = createOrganization(ctx, tx, orgName, user.ID)
+	if err != nil {
+		return user, org, err
+	}
+
+	return user, org, translateError(tx.Commit(), "commit registration")
+}
+
+func (r *postgresUsers) SetAdmin(ctx context.Context, id int, admin bool) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE users SET is_admin = $2 WHERE id = $1", id, admin)
+	if err != nil {
+		return translateError(err, "set user admin")
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type postgresOrganizations struct {
+	db *sql.DB
+}
+
+func (r *postgresOrganizations) Create(ctx context.Context, name string, ownerID int) (models.Organization, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Organization{}, translateError(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	org, err := createOrganization(ctx, tx, name, ownerID)
+	if err != nil {
+		return org, err
+	}
+	return org, translateError(tx.Commit(), "commit organization")
+}
+
+func (r *postgresOrganizations) ListForUser(ctx context.Context, userID int) ([]models.Organization, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT o.id, o.name, m.role, o.created_at FROM organizations o "+
+			"JOIN organization_members m ON m.organization_id = o.id "+
+			"WHERE m.user_id = $1 ORDER BY o.id",
+		userID,
+	)
+	if err != nil {
+		return nil, translateError(err, "list organizations")
+	}
+	defer rows.Close()
+
+	orgs := []models.Organization{}
+	for rows.Next() {
+		var org models.Organization
+		if err := rows.Scan(&org.ID, &org.Name, &org.Role, &org.CreatedAt); err != nil {
+			return nil, translateError(err, "scan organization")
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, translateError(rows.Err(), "list organizations")
+}
+
+func (r *postgresOrganizations) MemberRole(ctx context.Context, orgID, userID int) (string, error) {
+	var role string
+	err := r.db.QueryRowContext(ctx,
+		"SELECT role FROM organization_members WHERE organization_id = $1 AND user_id = $2",
+		orgID, userID,
+	).Scan(&role)
+	return role, translateError(err, "get member role")
+}
+
+func (r *postgresOrganizations) SetMemberRole(ctx context.Context, orgID, userID int, role string) error {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE organization_members SET role = $3 WHERE organization_id = $1 AND user_id = $2",
+		orgID, userID, role,
+	)
+	if err != nil {
+		return translateError(err, "set member role")
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *postgresOrganizations) ListMembers(ctx context.Context, orgID int) ([]models.Member, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT m.user_id, u.username, m.role FROM organization_members m "+
+			"JOIN users u ON u.id = m.user_id "+
+			"WHERE m.organization_id = $1 ORDER BY m.user_id",
+		orgID,
+	)
+	if err != nil {
+		return nil, translateError(err, "list members")
+	}
+	defer rows.Close()
+
+	members := []models.Member{}
+	for rows.Next() {
+		var member models.Member
+		if err := rows.Scan(&member.UserID, &member.Username, &member.Role); err != nil {
+			return nil, translateError(err, "scan member")
+		}
+		members = append(members, member)
+	}
+	return members, translateError(rows.Err(), "list members")
+}
+
+func (r *postgresOrganizations) AddMember(ctx context.Context, orgID, userID int, role string) (models.Member, error) {
+	var member models.Member
+	err := r.db.QueryRowContext(ctx,
+		"WITH added AS (INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3) RETURNING user_id, role) "+
+			"SELECT added.user_id, u.username, added.role FROM added JOIN users u ON u.id = added.user_id",
+		orgID, userID, role,
+	).Scan(&member.UserID, &member.Username, &member.Role)
+	return member, translateError(err, "add member")
+}
+
+// createOrganization inserts an organization with ownerID as its owner
+func createOrganization(ctx context.Context, q querier, name string, ownerID int) (models.Organization, error) {
+	var org models.Organization
+	err := q.QueryRowContext(ctx,
+		"INSERT INTO organizations (name) VALUES ($1) RETURNING id, name, created_at",
+		name,
+	).Scan(&org.ID, &org.Name, &org.CreatedAt)
+	if err != nil {
+		return org, translateError(err, "create organization")
+	}
+
+	_, err = q.ExecContext(ctx,
+		"INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, 'owner')",
+		org.ID, ownerID,
+	)
+	if err != nil {
+		return org, translateError(err, "add organization owner")
+	}
+	org.Role = "owner"
+	return org, nil
+}
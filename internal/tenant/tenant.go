@@ -0,0 +1,21 @@
+// Package tenant carries the caller's active organization through a request.
+//
+// The auth middleware stores the organization from the JWT claims in the
+// request context; repositories read it back to scope every query and to
+// set the Postgres row-level security variable.
+package tenant
+
+import "context"
+
+type contextKey struct{}
+
+// WithOrgID returns a copy of ctx scoped to the given organization
+func WithOrgID(ctx context.Context, orgID int) context.Context {
+	return context.WithValue(ctx, contextKey{}, orgID)
+}
+
+// OrgID returns the organization ctx is scoped to, if any
+func OrgID(ctx context.Context) (int, bool) {
+	orgID, ok := ctx.Value(contextKey{}).(int)
+	return orgID, ok && orgID > 0
+}
@@ -0,0 +1,123 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are recorded for
+// every HTTP request, SQL statement and job, and exported over OTLP or, for
+// development and tests, written to standard output.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Exporters accepted by Setup. ExporterOTLP sends spans over OTLP/HTTP to
+// the endpoint in OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, with the headers in
+// OTEL_EXPORTER_OTLP_HEADERS.
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+// serviceName names the service in spans, as set by Install
+var serviceName = "saas-go-app"
+
+// ValidExporter reports whether Setup accepts the exporter name
+func ValidExporter(name string) bool {
+	switch strings.ToLower(name) {
+	case ExporterOTLP, ExporterStdout, ExporterNone:
+		return true
+	}
+	return false
+}
+
+// Setup installs the global tracer provider, exporting spans of
+// serviceName through exporter, and the W3C trace context propagator. The
+// returned function flushes buffered spans and must be called before the
+// process exits.
+func Setup(ctx context.Context, exporter, serviceName string) (func(context.Context) error, error) {
+	var exp sdktrace.SpanExporter
+	switch strings.ToLower(exporter) {
+	case ExporterOTLP:
+		var err error
+		if exp, err = otlptracehttp.New(ctx); err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+	case ExporterStdout:
+		var err error
+		if exp, err = NewStdoutExporter(os.Stdout); err != nil {
+			return nil, err
+		}
+	case ExporterNone, "":
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, use %s, %s or %s", exporter, ExporterOTLP, ExporterStdout, ExporterNone)
+	}
+	return Install(ctx, exp, serviceName)
+}
+
+// NewStdoutExporter returns an exporter writing spans to w as JSON, one
+// object per span
+func NewStdoutExporter(w io.Writer) (sdktrace.SpanExporter, error) {
+	exp, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+	}
+	return exp, nil
+}
+
+// Install installs the global tracer provider, exporting spans of the
+// service name through exp, and the W3C trace context propagator. Without
+// an exporter spans are not recorded, but trace context is still passed
+// on from incoming requests to jobs. OTEL_TRACES_SAMPLER and
+// OTEL_RESOURCE_ATTRIBUTES are honoured.
+func Install(ctx context.Context, exp sdktrace.SpanExporter, name string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	serviceName = name
+	if exp == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []resource.Option{
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(name)),
+	}
+	// Heroku names each dyno, such as web.1
+	if dyno := os.Getenv("DYNO"); dyno != "" {
+		opts = append(opts, resource.WithAttributes(semconv.ServiceInstanceID(dyno)))
+	}
+	res, err := resource.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the service: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Middleware starts a server span for every request, continuing the trace
+// of a traceparent header, named after the route template. The span is in
+// the request's context, so SQL statements and enqueued jobs join it.
+func Middleware() gin.HandlerFunc {
+	return otelgin.Middleware(serviceName,
+		// Prometheus scrapes would drown out real requests
+		otelgin.WithFilter(func(r *http.Request) bool { return r.URL.Path != "/metrics" }),
+	)
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddlewareContinuesTrace(t *testing.T) {
+	var out bytes.Buffer
+	exporter, err := NewStdoutExporter(&out)
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+	shutdown, err := Install(context.Background(), exporter, "tracing-test")
+	if err != nil {
+		t.Fatalf("Failed to install tracing: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	var handlerSpan trace.SpanContext
+	router.GET("/customers/:id", func(c *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/metrics", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/customers/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to flush spans: %v", err)
+	}
+
+	if handlerSpan.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the handler to continue the caller's trace, got %s", handlerSpan.TraceID())
+	}
+
+	var names []string
+	for decoder := json.NewDecoder(&out); decoder.More(); {
+		var span struct {
+			Name   string
+			Parent struct{ SpanID string }
+		}
+		if err := decoder.Decode(&span); err != nil {
+			t.Fatalf("Failed to decode span: %v", err)
+		}
+		names = append(names, span.Name)
+		if span.Parent.SpanID != "00f067aa0ba902b7" {
+			t.Errorf("Expected span %s to be a child of the caller's span, got parent %s", span.Name, span.Parent.SpanID)
+		}
+	}
+	if len(names) != 1 || names[0] != "GET /customers/:id" {
+		t.Errorf("Expected one span named after the route, got %v", names)
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), "zipkin", "test"); err == nil {
+		t.Error("Expected an unknown exporter to be rejected")
+	}
+	if !ValidExporter("OTLP") || ValidExporter("zipkin") {
+		t.Error("Expected exporter names to be checked case-insensitively")
+	}
+}
@@ -1,23 +1,20 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
-	"net/http"
+	"log/slog"
 	"os"
-
-	"saas-go-app/internal/api"
-	"saas-go-app/internal/auth"
-	"saas-go-app/internal/db"
-	"saas-go-app/internal/jobs"
-
-	"github.com/gin-gonic/gin"
-	"github.com/hibiken/asynq"
-	"github.com/joho/godotenv"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
-	
-	_ "saas-go-app/docs" // Swagger docs
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"saas-go-app/internal/config"
+	"saas-go-app/internal/logging"
+	"saas-go-app/internal/tracing"
 )
 
 // @title           SaaS Go App API
@@ -39,172 +36,88 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token. Example: "Bearer {token}"
 
-func main() {
-	// Load environment variables from .env file (if it exists)
-	_ = godotenv.Load()
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+// @description Type "ApiKey" followed by a space and an API key. Example: "ApiKey sk_..."
 
-	// Initialize JWT
-	if err := auth.InitJWT(); err != nil {
-		log.Fatal("Failed to initialize JWT:", err)
-	}
+// command is a subcommand of the binary
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, cfg *config.Config, args []string) error
+}
 
-	// Initialize database connections
-	if err := db.InitPrimaryDB(); err != nil {
-		log.Fatal("Failed to initialize primary database:", err)
-	}
-	defer db.CloseDB()
+var commands = []command{
+	{"serve", "Run the HTTP server (the default)", runServe},
+	{"worker", "Run background jobs without serving HTTP", runWorker},
+	{"migrate", "Apply or roll back database migrations", runMigrate},
+	{"seed", "Seed sample data if the database is empty", runSeed},
+	{"reseed", "Clear all customers and accounts and seed sample data", runReseed},
+	{"user", "Manage users (user create)", runUser},
+	{"token", "Issue access tokens (token issue)", runToken},
+}
 
-	if err := db.InitAnalyticsDB(); err != nil {
-		log.Printf("Warning: Failed to initialize analytics database: %v", err)
-	}
+func main() {
+	flag.Usage = usage
 
-	// Create database tables
-	if err := db.CreateTables(); err != nil {
-		log.Fatal("Failed to create database tables:", err)
+	// Load configuration from the environment, .env and the config file
+	cfg := config.ParseFlags()
+	if err := logging.Setup(cfg.Log.Format, cfg.Log.Level); err != nil {
+		log.Fatal(err)
 	}
 
-	// Seed database with sample data if SEED_DATA is set
-	if os.Getenv("SEED_DATA") == "true" {
-		if err := db.SeedDataIfEmpty(); err != nil {
-			log.Printf("Warning: Failed to seed database: %v", err)
+	// Stop on SIGTERM, which Heroku sends on every deploy and restart, or Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// A second signal kills the process without waiting
+		<-ctx.Done()
+		stop()
+	}()
+
+	// Export traces; buffered spans are flushed before exiting
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.Exporter, cfg.Tracing.ServiceName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	flushTraces := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Warn("Failed to flush traces", "error", err)
 		}
 	}
 
-	// Initialize background job processor
-	redisURL := os.Getenv("REDIS_URL")
-	if redisURL != "" {
-		srv := asynq.NewServer(
-			asynq.RedisClientOpt{Addr: redisURL},
-			asynq.Config{
-				Concurrency: 10,
-				Queues: map[string]int{
-					"critical": 6,
-					"default":  3,
-					"low":      1,
-				},
-			},
-		)
-
-		mux := asynq.NewServeMux()
-		mux.HandleFunc(jobs.TypeAggregateData, jobs.HandleAggregationTask)
-
-		go func() {
-			log.Println("Starting background job processor...")
-			if err := srv.Run(mux); err != nil {
-				log.Fatalf("Failed to start background job processor: %v", err)
-			}
-		}()
-	} else {
-		log.Println("REDIS_URL not set, background jobs will not be processed")
+	args := flag.Args()
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
 	}
-
-	// Set up Gin router
-	router := gin.Default()
-
-	// Serve static files from frontend build (if it exists)
-	// In production, the frontend should be built and placed in web/frontend/dist
-	if _, err := os.Stat("web/frontend/dist"); err == nil {
-		// Serve static files
-		router.Static("/assets", "web/frontend/dist/assets")
-		router.StaticFile("/favicon.ico", "web/frontend/dist/favicon.ico")
-		
-		// Serve index.html for root route
-		router.GET("/", func(c *gin.Context) {
-			c.File("web/frontend/dist/index.html")
-		})
-		
-		// Serve index.html for all other non-API routes (SPA routing)
-		router.NoRoute(func(c *gin.Context) {
-			path := c.Request.URL.Path
-			// Don't serve frontend for API routes, health, or metrics
-			if len(path) >= 4 && path[:4] == "/api" {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
-			} else if path == "/health" || path == "/metrics" {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
-			} else {
-				// Serve the SPA index.html for all other routes
-				c.File("web/frontend/dist/index.html")
+	for _, cmd := range commands {
+		if cmd.name == name {
+			err := cmd.run(ctx, cfg, args)
+			flushTraces()
+			if err != nil {
+				slog.Error("Command failed", "command", name, "error", err)
+				os.Exit(1)
 			}
-		})
-	} else {
-		// If frontend is not built, show API info at root
-		router.GET("/", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"message": "SaaS Go App API",
-				"version": "1.0.0",
-				"note": "Frontend not built. Run 'cd web/frontend && npm install && npm run build' to build the frontend.",
-				"endpoints": gin.H{
-					"health": "/health",
-					"metrics": "/metrics",
-					"auth": gin.H{
-						"login": "POST /api/auth/login",
-						"register": "POST /api/auth/register",
-					},
-					"customers": "GET, POST, PUT, DELETE /api/customers",
-					"accounts": "GET, POST, PUT, DELETE /api/accounts",
-					"analytics": "GET /api/analytics",
-				},
-			})
-		})
-	}
-
-	// Prometheus metrics endpoint
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
-
-	// Health check endpoint
-	router.GET("/health", api.HealthCheck)
-
-	// Swagger documentation
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	// Public routes
-	apiRoutes := router.Group("/api")
-	{
-		apiRoutes.POST("/auth/login", api.Login)
-		apiRoutes.POST("/auth/register", api.Register)
-	}
-
-	// Protected routes
-	protectedRoutes := apiRoutes.Group("")
-	protectedRoutes.Use(auth.AuthMiddleware())
-	{
-		// Customer routes
-		customers := protectedRoutes.Group("/customers")
-		{
-			customers.GET("", api.GetCustomers)
-			customers.GET("/:id", api.GetCustomer)
-			customers.POST("", api.CreateCustomer)
-			customers.PUT("/:id", api.UpdateCustomer)
-			customers.DELETE("/:id", api.DeleteCustomer)
-		}
-
-		// Account routes
-		accounts := protectedRoutes.Group("/accounts")
-		{
-			accounts.GET("", api.GetAccounts)
-			accounts.GET("/:id", api.GetAccount)
-			accounts.POST("", api.CreateAccount)
-			accounts.PUT("/:id", api.UpdateAccount)
-			accounts.DELETE("/:id", api.DeleteAccount)
-		}
-
-		// Analytics routes
-		analytics := protectedRoutes.Group("/analytics")
-		{
-			analytics.GET("", api.GetAnalytics)
-			analytics.GET("/customers/:customer_id", api.GetCustomerAnalytics)
+			return
 		}
 	}
 
-	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
 
-	log.Printf("Server starting on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+func usage() {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage: %s [flags] [command] [args]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-8s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Fprintf(&b, "\nRun '%s <command> -h' for the command's arguments.\n\nFlags:\n", os.Args[0])
+	fmt.Fprint(flag.CommandLine.Output(), b.String())
+	flag.PrintDefaults()
 }
-
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"saas-go-app/internal/app"
+	"saas-go-app/internal/config"
+	"saas-go-app/internal/db"
+	"saas-go-app/internal/db/migrations"
+)
+
+const migrateUsage = `Usage: migrate <command>
+
+Commands:
+  up        Apply all pending migrations
+  down N    Roll back the N most recent migrations (default 1)
+  status    Show applied and pending migrations
+  redo      Roll back the most recent migration and apply it again
+`
+
+// runMigrate applies, rolls back or reports on schema migrations
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), migrateUsage) }
+	flags.Parse(args)
+	args = flags.Args()
+
+	if len(args) < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	// Reject bad arguments before connecting
+	n := 1
+	switch args[0] {
+	case "up", "status", "redo":
+	case "down":
+		if len(args) > 1 {
+			var err error
+			n, err = strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid number of migrations to roll back: %q", args[1])
+			}
+		}
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	// Initialize database connection
+	if err := db.InitPrimaryDB(ctx, cfg.Database.URL, app.PoolOptions(cfg.Database.Pool)); err != nil {
+		return fmt.Errorf("failed to initialize primary database: %w", err)
+	}
+	defer db.CloseDB()
+
+	migrator, err := db.NewMigrator(db.PrimaryDB, migrations.FS)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+		slog.Info("Applied migrations", "count", applied)
+
+	case "down":
+		rolledBack, err := migrator.Down(ctx, n)
+		if err != nil {
+			return fmt.Errorf("rollback failed: %w", err)
+		}
+		slog.Info("Rolled back migrations", "count", rolledBack)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read migration status: %w", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", status.Version, status.Name, state)
+		}
+
+	case "redo":
+		if err := migrator.Redo(ctx); err != nil {
+			return fmt.Errorf("redo failed: %w", err)
+		}
+		slog.Info("Redo completed")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"saas-go-app/internal/app"
+	"saas-go-app/internal/config"
+	"saas-go-app/internal/db"
+)
+
+// runSeed seeds sample data if the database has no customers
+func runSeed(ctx context.Context, cfg *config.Config, args []string) error {
+	return seed(ctx, cfg, args, "seed", db.SeedDataIfEmpty)
+}
+
+// runReseed clears existing data and seeds sample data
+func runReseed(ctx context.Context, cfg *config.Config, args []string) error {
+	return seed(ctx, cfg, args, "reseed", db.ClearAndReseed)
+}
+
+// seed applies pending migrations, in case the database is new, then
+// seeds with fn. SEED_PERFORMANCE_DATA and friends select the data.
+func seed(ctx context.Context, cfg *config.Config, args []string, name string, fn func(context.Context, db.SeedOptions) error) error {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Parse(args)
+
+	if err := app.Connect(ctx, cfg); err != nil {
+		return err
+	}
+	defer db.CloseDB()
+
+	if err := db.RunMigrations(ctx); err != nil {
+		return fmt.Errorf("failed to run database migrations: %w", err)
+	}
+
+	if err := fn(ctx, app.SeedOptions(cfg)); err != nil {
+		return fmt.Errorf("failed to seed database: %w", err)
+	}
+
+	slog.Info("Database seeded successfully")
+	return nil
+}
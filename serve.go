@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"saas-go-app/internal/app"
+	"saas-go-app/internal/config"
+	"saas-go-app/internal/db"
+	"saas-go-app/internal/jobs"
+)
+
+// runServe serves the API and frontend until ctx is done, then drains
+func runServe(ctx context.Context, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	flags.Parse(args)
+
+	// Initialize database connections
+	if err := app.Connect(ctx, cfg); err != nil {
+		return err
+	}
+
+	// Apply pending schema migrations
+	if err := db.RunMigrations(ctx); err != nil {
+		db.CloseDB()
+		return fmt.Errorf("failed to run database migrations: %w", err)
+	}
+
+	// Seed database with sample data if SEED_DATA is set
+	if cfg.Seed.OnStartup {
+		// Check if we should force reseed (clears existing data first)
+		if cfg.Seed.Force {
+			if err := db.ClearAndReseed(ctx, app.SeedOptions(cfg)); err != nil {
+				slog.Warn("Failed to clear and reseed database", "error", err)
+			}
+		} else {
+			if err := db.SeedDataIfEmpty(ctx, app.SeedOptions(cfg)); err != nil {
+				slog.Warn("Failed to seed database", "error", err)
+			}
+		}
+	}
+
+	// Keep measuring follower lag to route analytics reads
+	go db.Analytics.Run(ctx, cfg.Database.FollowerCheckInterval)
+
+	store := app.NewStore()
+	if err := app.InitAuth(ctx, cfg, store); err != nil {
+		db.CloseDB()
+		return err
+	}
+
+	// The in-process queue is only reachable from this process, so it
+	// always runs its own tasks. With Redis, workers run them.
+	process := cfg.Jobs.ProcessInWeb || cfg.Jobs.RedisURL == ""
+	if !process {
+		slog.Info("Background jobs are enqueued for the worker process")
+	}
+	queue, scheduler, err := app.StartJobs(ctx, cfg, store, process)
+	if err != nil {
+		db.CloseDB()
+		return err
+	}
+
+	router := app.NewRouter(store, scheduler, queue.Inspector(), app.NewHealthChecks(cfg, queue.Inspector()), app.Timeouts(cfg))
+
+	// Start server
+	server := &http.Server{Addr: fmt.Sprintf(":%d", cfg.Server.Port), Handler: router}
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server starting", "port", cfg.Server.Port)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serverErr:
+		err = fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdown(server, queue, cfg.Server.ShutdownTimeout)
+	return err
+}
+
+// shutdown stops accepting requests and waits for in-flight ones, then
+// lets running jobs finish, then closes the database pools they use. One
+// deadline covers both waits.
+func shutdown(server *http.Server, queue jobs.Queue, timeout time.Duration) {
+	slog.Info("Shutting down, waiting for requests and jobs to finish", "timeout", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Warn("HTTP server did not drain", "error", err)
+	}
+	app.StopJobs(ctx, queue)
+	db.CloseDB()
+	slog.Info("Shutdown complete")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"saas-go-app/internal/app"
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/config"
+	"saas-go-app/internal/db"
+	"saas-go-app/internal/repository"
+)
+
+const tokenUsage = `Usage: token issue --username NAME [--org ID]
+
+Prints an access token for the user, valid for %s, for use with
+"Authorization: Bearer <token>".
+`
+
+// runToken issues access tokens for scripts and debugging without logging in
+func runToken(ctx context.Context, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("token issue", flag.ExitOnError)
+	username := flags.String("username", "", "user to issue the token for")
+	orgID := flags.Int("org", 0, "organization to scope the token to (default: the user's first)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), tokenUsage, auth.AccessTokenTTL)
+		flags.PrintDefaults()
+	}
+
+	if len(args) < 1 || args[0] != "issue" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	flags.Parse(args[1:])
+	if *username == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err := app.Connect(ctx, cfg); err != nil {
+		return err
+	}
+	defer db.CloseDB()
+	store := app.NewStore()
+
+	// Sign with the keys the servers publish
+	if err := app.InitAuth(ctx, cfg, store); err != nil {
+		return err
+	}
+
+	user, err := store.Users().GetByUsername(ctx, *username)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("user %s does not exist", *username)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	orgs, err := store.Organizations().ListForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list organizations: %w", err)
+	}
+	for _, org := range orgs {
+		if *orgID != 0 && org.ID != *orgID {
+			continue
+		}
+		token, err := auth.GenerateToken(auth.Identity{
+			UserID:   user.ID,
+			Username: user.Username,
+			OrgID:    org.ID,
+			Role:     auth.Role(org.Role),
+			Admin:    user.IsAdmin,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to generate token: %w", err)
+		}
+		fmt.Println(token)
+		return nil
+	}
+	return fmt.Errorf("user %s is not a member of organization %d", *username, *orgID)
+}
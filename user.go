@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"saas-go-app/internal/app"
+	"saas-go-app/internal/auth"
+	"saas-go-app/internal/config"
+	"saas-go-app/internal/db"
+	"saas-go-app/internal/repository"
+)
+
+const userUsage = `Usage: user create --username NAME [--organization NAME] [--admin]
+
+The password is read from standard input.
+`
+
+// runUser creates users, for example the first system administrator
+func runUser(ctx context.Context, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("user create", flag.ExitOnError)
+	username := flags.String("username", "", "username to create")
+	orgName := flags.String("organization", "", "name of the user's organization (default \"<username>'s Organization\")")
+	admin := flags.Bool("admin", false, "make the user a system administrator")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), userUsage)
+		flags.PrintDefaults()
+	}
+
+	if len(args) < 1 || args[0] != "create" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	flags.Parse(args[1:])
+	if *username == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	// Keep passwords out of shell history and process listings
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	password = strings.TrimRight(password, "\r\n")
+	if err != nil && password == "" {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+	if len(password) < 6 {
+		return errors.New("password must be at least 6 characters")
+	}
+
+	passwordHash, err := auth.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if *orgName == "" {
+		*orgName = *username + "'s Organization"
+	}
+
+	if err := app.Connect(ctx, cfg); err != nil {
+		return err
+	}
+	defer db.CloseDB()
+	users := app.NewStore().Users()
+
+	user, org, err := users.Register(ctx, *username, passwordHash, *orgName)
+	if errors.Is(err, repository.ErrConflict) {
+		return fmt.Errorf("user %s already exists", *username)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	if *admin {
+		if err := users.SetAdmin(ctx, user.ID, true); err != nil {
+			return fmt.Errorf("failed to make user an administrator: %w", err)
+		}
+	}
+
+	fmt.Printf("Created user %s (id %d) owning organization %q (id %d)\n", user.Username, user.ID, org.Name, org.ID)
+	return nil
+}
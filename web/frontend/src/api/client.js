@@ -21,13 +21,53 @@ apiClient.interceptors.request.use(
   }
 )
 
-// Handle 401 errors (unauthorized)
+const clearSession = () => {
+  localStorage.removeItem('token')
+  localStorage.removeItem('refreshToken')
+  window.location.href = '/login'
+}
+
+// Concurrent 401s share a single refresh request; refresh tokens work once
+let refreshing = null
+
+const refreshSession = () => {
+  if (!refreshing) {
+    refreshing = axios
+      .post('/api/auth/refresh', { refresh_token: localStorage.getItem('refreshToken') })
+      .then((response) => {
+        localStorage.setItem('token', response.data.token)
+        localStorage.setItem('refreshToken', response.data.refresh_token)
+        return response.data.token
+      })
+      .finally(() => {
+        refreshing = null
+      })
+  }
+  return refreshing
+}
+
+// Handle 401 errors (unauthorized): refresh the access token once and retry
 apiClient.interceptors.response.use(
   (response) => response,
-  (error) => {
-    if (error.response?.status === 401) {
-      localStorage.removeItem('token')
-      window.location.href = '/login'
+  async (error) => {
+    const original = error.config
+    if (error.response?.status === 401 && original && !original._retried && !original.url?.startsWith('/auth/')) {
+      if (!localStorage.getItem('refreshToken')) {
+        clearSession()
+        return Promise.reject(error)
+      }
+      original._retried = true
+      try {
+        const token = await refreshSession()
+        original.headers.Authorization = `Bearer ${token}`
+        return apiClient(original)
+      } catch (refreshError) {
+        clearSession()
+        return Promise.reject(refreshError)
+      }
+    }
+    if (error.response?.status === 401 && !original?.url?.startsWith('/auth/login')) {
+      clearSession()
     }
     return Promise.reject(error)
   }
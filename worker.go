@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"saas-go-app/internal/app"
+	"saas-go-app/internal/config"
+	"saas-go-app/internal/db"
+)
+
+// runWorker runs background jobs until ctx is done, then drains. Unless
+// WORKER_PORT is 0 it also serves health checks and /metrics.
+func runWorker(ctx context.Context, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("worker", flag.ExitOnError)
+	flags.Parse(args)
+
+	if cfg.Jobs.RedisURL == "" {
+		return errors.New("the worker needs REDIS_URL to share jobs with the web process; without Redis, serve runs jobs itself")
+	}
+
+	if err := app.Connect(ctx, cfg); err != nil {
+		return err
+	}
+
+	queue, _, err := app.StartJobs(ctx, cfg, app.NewStore(), true)
+	if err != nil {
+		db.CloseDB()
+		return err
+	}
+
+	var server *http.Server
+	serverErr := make(chan error, 1)
+	if cfg.Jobs.WorkerPort != 0 {
+		server = &http.Server{
+			Addr:    fmt.Sprintf(":%d", cfg.Jobs.WorkerPort),
+			Handler: app.NewWorkerRouter(app.NewWorkerHealthChecks(cfg, queue.Inspector())),
+		}
+		go func() {
+			slog.Info("Worker health check and metrics listening", "port", cfg.Jobs.WorkerPort)
+			serverErr <- server.ListenAndServe()
+		}()
+	}
+	slog.Info("Worker started")
+
+	select {
+	case err = <-serverErr:
+		err = fmt.Errorf("failed to start worker health server: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, waiting for jobs to finish", "timeout", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	// Keep reporting while the jobs in flight finish
+	app.StopJobs(shutdownCtx, queue)
+	if server != nil {
+		server.Shutdown(shutdownCtx)
+	}
+	db.CloseDB()
+	slog.Info("Shutdown complete")
+	return err
+}